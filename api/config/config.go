@@ -22,10 +22,20 @@ type StorageConfig struct {
 	ElasticSearch ElasticsearchConfig `yaml:"elasticsearch"`
 }
 
+// CurrencyConfig describe the reporting currency.
+// The collected prices are in USD, and converted by the exchange rate (the value of one USD) of the reporting currency.
+// The rates of the rates file (YAML or JSON) override the static rates
+type CurrencyConfig struct {
+	Code      string             `yaml:"code"`
+	Rates     map[string]float64 `yaml:"rates"`
+	RatesFile string             `yaml:"rates_file"`
+}
+
 // APIConfig present the application config
 type APIConfig struct {
-	LogLevel string        `yaml:"log_level"`
-	Storage  StorageConfig `yaml:"storage"`
+	LogLevel string         `yaml:"log_level"`
+	Storage  StorageConfig  `yaml:"storage"`
+	Currency CurrencyConfig `yaml:"currency"`
 }
 
 // LoadAPI will load yaml file go struct
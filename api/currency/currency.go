@@ -0,0 +1,101 @@
+package currency
+
+import (
+	"errors"
+	"finala/api/config"
+	"io/ioutil"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// DefaultCode defines the currency of the collected prices
+	DefaultCode = "USD"
+)
+
+// ErrMissingExchangeRate returned when the reporting currency has no exchange rate
+var ErrMissingExchangeRate = errors.New("missing exchange rate of the reporting currency")
+
+// Converter describe the conversion of the collected prices to the reporting currency
+type Converter struct {
+	code string
+	rate float64
+}
+
+// NewConverter creates new currency converter by the reporting currency configuration.
+// When no currency code is configured, the prices are reported in USD
+func NewConverter(currencyConfig config.CurrencyConfig) (*Converter, error) {
+
+	code := strings.ToUpper(currencyConfig.Code)
+	if code == "" || code == DefaultCode {
+		return &Converter{code: DefaultCode, rate: 1}, nil
+	}
+
+	rates := map[string]float64{}
+	for rateCode, rate := range currencyConfig.Rates {
+		rates[strings.ToUpper(rateCode)] = rate
+	}
+
+	if currencyConfig.RatesFile != "" {
+		fileRates, err := loadRatesFile(currencyConfig.RatesFile)
+		if err != nil {
+			log.WithError(err).WithField("file", currencyConfig.RatesFile).Error("could not load exchange rates file")
+			return nil, err
+		}
+		for rateCode, rate := range fileRates {
+			rates[strings.ToUpper(rateCode)] = rate
+		}
+	}
+
+	rate, found := rates[code]
+	if !found || rate <= 0 {
+		log.WithField("currency", code).Error("reporting currency has no exchange rate")
+		return nil, ErrMissingExchangeRate
+	}
+
+	log.WithFields(log.Fields{
+		"currency": code,
+		"rate":     rate,
+	}).Info("prices are reported in the configured currency")
+
+	return &Converter{code: code, rate: rate}, nil
+}
+
+// loadRatesFile returns the exchange rates of a YAML or JSON file
+func loadRatesFile(path string) (map[string]float64, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := map[string]float64{}
+	err = yaml.Unmarshal(data, &rates)
+	if err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// Code returns the reporting currency code
+func (c *Converter) Code() string {
+	return c.code
+}
+
+// Convert returns the USD amount in the reporting currency
+func (c *Converter) Convert(amount float64) float64 {
+	return amount * c.rate
+}
+
+// ConvertResourceData converts the price fields (for example, PricePerMonth and EffectivePricePerHour) of the detected resource data
+func (c *Converter) ConvertResourceData(data map[string]interface{}) {
+	for key, value := range data {
+		amount, ok := value.(float64)
+		if ok && strings.Contains(key, "Price") {
+			data[key] = c.Convert(amount)
+		}
+	}
+}
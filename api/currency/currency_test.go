@@ -0,0 +1,85 @@
+package currency_test
+
+import (
+	"finala/api/config"
+	"finala/api/currency"
+	"testing"
+)
+
+func TestNewConverter(t *testing.T) {
+
+	testCases := []struct {
+		name         string
+		config       config.CurrencyConfig
+		expectedCode string
+		expectedRate float64
+		expectedErr  error
+	}{
+		{"default", config.CurrencyConfig{}, "USD", 1, nil},
+		{"usd", config.CurrencyConfig{Code: "usd", Rates: map[string]float64{"USD": 2}}, "USD", 1, nil},
+		{"static_rate", config.CurrencyConfig{Code: "eur", Rates: map[string]float64{"EUR": 0.92}}, "EUR", 0.92, nil},
+		{"file_rate", config.CurrencyConfig{Code: "GBP", RatesFile: "testutils/rates.yaml"}, "GBP", 0.8, nil},
+		{"file_override", config.CurrencyConfig{Code: "EUR", Rates: map[string]float64{"EUR": 0.92}, RatesFile: "testutils/rates.yaml"}, "EUR", 0.9, nil},
+		{"missing_rate", config.CurrencyConfig{Code: "ILS", Rates: map[string]float64{"EUR": 0.92}}, "", 0, currency.ErrMissingExchangeRate},
+		{"invalid_rate", config.CurrencyConfig{Code: "EUR", Rates: map[string]float64{"EUR": 0}}, "", 0, currency.ErrMissingExchangeRate},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+
+			converter, err := currency.NewConverter(test.config)
+			if err != test.expectedErr {
+				t.Fatalf("unexpected error, got %v expected %v", err, test.expectedErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if converter.Code() != test.expectedCode {
+				t.Fatalf("unexpected currency code, got %s expected %s", converter.Code(), test.expectedCode)
+			}
+
+			if converter.Convert(10) != 10*test.expectedRate {
+				t.Fatalf("unexpected converted amount, got %v expected %v", converter.Convert(10), 10*test.expectedRate)
+			}
+		})
+	}
+}
+
+func TestNewConverterMissingFile(t *testing.T) {
+
+	_, err := currency.NewConverter(config.CurrencyConfig{Code: "EUR", RatesFile: "testutils/not_found.yaml"})
+	if err == nil {
+		t.Fatalf("unexpected error, got nil expected error")
+	}
+}
+
+func TestConvertResourceData(t *testing.T) {
+
+	converter, err := currency.NewConverter(config.CurrencyConfig{Code: "EUR", Rates: map[string]float64{"EUR": 0.5}})
+	if err != nil {
+		t.Fatalf("unexpected error, got %v expected nil", err)
+	}
+
+	data := map[string]interface{}{
+		"ResourceID":             "i-123",
+		"PricePerHour":           float64(2),
+		"PricePerMonth":          float64(1460),
+		"EffectivePricePerMonth": float64(730),
+		"CoveragePercentage":     float64(50),
+	}
+	converter.ConvertResourceData(data)
+
+	expected := map[string]interface{}{
+		"ResourceID":             "i-123",
+		"PricePerHour":           float64(1),
+		"PricePerMonth":          float64(730),
+		"EffectivePricePerMonth": float64(365),
+		"CoveragePercentage":     float64(50),
+	}
+	for key, value := range expected {
+		if data[key] != value {
+			t.Fatalf("unexpected %s value, got %v expected %v", key, data[key], value)
+		}
+	}
+}
@@ -40,6 +40,13 @@ func (server *Server) GetSummary(resp http.ResponseWriter, req *http.Request) {
 		return
 
 	}
+
+	for resourceName, resourceSummary := range response {
+		resourceSummary.TotalSpent = server.currency.Convert(resourceSummary.TotalSpent)
+		resourceSummary.EffectiveTotalSpent = server.currency.Convert(resourceSummary.EffectiveTotalSpent)
+		resourceSummary.Currency = server.currency.Code()
+		response[resourceName] = resourceSummary
+	}
 	server.JSONWrite(resp, http.StatusOK, response)
 }
 
@@ -79,6 +86,13 @@ func (server *Server) GetResourceData(resp http.ResponseWriter, req *http.Reques
 		return
 
 	}
+
+	for _, resource := range response {
+		if data, ok := resource["Data"].(map[string]interface{}); ok {
+			server.currency.ConvertResourceData(data)
+		}
+		resource["Currency"] = server.currency.Code()
+	}
 	server.JSONWrite(resp, http.StatusOK, response)
 }
 
@@ -105,6 +119,11 @@ func (server *Server) GetResourceTrends(resp http.ResponseWriter, req *http.Requ
 		return
 
 	}
+
+	for i := range trends {
+		trends[i].CostSum = server.currency.Convert(trends[i].CostSum)
+		trends[i].Currency = server.currency.Code()
+	}
 	server.JSONWrite(resp, http.StatusOK, trends)
 }
 
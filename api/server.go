@@ -12,6 +12,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"finala/api/currency"
 	"finala/api/storage"
 	"finala/serverutil"
 	"finala/version"
@@ -28,17 +29,19 @@ type Server struct {
 	httpserver *http.Server
 	storage    storage.StorageDescriber
 	version    version.VersionManagerDescriptor
+	currency   *currency.Converter
 }
 
-// NewServer returns a new Server
-func NewServer(port int, storage storage.StorageDescriber, version version.VersionManagerDescriptor) *Server {
+// NewServer returns a new Server, the prices of the responses are converted to the currency of the converter
+func NewServer(port int, storage storage.StorageDescriber, version version.VersionManagerDescriptor, currency *currency.Converter) *Server {
 
 	router := mux.NewRouter()
 	corsObj := handlers.AllowedOrigins([]string{"*"})
 	return &Server{
-		router:  router,
-		storage: storage,
-		version: version,
+		router:   router,
+		storage:  storage,
+		version:  version,
+		currency: currency,
 		httpserver: &http.Server{
 			Handler: handlers.CORS(corsObj)(router),
 			Addr:    fmt.Sprintf("0.0.0.0:%d", port),
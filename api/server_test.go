@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"finala/api"
+	"finala/api/config"
+	"finala/api/currency"
 	"finala/api/storage"
 	"finala/api/testutils"
 	"io/ioutil"
@@ -17,10 +19,15 @@ import (
 )
 
 func MockServer() (*api.Server, *testutils.MockStorage) {
+	return MockServerWithCurrency(config.CurrencyConfig{})
+}
+
+func MockServerWithCurrency(currencyConfig config.CurrencyConfig) (*api.Server, *testutils.MockStorage) {
 	version := testutils.NewMockVersion()
 
+	currencyConverter, _ := currency.NewConverter(currencyConfig)
 	mockStorage := testutils.NewMockStorage()
-	server := api.NewServer(9090, mockStorage, version)
+	server := api.NewServer(9090, mockStorage, version, currencyConverter)
 	return server, mockStorage
 }
 
@@ -137,6 +144,37 @@ func TestGetSummary(t *testing.T) {
 
 }
 
+func TestGetSummaryCurrency(t *testing.T) {
+	ms, _ := MockServerWithCurrency(config.CurrencyConfig{Code: "EUR", Rates: map[string]float64{"EUR": 0.5}})
+	ms.BindEndpoints()
+	ms.Serve()
+
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/api/v1/summary/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms.Router().ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	summaryData := map[string]storage.CollectorsSummary{}
+	err = json.Unmarshal(rr.Body.Bytes(), &summaryData)
+	if err != nil {
+		t.Fatalf("Could not parse http response")
+	}
+
+	for resourceName, resourceSummary := range summaryData {
+		if resourceSummary.TotalSpent != 50 {
+			t.Fatalf("unexpected %s total spent, got %v expected %v", resourceName, resourceSummary.TotalSpent, 50)
+		}
+		if resourceSummary.Currency != "EUR" {
+			t.Fatalf("unexpected %s currency, got %s expected %s", resourceName, resourceSummary.Currency, "EUR")
+		}
+	}
+}
+
 func TestGetResourcesData(t *testing.T) {
 	ms, _ := MockServer()
 	ms.BindEndpoints()
@@ -163,33 +163,41 @@ func (sm *StorageManager) GetSummary(executionID string, filters map[string]stri
 			continue
 		}
 
+		// The suppressed resources are counted by all the resource status events (a finish event for each scanned region)
+		suppressedCount := summaryRow.Data.SuppressedCount
+
 		// check if the resource status already exists, if yes we check if have latest event
 		val, found := summary[summaryRow.ResourceName]
 		if found {
+			suppressedCount += val.SuppressedCount
 			if summaryRow.EventTime < val.EventTime {
+				val.SuppressedCount = suppressedCount
+				summary[summaryRow.ResourceName] = val
 				continue
 			}
 			delete(summary, summaryRow.ResourceName)
 		}
 
 		summary[summaryRow.ResourceName] = storage.CollectorsSummary{
-			EventTime:    summaryRow.EventTime,
-			Status:       summaryRow.Data.Status,
-			ResourceName: summaryRow.ResourceName,
-			ErrorMessage: summaryRow.Data.ErrorMessage,
+			EventTime:       summaryRow.EventTime,
+			Status:          summaryRow.Data.Status,
+			ResourceName:    summaryRow.ResourceName,
+			ErrorMessage:    summaryRow.Data.ErrorMessage,
+			SuppressedCount: suppressedCount,
 		}
 	}
 
 	for resourceName, resourceData := range summary {
 		filters["ResourceName"] = resourceName
 		log.WithField("filters", filters).Debug("Going to get resources summary details with the following filters")
-		totalSpent, resourceCount, err := sm.getResourceSummaryDetails(executionID, filters)
+		totalSpent, effectiveTotalSpent, resourceCount, err := sm.getResourceSummaryDetails(executionID, filters)
 
 		if err != nil {
 			continue
 		}
 		newResourceData := resourceData
 		newResourceData.TotalSpent = totalSpent
+		newResourceData.EffectiveTotalSpent = effectiveTotalSpent
 		newResourceData.ResourceCount = resourceCount
 		summary[resourceName] = newResourceData
 
@@ -199,10 +207,11 @@ func (sm *StorageManager) GetSummary(executionID string, filters map[string]stri
 
 }
 
-// getResourceSummaryDetails returns total resource spent and total resources detected
-func (sm *StorageManager) getResourceSummaryDetails(executionID string, filters map[string]string) (float64, int64, error) {
+// getResourceSummaryDetails returns total resource spent, total resource effective spent and total resources detected
+func (sm *StorageManager) getResourceSummaryDetails(executionID string, filters map[string]string) (float64, float64, int64, error) {
 
 	var totalSpent float64
+	var effectiveTotalSpent float64
 	var resourceCount int64
 
 	dynamicMatchQuery := sm.getDynamicMatchQuery(filters, "or")
@@ -212,6 +221,7 @@ func (sm *StorageManager) getResourceSummaryDetails(executionID string, filters
 	searchResult, err := sm.client.Search().
 		Query(elastic.NewBoolQuery().Must(dynamicMatchQuery...)).
 		Aggregation("sum", elastic.NewSumAggregation().Field("Data.PricePerMonth")).
+		Aggregation("effective_sum", elastic.NewSumAggregation().Field("Data.EffectivePricePerMonth")).
 		Size(0).Do(context.Background())
 
 	if err != nil {
@@ -219,7 +229,7 @@ func (sm *StorageManager) getResourceSummaryDetails(executionID string, filters
 			"filters": filters,
 		}).Error("error when trying to get summary details")
 
-		return totalSpent, resourceCount, err
+		return totalSpent, effectiveTotalSpent, resourceCount, err
 	}
 
 	log.WithFields(log.Fields{
@@ -236,7 +246,14 @@ func (sm *StorageManager) getResourceSummaryDetails(executionID string, filters
 		}
 	}
 
-	return totalSpent, resourceCount, nil
+	effectiveResp, ok := searchResult.Aggregations.Terms("effective_sum")
+	if ok {
+		if val, ok := effectiveResp.Aggregations["value"]; ok {
+			effectiveTotalSpent, _ = strconv.ParseFloat(string(val), 64)
+		}
+	}
+
+	return totalSpent, effectiveTotalSpent, resourceCount, nil
 }
 
 // GetExecutions returns collector executions
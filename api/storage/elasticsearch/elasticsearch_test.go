@@ -227,16 +227,17 @@ func TestGetSummary(t *testing.T) {
 
 		switch testutils.GetPostParams(req) {
 		case `{"query":{"bool":{"must":[{"term":{"EventType":"service_status"}},{"term":{"ExecutionID":""}}]}},"size":0}`:
-			response.Hits = &elastic.SearchHits{TotalHits: &elastic.TotalHits{Value: 1}}
-		case `{"query":{"bool":{"must":[{"term":{"EventType":"service_status"}},{"term":{"ExecutionID":""}}]}},"size":1}`:
+			response.Hits = &elastic.SearchHits{TotalHits: &elastic.TotalHits{Value: 2}}
+		case `{"query":{"bool":{"must":[{"term":{"EventType":"service_status"}},{"term":{"ExecutionID":""}}]}},"size":2}`:
 			response.Hits = &elastic.SearchHits{
-				TotalHits: &elastic.TotalHits{Value: 1},
+				TotalHits: &elastic.TotalHits{Value: 2},
 				Hits: []*elastic.SearchHit{
-					{Source: testutils.GetDummyDoc("aws_resource_name", nil)},
+					{Source: testutils.GetDummyDoc("aws_resource_name", map[string]interface{}{"SuppressedCount": 2})},
+					{Source: testutils.GetDummyDoc("aws_resource_name", map[string]interface{}{"SuppressedCount": 1})},
 				},
 			}
-		case `{"aggregations":{"sum":{"sum":{"field":"Data.PricePerMonth"}}},"query":{"bool":{"must":[{"match":{"ResourceName":{"minimum_should_match":"100%","query":"aws_resource_name"}}},{"term":{"ExecutionID":""}},{"term":{"EventType":"resource_detected"}}]}},"size":0}`:
-			response.Aggregations = map[string]json.RawMessage{"sum": []byte(`{"value": 36.5}`)}
+		case `{"aggregations":{"effective_sum":{"sum":{"field":"Data.EffectivePricePerMonth"}},"sum":{"sum":{"field":"Data.PricePerMonth"}}},"query":{"bool":{"must":[{"match":{"ResourceName":{"minimum_should_match":"100%","query":"aws_resource_name"}}},{"term":{"ExecutionID":""}},{"term":{"EventType":"resource_detected"}}]}},"size":0}`:
+			response.Aggregations = map[string]json.RawMessage{"sum": []byte(`{"value": 36.5}`), "effective_sum": []byte(`{"value": 10.95}`)}
 			response.Hits = &elastic.SearchHits{TotalHits: &elastic.TotalHits{Value: 1}}
 		default:
 			t.Fatalf("unexpected request params")
@@ -282,6 +283,14 @@ func TestGetSummary(t *testing.T) {
 		t.Fatalf("unexpected resource count, got %v expected %v", data.TotalSpent, 36.5)
 	}
 
+	if data.EffectiveTotalSpent != 10.95 {
+		t.Fatalf("unexpected effective total spent, got %v expected %v", data.EffectiveTotalSpent, 10.95)
+	}
+
+	if data.SuppressedCount != 3 {
+		t.Fatalf("unexpected suppressed count, got %d expected %d", data.SuppressedCount, 3)
+	}
+
 }
 
 func TestGetResources(t *testing.T) {
@@ -29,21 +29,27 @@ type ExecutionCost struct {
 	ExecutionID        string
 	ExtractedTimestamp int64
 	CostSum            float64
+	Currency           string
 }
 
-// CollectorsSummary defines unused resource summary
+// CollectorsSummary defines unused resource summary.
+// TotalSpent is the on demand spent, EffectiveTotalSpent excludes the spent that is covered by reserved instances and savings plans
 type CollectorsSummary struct {
-	ResourceName  string  `json:"ResourceName"`
-	ResourceCount int64   `json:"ResourceCount"`
-	TotalSpent    float64 `json:"TotalSpent"`
-	Status        int     `json:"Status"`
-	ErrorMessage  string  `json:"ErrorMessage"`
-	EventTime     int64   `json:"-"`
+	ResourceName        string  `json:"ResourceName"`
+	ResourceCount       int64   `json:"ResourceCount"`
+	SuppressedCount     int64   `json:"SuppressedCount"`
+	TotalSpent          float64 `json:"TotalSpent"`
+	EffectiveTotalSpent float64 `json:"EffectiveTotalSpent"`
+	Currency            string  `json:"Currency"`
+	Status              int     `json:"Status"`
+	ErrorMessage        string  `json:"ErrorMessage"`
+	EventTime           int64   `json:"-"`
 }
 
 type SummaryData struct {
-	Status       int    `json:"Status"`
-	ErrorMessage string `json:"ErrorMessage"`
+	Status          int    `json:"Status"`
+	ErrorMessage    string `json:"ErrorMessage"`
+	SuppressedCount int64  `json:"SuppressedCount"`
 }
 
 type Summary struct {
@@ -3,6 +3,7 @@ package cmd
 import (
 	"finala/api"
 	"finala/api/config"
+	"finala/api/currency"
 	"finala/api/storage/elasticsearch"
 	"finala/serverutil"
 	"finala/visibility"
@@ -42,7 +43,12 @@ var apiServer = &cobra.Command{
 			os.Exit(1)
 		}
 
-		apiManager := api.NewServer(port, storage, versionManager)
+		currencyConverter, err := currency.NewConverter(configStruct.Currency)
+		if err != nil {
+			os.Exit(1)
+		}
+
+		apiManager := api.NewServer(port, storage, versionManager, currencyConverter)
 
 		apiStopper := serverutil.RunAll(apiManager).StopFunc
 
@@ -4,16 +4,24 @@ import (
 	"context"
 	"finala/collector"
 	"finala/collector/aws"
+	"finala/collector/aws/pricing"
 	"finala/collector/config"
+	"finala/collector/spool"
 	"finala/request"
 	"finala/visibility"
 	"os"
 	"sync"
 
+	"github.com/dustin/go-humanize"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
+var (
+	// collectorFilter contains the filter flags, a flag that was set overrides the configuration filter
+	collectorFilter config.FilterConfig
+)
+
 // collectorCMD will present the aws analyze command
 var collectorCMD = &cobra.Command{
 	Use:   "collector",
@@ -34,33 +42,137 @@ var collectorCMD = &cobra.Command{
 		// Set application log level
 		visibility.SetLoggingLevel(configStruct.LogLevel)
 
+		overrideFilter(cmd, &configStruct.Filter)
+
 		if len(configStruct.Providers) == 0 {
 			log.Error("Providers not found")
 		}
 
 		// Create HTTP client request
-		req := request.NewHTTPClient()
+		req := request.NewHTTPClientWithRetry(configStruct.APIServer.Retry)
+
+		// Init events spool, unsent events will be written to the disk and sent again on the next run
+		var eventsSpool *spool.Spool
+		spoolConfig := configStruct.APIServer.Spool
+		if spoolConfig.Directory != "" {
+			var maxSize uint64
+			if spoolConfig.MaxSize != "" {
+				maxSize, err = humanize.ParseBytes(spoolConfig.MaxSize)
+				if err != nil {
+					log.WithError(err).WithField("max_size", spoolConfig.MaxSize).Error("could not parse spool max size")
+					os.Exit(1)
+				}
+			}
+
+			eventsSpool, err = spool.NewSpool(spoolConfig.Directory, maxSize)
+			if err != nil {
+				log.WithError(err).WithField("directory", spoolConfig.Directory).Error("could not create events spool")
+				os.Exit(1)
+			}
+		}
+
+		// Init collector output file, the events will be written to the file instead of the api server
+		var output collector.EventsWriter
+		if configStruct.Output.File != "" {
+			fileOutput, err := collector.NewFileOutput(configStruct.Output.File)
+			if err != nil {
+				log.WithError(err).WithField("file", configStruct.Output.File).Error("could not open collector output file")
+				os.Exit(1)
+			}
+			defer fileOutput.Close()
+			output = fileOutput
+		}
 
 		// Init collector manager
-		collectorManager := collector.NewCollectorManager(ctx, &wg, req, configStruct.APIServer.BulkInterval, configStruct.Name, configStruct.APIServer.Addr)
+		collectorManager := collector.NewCollectorManager(ctx, &wg, req, eventsSpool, output, configStruct.APIServer.BulkInterval, configStruct.Name, configStruct.APIServer.Addr)
 
 		// Starting collect data
 		awsProvider := configStruct.Providers["aws"]
 
+		// Expand the organization accounts, in addition to the accounts from the configuration file
+		awsAccounts := awsProvider.Accounts
+		if awsProvider.Organization != nil {
+			organizationAccounts, err := aws.DiscoverOrganizationAccounts(*awsProvider.Organization)
+			if err != nil {
+				log.WithError(err).Error("could not discover organization accounts")
+				os.Exit(1)
+			}
+			awsAccounts = append(awsAccounts, organizationAccounts...)
+		}
+
 		// init metric manager
 		metricManager := collector.NewMetricManager(awsProvider)
 
-		awsManager := aws.NewAnalyzeManager(collectorManager, metricManager, awsProvider.Accounts)
+		// Resources that are marked as intentionally unused by their tags are not reported
+		suppressCollector := collector.NewSuppressCollector(collectorManager, awsProvider.IgnoreTags, awsProvider.SnoozeTags)
+
+		// Load the offline price list, the prices are fetched from the pricing api when it is not configured
+		var priceClient pricing.PricingClientDescreptor
+		if awsProvider.Pricing.OfflineDirectory != "" {
+			priceClient, err = pricing.NewOfflinePriceList(awsProvider.Pricing.OfflineDirectory)
+			if err != nil {
+				log.WithError(err).WithField("directory", awsProvider.Pricing.OfflineDirectory).Error("could not load offline price list")
+				os.Exit(1)
+			}
+		}
+
+		// Init the price cache, shared by all the accounts and regions and kept in the cache file for the next runs
+		priceCache, err := pricing.NewPriceCache(awsProvider.Pricing.CacheFile, awsProvider.Pricing.CacheTTL)
+		if err != nil {
+			log.WithError(err).WithField("file", awsProvider.Pricing.CacheFile).Error("could not load pricing cache")
+			os.Exit(1)
+		}
+
+		awsManager := aws.NewAnalyzeManager(suppressCollector, metricManager, awsAccounts, configStruct.Concurrency, configStruct.Filter, priceClient, priceCache, awsProvider.Pricing.Coverage)
 
 		awsManager.All()
 
+		cacheHits, cacheMisses := priceCache.Stats()
+		log.WithFields(log.Fields{
+			"hits":   cacheHits,
+			"misses": cacheMisses,
+		}).Info("pricing cache statistics")
+
+		err = priceCache.Save()
+		if err != nil {
+			log.WithError(err).WithField("file", awsProvider.Pricing.CacheFile).Error("could not save pricing cache")
+		}
+
 		log.Info("Collector Done. Starting graceful shutdown")
 		cancelFn()
 		wg.Wait()
 	},
 }
 
+// overrideFilter replaces the configuration filter lists with the filter flags that were set
+func overrideFilter(cmd *cobra.Command, filter *config.FilterConfig) {
+
+	if cmd.Flags().Changed("only") {
+		filter.Only = collectorFilter.Only
+	}
+	if cmd.Flags().Changed("skip") {
+		filter.Skip = collectorFilter.Skip
+	}
+	if cmd.Flags().Changed("accounts") {
+		filter.Accounts = collectorFilter.Accounts
+	}
+	if cmd.Flags().Changed("regions") {
+		filter.Regions = collectorFilter.Regions
+	}
+
+	log.WithFields(log.Fields{
+		"only":     filter.Only,
+		"skip":     filter.Skip,
+		"accounts": filter.Accounts,
+		"regions":  filter.Regions,
+	}).Debug("collector filter")
+}
+
 // init will add aws command
 func init() {
+	collectorCMD.Flags().StringSliceVar(&collectorFilter.Only, "only", nil, "scan only the given resource types (for example: rds,ec2)")
+	collectorCMD.Flags().StringSliceVar(&collectorFilter.Skip, "skip", nil, "skip the given resource types")
+	collectorCMD.Flags().StringSliceVar(&collectorFilter.Accounts, "accounts", nil, "scan only the given account names")
+	collectorCMD.Flags().StringSliceVar(&collectorFilter.Regions, "regions", nil, "scan only the given regions")
 	rootCmd.AddCommand(collectorCMD)
 }
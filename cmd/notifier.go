@@ -48,7 +48,7 @@ var notifierCMD = &cobra.Command{
 		visibility.SetLoggingLevel(notifierConfig.LogLevel)
 
 		// Create HTTP client request
-		request := request.NewHTTPClient()
+		request := request.NewHTTPClientWithRetry(notifierConfig.APIServerRetry)
 		dataFetcherManager := notifiers.NewDataFetcherManager(request, *notifierLog, notifierConfig.APIServerAddr)
 
 		notifierLog.Info("The command has started it's work")
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"finala/collector"
+	"finala/collector/config"
+	"finala/request"
+	"finala/visibility"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// uploadFile contains the path of the collector output file to upload
+	uploadFile string
+)
+
+// uploadCMD will upload a collector output file to the api server
+var uploadCMD = &cobra.Command{
+	Use:   "upload",
+	Short: "Uploads a collector output file to the api server",
+	Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		// Loading configuration file
+		configStruct, err := config.Load(cfgFile)
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+
+		// Set application log level
+		visibility.SetLoggingLevel(configStruct.LogLevel)
+
+		// Create HTTP client request
+		req := request.NewHTTPClientWithRetry(configStruct.APIServer.Retry)
+
+		err = collector.UploadFile(req, configStruct.APIServer.Addr, uploadFile)
+		if err != nil {
+			log.WithError(err).WithField("file", uploadFile).Error("could not upload collector output file")
+			os.Exit(1)
+		}
+
+		log.WithField("file", uploadFile).Info("Collector output file was uploaded")
+	},
+}
+
+// init will add upload command
+func init() {
+	uploadCMD.Flags().StringVarP(&uploadFile, "file", "f", "", "path to the collector output file")
+	_ = uploadCMD.MarkFlagRequired("file")
+	rootCmd.AddCommand(uploadCMD)
+}
@@ -5,11 +5,15 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
 	log "github.com/sirupsen/logrus"
 
 	"finala/collector/config"
 )
 
+// newRoleProvider creates the provider of the role credentials
+var newRoleProvider = roleProvider
+
 // AuthDescriptor is an interface defining the aws auth logic
 type AuthDescriptor interface {
 	Login(region string) (*session.Session, *awsClient.Config)
@@ -29,19 +33,31 @@ func NewAuth(account config.AWSAccount) *Auth {
 }
 
 // Login to AWS account.
-// Application hierarchy login:
+// Application hierarchy login of the base credentials:
 // 1. checks first if static credentials defind (accessKey/ secret key and session token (optional) )
 // 2. checks if profile exists in yaml file
-// 3. checks if role exists in yaml file
 // else login without any specific creds and give aws logic. for more details: https://docs.aws.amazon.com/sdk-for-go/v1/developer-guide/configuring-sdk.html
+// When a role exists in yaml file, the role is assumed with the base credentials (after the source role, if exists)
 func (au *Auth) Login(region string) (*session.Session, *awsClient.Config) {
 
+	sess, config := au.baseLogin(region)
+
+	for _, role := range []string{au.account.SourceRole, au.account.Role} {
+		if role != "" {
+			sess, config = au.withRole(sess, role, region)
+		}
+	}
+
+	return sess, config
+}
+
+// baseLogin login with the account credentials, before any role is assumed
+func (au *Auth) baseLogin(region string) (*session.Session, *awsClient.Config) {
+
 	if au.account.AccessKey != "" && au.account.SecretKey != "" {
 		return au.withStaticCredentials(au.account.AccessKey, au.account.SecretKey, au.account.SessionToken, region)
 	} else if au.account.Profile != "" {
 		return au.withProfile(au.account.Profile, region)
-	} else if au.account.Role != "" {
-		return au.withRole(au.account.Role, region)
 	}
 
 	log.WithField("region", region).Info("auth: using default AWS auth client")
@@ -85,14 +101,26 @@ func (au *Auth) withProfile(profile, region string) (*session.Session, *awsClien
 	return sess, config
 }
 
-// withRole login with role
-func (au *Auth) withRole(role, region string) (*session.Session, *awsClient.Config) {
+// withRole login with role, the role is assumed with the credentials of the given session
+func (au *Auth) withRole(sess *session.Session, role, region string) (*session.Session, *awsClient.Config) {
+
+	log.WithFields(log.Fields{
+		"region": region,
+		"role":   role,
+	}).Info("auth: using aws role")
 
-	log.WithField("region", region).Info("auth: using aws role")
 	config := &awsClient.Config{
-		Region: &region,
+		Region:      &region,
+		Credentials: credentials.NewCredentials(newRoleProvider(sess, role)),
+	}
+	return session.Must(session.NewSession(config)), config
+}
+
+// roleProvider returns the provider of the role credentials, the role is assumed with the credentials of the given session
+func roleProvider(sess *session.Session, role string) *stscreds.AssumeRoleProvider {
+	return &stscreds.AssumeRoleProvider{
+		Client:   sts.New(sess),
+		RoleARN:  role,
+		Duration: stscreds.DefaultDuration,
 	}
-	sess := session.Must(session.NewSession(config))
-	config.Credentials = stscreds.NewCredentials(sess, role, func(p *stscreds.AssumeRoleProvider) {})
-	return sess, config
 }
@@ -0,0 +1,184 @@
+package cloudwatch
+
+import (
+	"finala/collector/config"
+	"fmt"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// maxMetricDataQueries defines the max queries count of a single GetMetricData request
+	maxMetricDataQueries = 500
+)
+
+// MetricHandler handles the result of a batched metric, with the same values GetMetric returns
+type MetricHandler func(formulaValue float64, metricsValues map[string]interface{}, err error)
+
+// MetricBatch collects the metric queries of a detector and fetches them with GetMetricData requests
+type MetricBatch struct {
+	cw       *CloudwatchManager
+	requests []*metricRequest
+}
+
+// metricRequest describe a single GetMetric request in the batch
+type metricRequest struct {
+	metrics config.MetricConfig
+	handler MetricHandler
+	window  metricWindow
+	queries []*awsCloudwatch.MetricDataQuery
+	values  map[string][]float64
+	err     error
+}
+
+// NewBatch creates a new metric batch
+func (cw *CloudwatchManager) NewBatch() *MetricBatch {
+	return &MetricBatch{
+		cw:       cw,
+		requests: []*metricRequest{},
+	}
+}
+
+// Add adds the metric config query to the batch. The given handler is called with the metric result when the batch is executed
+func (mb *MetricBatch) Add(metricInput *awsCloudwatch.GetMetricStatisticsInput, metrics config.MetricConfig, handler MetricHandler) {
+
+	request := &metricRequest{
+		metrics: metrics,
+		handler: handler,
+		queries: []*awsCloudwatch.MetricDataQuery{},
+		values:  make(map[string][]float64),
+	}
+
+	for _, metric := range metrics.Data {
+		// An invalid statistic fails the whole GetMetricData request, so it is not sent
+		if !isStatisticSupported(metric.Statistic) {
+			request.err = ErrActionNotSupported
+			request.queries = []*awsCloudwatch.MetricDataQuery{}
+			break
+		}
+
+		request.queries = append(request.queries, &awsCloudwatch.MetricDataQuery{
+			Id: awsClient.String(fmt.Sprintf("q%d_%d", len(mb.requests), len(request.queries))),
+			MetricStat: &awsCloudwatch.MetricStat{
+				Metric: &awsCloudwatch.Metric{
+					Namespace:  metricInput.Namespace,
+					MetricName: awsClient.String(metric.Name),
+					Dimensions: metricInput.Dimensions,
+				},
+				Period: metricInput.Period,
+				Stat:   awsClient.String(metric.Statistic),
+			},
+			ReturnData: awsClient.Bool(true),
+		})
+	}
+
+	// GetMetricData requests share a single time window for all the queries
+	request.window = metricWindow{start: *metricInput.StartTime, end: *metricInput.EndTime}
+	mb.requests = append(mb.requests, request)
+}
+
+// Execute fetches all the batch metrics and calls the requests handlers by the order they were added
+func (mb *MetricBatch) Execute() {
+
+	windows := []metricWindow{}
+	requestsByWindow := map[metricWindow][]*metricRequest{}
+	for _, request := range mb.requests {
+		if _, found := requestsByWindow[request.window]; !found {
+			windows = append(windows, request.window)
+		}
+		requestsByWindow[request.window] = append(requestsByWindow[request.window], request)
+	}
+
+	for _, window := range windows {
+		mb.fetch(window, requestsByWindow[window])
+	}
+
+	for _, request := range mb.requests {
+		request.handler(mb.calculate(request))
+	}
+
+	mb.requests = []*metricRequest{}
+}
+
+// metricWindow describe the time window of the metric queries
+type metricWindow struct {
+	start time.Time
+	end   time.Time
+}
+
+// fetch gets the values of the given requests queries, chunked by the max queries of a single request
+func (mb *MetricBatch) fetch(window metricWindow, requests []*metricRequest) {
+
+	queries := []*awsCloudwatch.MetricDataQuery{}
+	queryRequests := map[string]*metricRequest{}
+	for _, request := range requests {
+		for _, query := range request.queries {
+			queries = append(queries, query)
+			queryRequests[*query.Id] = request
+		}
+	}
+
+	for start := 0; start < len(queries); start += maxMetricDataQueries {
+		end := start + maxMetricDataQueries
+		if end > len(queries) {
+			end = len(queries)
+		}
+
+		input := &awsCloudwatch.GetMetricDataInput{
+			StartTime:         awsClient.Time(window.start),
+			EndTime:           awsClient.Time(window.end),
+			MetricDataQueries: queries[start:end],
+		}
+
+		for {
+			output, err := mb.cw.client.GetMetricData(input)
+			if err != nil {
+				log.WithError(err).WithField("queries_count", end-start).Error("could not get cloudwatch metric data")
+				for _, query := range queries[start:end] {
+					queryRequests[*query.Id].err = err
+				}
+				break
+			}
+
+			for _, result := range output.MetricDataResults {
+				request, found := queryRequests[*result.Id]
+				if !found {
+					continue
+				}
+				for _, value := range result.Values {
+					request.values[*result.Id] = append(request.values[*result.Id], *value)
+				}
+			}
+
+			if output.NextToken == nil {
+				break
+			}
+			input.NextToken = output.NextToken
+		}
+	}
+}
+
+// calculate reduces the request queries values and evaluates the metric config formula
+func (mb *MetricBatch) calculate(request *metricRequest) (float64, map[string]interface{}, error) {
+
+	metricsResponseValue := make(map[string]interface{})
+
+	var calculatedMetricValue float64
+	if request.err != nil {
+		return calculatedMetricValue, metricsResponseValue, request.err
+	}
+
+	for i, metric := range request.metrics.Data {
+		value, err := mb.cw.reduceValues(metric.Statistic, request.values[*request.queries[i].Id])
+		if err != nil {
+			return calculatedMetricValue, metricsResponseValue, err
+		}
+		calculatedMetricValue = value
+		metricsResponseValue[metric.Name] = calculatedMetricValue
+	}
+
+	return mb.cw.calculateFormula(request.metrics, calculatedMetricValue, metricsResponseValue)
+}
@@ -0,0 +1,186 @@
+package cloudwatch_test
+
+import (
+	"finala/collector/aws/cloudwatch"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	"finala/collector/testutils"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+var batchMetricConfig = config.MetricConfig{
+	Description: "test description",
+	Data: []config.MetricDataConfiguration{
+		{
+			Name:      "a",
+			Statistic: "Sum",
+		},
+		{
+			Name:      "b",
+			Statistic: "Maximum",
+		},
+		{
+			Name:      "c",
+			Statistic: "Average",
+		},
+	},
+	Constraint: config.MetricConstraintConfig{
+		Formula: "a + b + c",
+	},
+}
+
+var batchMetricStatistics = map[string]awsCloudwatch.GetMetricStatisticsOutput{
+	"a": {
+		Datapoints: []*awsCloudwatch.Datapoint{
+			{Sum: testutils.Float64Pointer(3)},
+			{Sum: testutils.Float64Pointer(2)},
+		},
+	},
+	"b": {
+		Datapoints: []*awsCloudwatch.Datapoint{
+			{Maximum: testutils.Float64Pointer(5)},
+			{Maximum: testutils.Float64Pointer(0)},
+		},
+	},
+	"c": {
+		Datapoints: []*awsCloudwatch.Datapoint{
+			{Average: testutils.Float64Pointer(4)},
+			{Average: testutils.Float64Pointer(2)},
+		},
+	},
+}
+
+func newBatchMetricInput(resourceID string, startTime, endTime time.Time) awsCloudwatch.GetMetricStatisticsInput {
+
+	period := int64(60)
+	return awsCloudwatch.GetMetricStatisticsInput{
+		Namespace: awsClient.String("AWS/Test"),
+		Period:    &period,
+		StartTime: &startTime,
+		EndTime:   &endTime,
+		Dimensions: []*awsCloudwatch.Dimension{
+			{
+				Name:  awsClient.String("ResourceId"),
+				Value: awsClient.String(resourceID),
+			},
+		},
+	}
+}
+
+func TestMetricBatchRequestCount(t *testing.T) {
+
+	now := time.Now()
+	startTime := now.Add(-time.Hour)
+	resourcesCount := 200
+
+	// The current path, a GetMetricStatistics request per metric per resource
+	statisticsClient := awsTestutils.NewMockCloudwatchClient(&batchMetricStatistics)
+	statisticsManager := cloudwatch.NewCloudWatchManager(statisticsClient)
+	statisticsResults := []float64{}
+	for i := 0; i < resourcesCount; i++ {
+		metricInput := newBatchMetricInput(string(rune('a'+i%26)), startTime, now)
+		result, _, err := statisticsManager.GetMetric(&metricInput, batchMetricConfig)
+		if err != nil {
+			t.Fatalf("unexpected get metric error, got %v expected %v", err, nil)
+		}
+		statisticsResults = append(statisticsResults, result)
+	}
+
+	// The batch path, a GetMetricData request per 500 queries
+	dataClient := awsTestutils.NewMockCloudwatchClient(&batchMetricStatistics)
+	batch := cloudwatch.NewCloudWatchManager(dataClient).NewBatch()
+	batchResults := []float64{}
+	for i := 0; i < resourcesCount; i++ {
+		metricInput := newBatchMetricInput(string(rune('a'+i%26)), startTime, now)
+		batch.Add(&metricInput, batchMetricConfig, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
+			if err != nil {
+				t.Fatalf("unexpected batch metric error, got %v expected %v", err, nil)
+			}
+			batchResults = append(batchResults, formulaValue)
+		})
+	}
+	batch.Execute()
+
+	if statisticsClient.GetMetricStatisticsCount != resourcesCount*3 {
+		t.Fatalf("unexpected GetMetricStatistics requests count, got %d expected %d", statisticsClient.GetMetricStatisticsCount, resourcesCount*3)
+	}
+
+	if dataClient.GetMetricDataCount != 2 {
+		t.Fatalf("unexpected GetMetricData requests count, got %d expected %d", dataClient.GetMetricDataCount, 2)
+	}
+
+	if len(batchResults) != len(statisticsResults) {
+		t.Fatalf("unexpected batch results count, got %d expected %d", len(batchResults), len(statisticsResults))
+	}
+
+	for i := range statisticsResults {
+		if batchResults[i] != statisticsResults[i] {
+			t.Fatalf("unexpected batch result, got %f expected %f", batchResults[i], statisticsResults[i])
+		}
+	}
+
+}
+
+func TestMetricBatchTimeWindows(t *testing.T) {
+
+	now := time.Now()
+	dataClient := awsTestutils.NewMockCloudwatchClient(&batchMetricStatistics)
+	batch := cloudwatch.NewCloudWatchManager(dataClient).NewBatch()
+
+	handled := 0
+	for _, startTime := range []time.Duration{time.Hour, time.Hour * 24, time.Hour} {
+		metricInput := newBatchMetricInput("foo", now.Add(-startTime), now)
+		batch.Add(&metricInput, batchMetricConfig, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
+			handled++
+			if formulaValue != 13 {
+				t.Fatalf("unexpected formula result, got %f expected %d", formulaValue, 13)
+			}
+		})
+	}
+	batch.Execute()
+
+	if dataClient.GetMetricDataCount != 2 {
+		t.Fatalf("unexpected GetMetricData requests count, got %d expected %d", dataClient.GetMetricDataCount, 2)
+	}
+
+	if handled != 3 {
+		t.Fatalf("unexpected handled metrics count, got %d expected %d", handled, 3)
+	}
+
+}
+
+func TestMetricBatchErrors(t *testing.T) {
+
+	now := time.Now()
+	dataClient := awsTestutils.NewMockCloudwatchClient(&batchMetricStatistics)
+	batch := cloudwatch.NewCloudWatchManager(dataClient).NewBatch()
+
+	metricInput := newBatchMetricInput("foo", now.Add(-time.Hour), now)
+	invalidMetricConfig := config.MetricConfig{
+		Data: []config.MetricDataConfiguration{
+			{
+				Name:      "a",
+				Statistic: "invalid",
+			},
+		},
+	}
+
+	var batchErr error
+	batch.Add(&metricInput, invalidMetricConfig, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
+		batchErr = err
+	})
+	batch.Execute()
+
+	if batchErr != cloudwatch.ErrActionNotSupported {
+		t.Fatalf("unexpected batch error, got %v expected %v", batchErr, cloudwatch.ErrActionNotSupported)
+	}
+
+	if dataClient.GetMetricDataCount != 0 {
+		t.Fatalf("unexpected GetMetricData requests count, got %d expected %d", dataClient.GetMetricDataCount, 0)
+	}
+
+}
@@ -2,8 +2,10 @@ package cloudwatch
 
 import (
 	"errors"
+	"finala/collector"
 	"finala/collector/config"
 	"finala/expression"
+	"regexp"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
 	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
@@ -11,13 +13,18 @@ import (
 )
 
 var (
-	// ErrActionNotSupported returned when metrics statistics (from yaml configuration) is not equal to: Average, Maximum, Sum
+	// ErrActionNotSupported returned when metrics statistics (from yaml configuration) is not equal to: Average, Maximum, Minimum, Sum, SampleCount
+	// or to an extended percentile statistic (for example: p95)
 	ErrActionNotSupported = errors.New("action not supported")
+
+	// extendedStatisticPattern describe the percentile statistics, p0.0 - p100
+	extendedStatisticPattern = regexp.MustCompile(`^p(\d{1,2}(\.\d{1,2})?|100)$`)
 )
 
 // CloudwatchClientDescreptor defining the aws cloudwatch client
 type CloudwatchClientDescreptor interface {
 	GetMetricStatistics(*awsCloudwatch.GetMetricStatisticsInput) (*awsCloudwatch.GetMetricStatisticsOutput, error)
+	GetMetricData(*awsCloudwatch.GetMetricDataInput) (*awsCloudwatch.GetMetricDataOutput, error)
 }
 
 // CloudwatchManager define aws AWScloudwatch client
@@ -43,8 +50,19 @@ func (cw *CloudwatchManager) GetMetric(metricInput *awsCloudwatch.GetMetricStati
 
 	var calculatedMetricValue float64
 	for _, metric := range metrics.Data {
+		if !isStatisticSupported(metric.Statistic) {
+			return calculatedMetricValue, metricsResponseValue, ErrActionNotSupported
+		}
+
 		metricInput.MetricName = awsClient.String(metric.Name)
-		metricInput.Statistics = []*string{&metric.Statistic}
+		if isExtendedStatistic(metric.Statistic) {
+			metricInput.Statistics = nil
+			metricInput.ExtendedStatistics = []*string{&metric.Statistic}
+		} else {
+			metricInput.Statistics = []*string{&metric.Statistic}
+			metricInput.ExtendedStatistics = nil
+		}
+
 		metricData, err := cw.client.GetMetricStatistics(metricInput)
 		if err != nil {
 			return calculatedMetricValue, metricsResponseValue, err
@@ -55,16 +73,28 @@ func (cw *CloudwatchManager) GetMetric(metricInput *awsCloudwatch.GetMetricStati
 			calculatedMetricValue = cw.AvgDatapoint(metricData)
 		case "Maximum":
 			calculatedMetricValue = cw.MaxDatapoint(metricData)
+		case "Minimum":
+			calculatedMetricValue = cw.MinDatapoint(metricData)
 		case "Sum":
 			calculatedMetricValue = cw.SumDatapoint(metricData)
+		case "SampleCount":
+			calculatedMetricValue = cw.SampleCountDatapoint(metricData)
 		default:
-			return calculatedMetricValue, metricsResponseValue, ErrActionNotSupported
+			calculatedMetricValue = cw.ExtendedDatapoint(metricData, metric.Statistic)
 		}
 		metricsResponseValue[metric.Name] = calculatedMetricValue
 
 	}
 
-	if len(metrics.Data) == 1 {
+	return cw.calculateFormula(metrics, calculatedMetricValue, metricsResponseValue)
+
+}
+
+// calculateFormula returns the metric config formula result by the given metrics values.
+// When the metric config has a single metric, or a rule tree constraint without a root formula, the metric value is returned as is
+func (cw *CloudwatchManager) calculateFormula(metrics config.MetricConfig, calculatedMetricValue float64, metricsResponseValue map[string]interface{}) (float64, map[string]interface{}, error) {
+
+	if len(metrics.Data) == 1 || (metrics.Constraint.Formula == "" && metrics.Constraint.IsCompound()) {
 		return calculatedMetricValue, metricsResponseValue, nil
 	}
 
@@ -79,7 +109,47 @@ func (cw *CloudwatchManager) GetMetric(metricInput *awsCloudwatch.GetMetricStati
 	}
 
 	return formulaResponse.(float64), metricsResponseValue, nil
+}
 
+// reduceValues returns the calculated value of the metric values (each value is the statistic of a single period)
+func (cw *CloudwatchManager) reduceValues(statistic string, values []float64) (float64, error) {
+
+	var calculatedMetricValue float64
+	switch statistic {
+	case "Average":
+		for _, value := range values {
+			calculatedMetricValue = calculatedMetricValue + value
+		}
+		calculatedMetricValue = calculatedMetricValue / float64(len(values))
+	case "Maximum":
+		for _, value := range values {
+			if calculatedMetricValue < value {
+				calculatedMetricValue = value
+			}
+		}
+	case "Minimum":
+		for i, value := range values {
+			if calculatedMetricValue > value || i == 0 {
+				calculatedMetricValue = value
+			}
+		}
+	case "Sum", "SampleCount":
+		for _, value := range values {
+			calculatedMetricValue = calculatedMetricValue + value
+		}
+	default:
+		if !isExtendedStatistic(statistic) {
+			return calculatedMetricValue, ErrActionNotSupported
+		}
+		// The highest period percentile, the same as ExtendedDatapoint
+		for _, value := range values {
+			if calculatedMetricValue < value {
+				calculatedMetricValue = value
+			}
+		}
+	}
+
+	return calculatedMetricValue, nil
 }
 
 // SumDatapoint return datapoint sum
@@ -133,3 +203,60 @@ func (cw *CloudwatchManager) MinDatapoint(statisticOutput *awsCloudwatch.GetMetr
 	return min
 
 }
+
+// SampleCountDatapoint return datapoint sample count
+func (cw *CloudwatchManager) SampleCountDatapoint(statisticOutput *awsCloudwatch.GetMetricStatisticsOutput) float64 {
+
+	count := float64(0)
+	for _, re := range statisticOutput.Datapoints {
+		count = count + *re.SampleCount
+	}
+	return count
+
+}
+
+// ExtendedDatapoint return the highest datapoint percentile of the given extended statistic.
+// Each datapoint holds the percentile of a single period, so the result is the percentile of the worst period
+func (cw *CloudwatchManager) ExtendedDatapoint(statisticOutput *awsCloudwatch.GetMetricStatisticsOutput, statistic string) float64 {
+
+	max := float64(0)
+	for _, re := range statisticOutput.Datapoints {
+		value, found := re.ExtendedStatistics[statistic]
+		if found && max < *value {
+			max = *value
+		}
+	}
+	return max
+
+}
+
+// isStatisticSupported returns true if the given statistic can be calculated
+func isStatisticSupported(statistic string) bool {
+
+	switch statistic {
+	case "Average", "Maximum", "Minimum", "Sum", "SampleCount":
+		return true
+	default:
+		return isExtendedStatistic(statistic)
+	}
+}
+
+// isExtendedStatistic returns true if the given statistic is a percentile statistic
+func isExtendedStatistic(statistic string) bool {
+	return extendedStatisticPattern.MatchString(statistic)
+}
+
+// NewMetricDetectedFields returns the metric evaluation details of a detected resource: the metrics values,
+// the formula result, the constraint rule with all its sub constraints and the time window of the metric query
+func NewMetricDetectedFields(metrics config.MetricConfig, metricInput *awsCloudwatch.GetMetricStatisticsInput, formulaValue float64, metricsValues map[string]interface{}) collector.MetricDetectedFields {
+
+	return collector.MetricDetectedFields{
+		MetricValues:       metricsValues,
+		FormulaValue:       formulaValue,
+		ConstraintOperator: metrics.Constraint.Operator,
+		ConstraintValue:    metrics.Constraint.Value,
+		Constraint:         metrics.Constraint.String(),
+		MetricStartTime:    awsClient.TimeValue(metricInput.StartTime),
+		MetricEndTime:      awsClient.TimeValue(metricInput.EndTime),
+	}
+}
@@ -1,10 +1,12 @@
 package cloudwatch_test
 
 import (
+	cloudwatchmanager "finala/collector/aws/cloudwatch"
 	awsTestutils "finala/collector/aws/testutils"
 	"finala/collector/config"
 	"finala/collector/testutils"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
@@ -130,16 +132,24 @@ func TestDatapointMath(t *testing.T) {
 	statistics := cloudwatch.GetMetricStatisticsOutput{
 		Datapoints: []*awsCloudwatch.Datapoint{
 			{
-				Sum:     testutils.Float64Pointer(2),
-				Average: testutils.Float64Pointer(4),
-				Maximum: testutils.Float64Pointer(4),
-				Minimum: testutils.Float64Pointer(1),
+				Sum:         testutils.Float64Pointer(2),
+				Average:     testutils.Float64Pointer(4),
+				Maximum:     testutils.Float64Pointer(4),
+				Minimum:     testutils.Float64Pointer(1),
+				SampleCount: testutils.Float64Pointer(10),
+				ExtendedStatistics: map[string]*float64{
+					"p90": testutils.Float64Pointer(3),
+				},
 			},
 			{
-				Sum:     testutils.Float64Pointer(2),
-				Average: testutils.Float64Pointer(2),
-				Maximum: testutils.Float64Pointer(0),
-				Minimum: testutils.Float64Pointer(3),
+				Sum:         testutils.Float64Pointer(2),
+				Average:     testutils.Float64Pointer(2),
+				Maximum:     testutils.Float64Pointer(0),
+				Minimum:     testutils.Float64Pointer(3),
+				SampleCount: testutils.Float64Pointer(5),
+				ExtendedStatistics: map[string]*float64{
+					"p90": testutils.Float64Pointer(2),
+				},
 			},
 		},
 	}
@@ -169,5 +179,134 @@ func TestDatapointMath(t *testing.T) {
 			t.Fatalf("unexpected min datapoint, got %f expected %d", min, 1)
 		}
 	})
+	t.Run("sample_count_datapoint", func(t *testing.T) {
+		count := cloutwatchManager.SampleCountDatapoint(&statistics)
+		if count != 15 {
+			t.Fatalf("unexpected sample count datapoint, got %f expected %d", count, 15)
+		}
+	})
+	t.Run("extended_datapoint", func(t *testing.T) {
+		percentile := cloutwatchManager.ExtendedDatapoint(&statistics, "p90")
+		if percentile != 3 {
+			t.Fatalf("unexpected extended datapoint, got %f expected %d", percentile, 3)
+		}
+	})
+
+}
+
+func TestGetMetricStatistics(t *testing.T) {
+
+	cloudWatchMetrics := map[string]cloudwatch.GetMetricStatisticsOutput{
+		"a": {
+			Datapoints: []*cloudwatch.Datapoint{
+				{
+					Minimum:     testutils.Float64Pointer(3),
+					SampleCount: testutils.Float64Pointer(10),
+					ExtendedStatistics: map[string]*float64{
+						"p95":   testutils.Float64Pointer(7),
+						"p99.9": testutils.Float64Pointer(9),
+					},
+				},
+				{
+					Minimum:     testutils.Float64Pointer(2),
+					SampleCount: testutils.Float64Pointer(5),
+					ExtendedStatistics: map[string]*float64{
+						"p95":   testutils.Float64Pointer(8),
+						"p99.9": testutils.Float64Pointer(8),
+					},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		statistic     string
+		expectedValue float64
+		expectedErr   error
+	}{
+		{"Minimum", 2, nil},
+		{"SampleCount", 15, nil},
+		{"p95", 8, nil},
+		{"p99.9", 9, nil},
+		{"p101", 0, cloudwatchmanager.ErrActionNotSupported},
+		{"p", 0, cloudwatchmanager.ErrActionNotSupported},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.statistic, func(t *testing.T) {
+			metricConfig := config.MetricConfig{
+				Description: "test description",
+				Data: []config.MetricDataConfiguration{
+					{
+						Name:      "a",
+						Statistic: test.statistic,
+					},
+				},
+			}
+
+			cloutwatchManager := awsTestutils.NewMockCloudwatch(&cloudWatchMetrics)
+			metricInput := cloudwatch.GetMetricStatisticsInput{}
+			result, _, err := cloutwatchManager.GetMetric(&metricInput, metricConfig)
+			if err != test.expectedErr {
+				t.Fatalf("unexpected error, got %v expected %v", err, test.expectedErr)
+			}
+			if result != test.expectedValue {
+				t.Fatalf("unexpected metric result, got %f expected %f", result, test.expectedValue)
+			}
+
+			now := time.Now()
+			batchMetricInput := cloudwatch.GetMetricStatisticsInput{
+				StartTime: &now,
+				EndTime:   &now,
+			}
+			batch := awsTestutils.NewMockCloudwatch(&cloudWatchMetrics).NewBatch()
+			batch.Add(&batchMetricInput, metricConfig, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
+				if err != test.expectedErr {
+					t.Fatalf("unexpected batch error, got %v expected %v", err, test.expectedErr)
+				}
+				if formulaValue != test.expectedValue {
+					t.Fatalf("unexpected batch metric result, got %f expected %f", formulaValue, test.expectedValue)
+				}
+			})
+			batch.Execute()
+		})
+	}
+
+}
 
+func TestNewMetricDetectedFields(t *testing.T) {
+
+	metricConfig := config.MetricConfig{
+		Description: "test description",
+		Constraint: config.MetricConstraintConfig{
+			Operator: "<",
+			Value:    5,
+			Any: []config.MetricConstraintConfig{
+				{Formula: "a", Operator: ">", Value: 1},
+				{Not: &config.MetricConstraintConfig{Formula: "b", Operator: ">", Value: 4096}},
+			},
+		},
+	}
+
+	start := time.Now().Add(-time.Hour)
+	end := time.Now()
+	metricInput := cloudwatch.GetMetricStatisticsInput{
+		StartTime: &start,
+		EndTime:   &end,
+	}
+
+	fields := cloudwatchmanager.NewMetricDetectedFields(metricConfig, &metricInput, 3, map[string]interface{}{"a": float64(3), "b": float64(2)})
+
+	if fields.ConstraintOperator != "<" || fields.ConstraintValue != 5 {
+		t.Fatalf("unexpected constraint rule, got %s %v expected %s %v", fields.ConstraintOperator, fields.ConstraintValue, "<", 5)
+	}
+
+	expectedConstraint := "value < 5 and any(a > 1, not(b > 4096))"
+	if fields.Constraint != expectedConstraint {
+		t.Fatalf("unexpected constraint, got %q expected %q", fields.Constraint, expectedConstraint)
+	}
+
+	if !fields.MetricStartTime.Equal(start) || !fields.MetricEndTime.Equal(end) {
+		t.Fatalf("unexpected metric time window, got %v - %v expected %v - %v", fields.MetricStartTime, fields.MetricEndTime, start, end)
+	}
 }
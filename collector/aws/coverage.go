@@ -0,0 +1,349 @@
+package aws
+
+import (
+	"finala/collector"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/costexplorer"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultCoverageLookback defines the default period of the coverage history
+	defaultCoverageLookback = 30 * 24 * time.Hour
+
+	// coverageDateLayout defines the date format of the cost explorer time period
+	coverageDateLayout = "2006-01-02"
+
+	// coverageRegionAttribute defines the attribute of the coverage group region
+	coverageRegionAttribute = "region"
+)
+
+// reservationCoverageServices maps the resource types that can be covered by reserved instances to their cost explorer service
+var reservationCoverageServices = map[string]string{
+	"ec2":           "Amazon Elastic Compute Cloud - Compute",
+	"rds":           "Amazon Relational Database Service",
+	"elasticache":   "Amazon ElastiCache",
+	"redshift":      "Amazon Redshift",
+	"elasticsearch": "Amazon Elasticsearch Service",
+}
+
+// savingsPlansCoverageServices maps the resource types that can be covered by savings plans to their cost explorer service
+var savingsPlansCoverageServices = map[string]string{
+	"ec2": "Amazon Elastic Compute Cloud - Compute",
+}
+
+// CostExplorerClientDescriptor defines the cost explorer client
+type CostExplorerClientDescriptor interface {
+	GetReservationCoverage(input *costexplorer.GetReservationCoverageInput) (*costexplorer.GetReservationCoverageOutput, error)
+	GetSavingsPlansCoverage(input *costexplorer.GetSavingsPlansCoverageInput) (*costexplorer.GetSavingsPlansCoverageOutput, error)
+}
+
+// coverageUsage describe the covered and the total usage of a resource type in a region
+type coverageUsage struct {
+	covered float64
+	total   float64
+}
+
+// percentage returns the covered share of the usage
+func (cu *coverageUsage) percentage() float64 {
+	if cu == nil || cu.total <= 0 {
+		return 0
+	}
+	return cu.covered / cu.total * 100
+}
+
+// CoverageManager describe the reserved instances and savings plans coverage of an account
+type CoverageManager struct {
+	client       CostExplorerClientDescriptor
+	lookback     time.Duration
+	reservations map[string]map[string]*coverageUsage
+	savingsPlans map[string]map[string]*coverageUsage
+	now          func() time.Time
+}
+
+// NewCoverageManager implements AWS GO SDK
+func NewCoverageManager(client CostExplorerClientDescriptor, lookback time.Duration) *CoverageManager {
+
+	if lookback <= 0 {
+		lookback = defaultCoverageLookback
+	}
+
+	return &CoverageManager{
+		client:       client,
+		lookback:     lookback,
+		reservations: make(map[string]map[string]*coverageUsage),
+		savingsPlans: make(map[string]map[string]*coverageUsage),
+		now:          time.Now,
+	}
+}
+
+// Load pulls the coverage of the lookback period, by resource type and region, of the given account.
+// The reserved instances coverage is measured in hours and the savings plans coverage in spend
+func (cm *CoverageManager) Load(accountID string) error {
+
+	for resourceType, service := range reservationCoverageServices {
+		err := cm.loadReservations(accountID, resourceType, service)
+		if err != nil {
+			log.WithError(err).WithField("service", service).Error("could not get reserved instances coverage")
+			return err
+		}
+	}
+
+	for resourceType, service := range savingsPlansCoverageServices {
+		err := cm.loadSavingsPlans(accountID, resourceType, service)
+		if err != nil {
+			log.WithError(err).WithField("service", service).Error("could not get savings plans coverage")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Coverage returns the covered percentage of the resource type usage in the given region,
+// the reserved instances and savings plans coverage are combined up to a full coverage
+func (cm *CoverageManager) Coverage(resourceType, region string) float64 {
+
+	if cm == nil {
+		return 0
+	}
+
+	coverage := cm.reservations[resourceType][region].percentage() + cm.savingsPlans[resourceType][region].percentage()
+	if coverage > 100 {
+		coverage = 100
+	}
+	return coverage
+}
+
+// loadReservations pulls the reserved instances coverage of the service, grouped by region
+func (cm *CoverageManager) loadReservations(accountID, resourceType, service string) error {
+
+	input := &costexplorer.GetReservationCoverageInput{
+		Filter:      cm.getFilter(accountID, service),
+		Granularity: awsClient.String(costexplorer.GranularityMonthly),
+		GroupBy:     cm.getGroupBy(),
+		TimePeriod:  cm.getTimePeriod(),
+	}
+
+	for {
+		output, err := cm.client.GetReservationCoverage(input)
+		if err != nil {
+			return err
+		}
+
+		for _, coverageByTime := range output.CoveragesByTime {
+			for _, group := range coverageByTime.Groups {
+				if group.Coverage == nil || group.Coverage.CoverageHours == nil {
+					continue
+				}
+				cm.addUsage(cm.reservations, resourceType, getCoverageRegion(group.Attributes),
+					parseCoverageValue(group.Coverage.CoverageHours.ReservedHours),
+					parseCoverageValue(group.Coverage.CoverageHours.TotalRunningHours))
+			}
+		}
+
+		if output.NextPageToken == nil || *output.NextPageToken == "" {
+			return nil
+		}
+		input.NextPageToken = output.NextPageToken
+	}
+}
+
+// loadSavingsPlans pulls the savings plans coverage of the service, grouped by region
+func (cm *CoverageManager) loadSavingsPlans(accountID, resourceType, service string) error {
+
+	input := &costexplorer.GetSavingsPlansCoverageInput{
+		Filter:      cm.getFilter(accountID, service),
+		Granularity: awsClient.String(costexplorer.GranularityMonthly),
+		GroupBy:     cm.getGroupBy(),
+		TimePeriod:  cm.getTimePeriod(),
+	}
+
+	for {
+		output, err := cm.client.GetSavingsPlansCoverage(input)
+		if err != nil {
+			return err
+		}
+
+		for _, coverage := range output.SavingsPlansCoverages {
+			if coverage.Coverage == nil {
+				continue
+			}
+			cm.addUsage(cm.savingsPlans, resourceType, getCoverageRegion(coverage.Attributes),
+				parseCoverageValue(coverage.Coverage.SpendCoveredBySavingsPlans),
+				parseCoverageValue(coverage.Coverage.TotalCost))
+		}
+
+		if output.NextToken == nil || *output.NextToken == "" {
+			return nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// addUsage sums the usage of the resource type region, the lookback period may return a group for every month
+func (cm *CoverageManager) addUsage(usage map[string]map[string]*coverageUsage, resourceType, region string, covered, total float64) {
+
+	if region == "" {
+		return
+	}
+
+	if _, found := usage[resourceType]; !found {
+		usage[resourceType] = make(map[string]*coverageUsage)
+	}
+	if _, found := usage[resourceType][region]; !found {
+		usage[resourceType][region] = &coverageUsage{}
+	}
+
+	usage[resourceType][region].covered += covered
+	usage[resourceType][region].total += total
+}
+
+// getFilter returns the cost explorer filter of the service usage of the account
+func (cm *CoverageManager) getFilter(accountID, service string) *costexplorer.Expression {
+
+	serviceFilter := &costexplorer.Expression{
+		Dimensions: &costexplorer.DimensionValues{
+			Key:    awsClient.String(costexplorer.DimensionService),
+			Values: []*string{awsClient.String(service)},
+		},
+	}
+
+	if accountID == "" {
+		return serviceFilter
+	}
+
+	return &costexplorer.Expression{
+		And: []*costexplorer.Expression{
+			serviceFilter,
+			{
+				Dimensions: &costexplorer.DimensionValues{
+					Key:    awsClient.String(costexplorer.DimensionLinkedAccount),
+					Values: []*string{awsClient.String(accountID)},
+				},
+			},
+		},
+	}
+}
+
+// getGroupBy returns the cost explorer region grouping
+func (cm *CoverageManager) getGroupBy() []*costexplorer.GroupDefinition {
+	return []*costexplorer.GroupDefinition{
+		{
+			Key:  awsClient.String(costexplorer.DimensionRegion),
+			Type: awsClient.String(costexplorer.GroupDefinitionTypeDimension),
+		},
+	}
+}
+
+// getTimePeriod returns the cost explorer time period of the lookback
+func (cm *CoverageManager) getTimePeriod() *costexplorer.DateInterval {
+
+	now := cm.now()
+	return &costexplorer.DateInterval{
+		Start: awsClient.String(now.Add(-cm.lookback).Format(coverageDateLayout)),
+		End:   awsClient.String(now.Format(coverageDateLayout)),
+	}
+}
+
+// getCoverageRegion returns the region of the coverage group attributes
+func getCoverageRegion(attributes map[string]*string) string {
+	for key, value := range attributes {
+		if strings.EqualFold(key, coverageRegionAttribute) && value != nil {
+			return *value
+		}
+	}
+	return ""
+}
+
+// parseCoverageValue returns the float value of the cost explorer amount
+func parseCoverageValue(value *string) float64 {
+	if value == nil {
+		return 0
+	}
+	parsedValue, err := strconv.ParseFloat(*value, 64)
+	if err != nil {
+		return 0
+	}
+	return parsedValue
+}
+
+// CoverageCollector wraps a collector and sets the effective prices of the detected resources of a region.
+// The covered share of the on demand price is already paid by the reserved instances and savings plans,
+// and would not be saved by removing the resource
+type CoverageCollector struct {
+	collector.CollectorDescriber
+	coverage *CoverageManager
+	region   string
+}
+
+// NewCoverageCollector creates new coverage collector instance, when the coverage is nil the effective prices are the on demand prices
+func NewCoverageCollector(cl collector.CollectorDescriber, coverage *CoverageManager, region string) *CoverageCollector {
+	return &CoverageCollector{
+		CollectorDescriber: cl,
+		coverage:           coverage,
+		region:             region,
+	}
+}
+
+// AddResource add the resource data with its effective prices
+func (cc *CoverageCollector) AddResource(data collector.EventCollector) {
+
+	resourceType := strings.TrimPrefix(string(data.ResourceName), ResourcePrefix+"_")
+	data.Data = setEffectivePrices(data.Data, cc.coverage.Coverage(resourceType, cc.region))
+
+	cc.CollectorDescriber.AddResource(data)
+}
+
+// setEffectivePrices returns the detected resource data with the effective prices fields of its on demand prices fields
+func setEffectivePrices(data interface{}, coveragePercentage float64) interface{} {
+
+	value := reflect.ValueOf(data)
+	isPointer := value.Kind() == reflect.Ptr
+	if isPointer {
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return data
+	}
+
+	// The data struct is copied, since a struct of an interface is not settable
+	if !isPointer {
+		copyValue := reflect.New(value.Type()).Elem()
+		copyValue.Set(value)
+		value = copyValue
+	}
+
+	uncoveredShare := 1 - coveragePercentage/100
+	setFloatField(value, "EffectivePricePerHour", getFloatField(value, "PricePerHour")*uncoveredShare)
+	setFloatField(value, "EffectivePricePerMonth", getFloatField(value, "PricePerMonth")*uncoveredShare)
+	setFloatField(value, "CoveragePercentage", coveragePercentage)
+
+	if isPointer {
+		return data
+	}
+	return value.Interface()
+}
+
+// getFloatField returns the value of the float field, or zero when the struct has no such field
+func getFloatField(value reflect.Value, name string) float64 {
+	field := value.FieldByName(name)
+	if !field.IsValid() || field.Kind() != reflect.Float64 {
+		return 0
+	}
+	return field.Float()
+}
+
+// setFloatField sets the value of the float field, when the struct has such field
+func setFloatField(value reflect.Value, name string, fieldValue float64) {
+	field := value.FieldByName(name)
+	if field.IsValid() && field.Kind() == reflect.Float64 && field.CanSet() {
+		field.SetFloat(fieldValue)
+	}
+}
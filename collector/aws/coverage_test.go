@@ -0,0 +1,215 @@
+package aws
+
+import (
+	"errors"
+	"finala/collector"
+	collectorTestutils "finala/collector/testutils"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/costexplorer"
+)
+
+type mockCostExplorerClient struct {
+	reservations map[string][]*costexplorer.GetReservationCoverageOutput
+	savingsPlans map[string][]*costexplorer.GetSavingsPlansCoverageOutput
+	err          error
+}
+
+func getFilterService(filter *costexplorer.Expression) string {
+	if filter.Dimensions != nil {
+		return *filter.Dimensions.Values[0]
+	}
+	return getFilterService(filter.And[0])
+}
+
+func getPage(token *string) int {
+	if token == nil {
+		return 0
+	}
+	return 1
+}
+
+func (mce *mockCostExplorerClient) GetReservationCoverage(input *costexplorer.GetReservationCoverageInput) (*costexplorer.GetReservationCoverageOutput, error) {
+	if mce.err != nil {
+		return nil, mce.err
+	}
+	pages := mce.reservations[getFilterService(input.Filter)]
+	if len(pages) == 0 {
+		return &costexplorer.GetReservationCoverageOutput{}, nil
+	}
+	return pages[getPage(input.NextPageToken)], nil
+}
+
+func (mce *mockCostExplorerClient) GetSavingsPlansCoverage(input *costexplorer.GetSavingsPlansCoverageInput) (*costexplorer.GetSavingsPlansCoverageOutput, error) {
+	if mce.err != nil {
+		return nil, mce.err
+	}
+	pages := mce.savingsPlans[getFilterService(input.Filter)]
+	if len(pages) == 0 {
+		return &costexplorer.GetSavingsPlansCoverageOutput{}, nil
+	}
+	return pages[getPage(input.NextToken)], nil
+}
+
+func reservationGroup(region, reservedHours, totalHours string) *costexplorer.ReservationCoverageGroup {
+	return &costexplorer.ReservationCoverageGroup{
+		Attributes: map[string]*string{"region": awsClient.String(region)},
+		Coverage: &costexplorer.Coverage{
+			CoverageHours: &costexplorer.CoverageHours{
+				ReservedHours:     awsClient.String(reservedHours),
+				TotalRunningHours: awsClient.String(totalHours),
+			},
+		},
+	}
+}
+
+func newMockCostExplorerClient() *mockCostExplorerClient {
+	return &mockCostExplorerClient{
+		reservations: map[string][]*costexplorer.GetReservationCoverageOutput{
+			"Amazon Elastic Compute Cloud - Compute": {
+				{
+					CoveragesByTime: []*costexplorer.CoverageByTime{
+						{Groups: []*costexplorer.ReservationCoverageGroup{reservationGroup("us-east-1", "300", "600")}},
+					},
+					NextPageToken: awsClient.String("page-2"),
+				},
+				{
+					CoveragesByTime: []*costexplorer.CoverageByTime{
+						{Groups: []*costexplorer.ReservationCoverageGroup{reservationGroup("us-east-1", "200", "400")}},
+					},
+				},
+			},
+			"Amazon Relational Database Service": {
+				{
+					CoveragesByTime: []*costexplorer.CoverageByTime{
+						{Groups: []*costexplorer.ReservationCoverageGroup{
+							reservationGroup("us-east-1", "700", "700"),
+							reservationGroup("eu-west-1", "0", "100"),
+						}},
+					},
+				},
+			},
+		},
+		savingsPlans: map[string][]*costexplorer.GetSavingsPlansCoverageOutput{
+			"Amazon Elastic Compute Cloud - Compute": {
+				{
+					SavingsPlansCoverages: []*costexplorer.SavingsPlansCoverage{
+						{
+							Attributes: map[string]*string{"REGION": awsClient.String("us-east-1")},
+							Coverage: &costexplorer.SavingsPlansCoverageData{
+								SpendCoveredBySavingsPlans: awsClient.String("20"),
+								TotalCost:                  awsClient.String("100"),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestCoverageLoad(t *testing.T) {
+
+	coverage := NewCoverageManager(newMockCostExplorerClient(), 0)
+	err := coverage.Load("123456789")
+	if err != nil {
+		t.Fatalf("unexpected error, got %v expected nil", err)
+	}
+
+	testCases := []struct {
+		resourceType string
+		region       string
+		expected     float64
+	}{
+		{"ec2", "us-east-1", 70},
+		{"rds", "us-east-1", 100},
+		{"rds", "eu-west-1", 0},
+		{"ec2", "eu-west-1", 0},
+		{"natgateway", "us-east-1", 0},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.resourceType+"_"+test.region, func(t *testing.T) {
+			result := coverage.Coverage(test.resourceType, test.region)
+			if result != test.expected {
+				t.Fatalf("unexpected coverage, got %v expected %v", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestCoverageLoadError(t *testing.T) {
+
+	client := newMockCostExplorerClient()
+	client.err = errors.New("AccessDeniedException")
+
+	err := NewCoverageManager(client, 0).Load("123456789")
+	if err != client.err {
+		t.Fatalf("unexpected error, got %v expected %v", err, client.err)
+	}
+}
+
+func TestCoverageTimePeriod(t *testing.T) {
+
+	coverage := NewCoverageManager(newMockCostExplorerClient(), 7*24*time.Hour)
+	coverage.now = func() time.Time { return time.Date(2020, 6, 15, 10, 0, 0, 0, time.UTC) }
+
+	timePeriod := coverage.getTimePeriod()
+	if *timePeriod.Start != "2020-06-08" || *timePeriod.End != "2020-06-15" {
+		t.Fatalf("unexpected time period, got %s - %s", *timePeriod.Start, *timePeriod.End)
+	}
+}
+
+func TestCoverageCollector(t *testing.T) {
+
+	coverage := NewCoverageManager(newMockCostExplorerClient(), 0)
+	err := coverage.Load("")
+	if err != nil {
+		t.Fatalf("unexpected error, got %v expected nil", err)
+	}
+
+	type detectedPrice struct {
+		PricePerMonth          float64
+		EffectivePricePerMonth float64
+	}
+
+	mockCollector := collectorTestutils.NewMockCollector()
+	coverageCollector := NewCoverageCollector(mockCollector, coverage, "us-east-1")
+
+	coverageCollector.AddResource(collector.EventCollector{
+		ResourceName: "aws_ec2",
+		Data: struct {
+			collector.PriceDetectedFields
+		}{collector.PriceDetectedFields{PricePerHour: 1, PricePerMonth: 730}},
+	})
+	coverageCollector.AddResource(collector.EventCollector{
+		ResourceName: "aws_ec2_volumes",
+		Data:         &detectedPrice{PricePerMonth: 10},
+	})
+	coverageCollector.AddResource(collector.EventCollector{
+		ResourceName: "aws_ec2",
+		Data:         "not a struct",
+	})
+
+	if len(mockCollector.Events) != 3 {
+		t.Fatalf("unexpected events count, got %d expected %d", len(mockCollector.Events), 3)
+	}
+
+	ec2 := mockCollector.Events[0].Data.(struct{ collector.PriceDetectedFields })
+	if ec2.PricePerMonth != 730 || ec2.CoveragePercentage != 70 {
+		t.Fatalf("unexpected on demand price or coverage, got %v, %v", ec2.PricePerMonth, ec2.CoveragePercentage)
+	}
+	if ec2.EffectivePricePerHour < 0.299 || ec2.EffectivePricePerHour > 0.301 {
+		t.Fatalf("unexpected effective price per hour, got %v expected %v", ec2.EffectivePricePerHour, 0.3)
+	}
+	if ec2.EffectivePricePerMonth < 218.99 || ec2.EffectivePricePerMonth > 219.01 {
+		t.Fatalf("unexpected effective price per month, got %v expected %v", ec2.EffectivePricePerMonth, 219)
+	}
+
+	volume := mockCollector.Events[1].Data.(*detectedPrice)
+	if volume.EffectivePricePerMonth != 10 {
+		t.Fatalf("unexpected effective price per month, got %v expected %v", volume.EffectivePricePerMonth, 10)
+	}
+}
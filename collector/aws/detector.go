@@ -6,6 +6,7 @@ import (
 	"finala/collector/aws/pricing"
 	"finala/collector/config"
 	"fmt"
+	"sync"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -30,6 +31,20 @@ const (
 	defaultRegionPrice = "us-east-1"
 )
 
+// GlobalResources holds the resources that should be detected only once across all the scanned regions
+type GlobalResources struct {
+	resources map[string]struct{}
+	mutex     *sync.RWMutex
+}
+
+// NewGlobalResources creates new global resources instance
+func NewGlobalResources() *GlobalResources {
+	return &GlobalResources{
+		resources: make(map[string]struct{}),
+		mutex:     &sync.RWMutex{},
+	}
+}
+
 // DetectorManager describe tje detector manager
 type DetectorManager struct {
 	collector        collector.CollectorDescriber
@@ -39,14 +54,18 @@ type DetectorManager struct {
 	awsConfig        *awsClient.Config
 	accountIdentity  *sts.GetCallerIdentityOutput
 	region           string
-	global           map[string]struct{}
+	global           *GlobalResources
 }
 
-// NewDetectorManager create new instance of detector manager
-func NewDetectorManager(awsAuth AuthDescriptor, collector collector.CollectorDescriber, account config.AWSAccount, stsManager *STSManager, global map[string]struct{}, region string) *DetectorManager {
+// NewDetectorManager create new instance of detector manager.
+// When priceClient is nil, the prices are fetched from the aws pricing api
+func NewDetectorManager(awsAuth AuthDescriptor, collector collector.CollectorDescriber, account config.AWSAccount, stsManager *STSManager, global *GlobalResources, priceClient pricing.PricingClientDescreptor, priceCache *pricing.PriceCache, region string) *DetectorManager {
 
-	priceSession, _ := awsAuth.Login(defaultRegionPrice)
-	pricingManager := pricing.NewPricingManager(awsPricing.New(priceSession), defaultRegionPrice)
+	if priceClient == nil {
+		priceSession, _ := awsAuth.Login(defaultRegionPrice)
+		priceClient = awsPricing.New(priceSession)
+	}
+	pricingManager := pricing.NewPricingManagerWithCache(priceClient, priceCache, defaultRegionPrice)
 
 	regionSession, regionConfig := awsAuth.Login(region)
 	cloudWatchCLient := cloudwatch.NewCloudWatchManager(awsCloudwatch.New(regionSession, regionConfig))
@@ -101,11 +120,27 @@ func (dm *DetectorManager) GetAccountIdentity() *sts.GetCallerIdentityOutput {
 
 // SetGlobal marked resource as global
 func (dm *DetectorManager) SetGlobal(resourceName collector.ResourceIdentifier) {
-	dm.global[string(resourceName)] = struct{}{}
+	dm.global.mutex.Lock()
+	defer dm.global.mutex.Unlock()
+	dm.global.resources[string(resourceName)] = struct{}{}
 }
 
 // IsGlobalSet return true if the resource already exists in global slice
 func (dm *DetectorManager) IsGlobalSet(resourceName collector.ResourceIdentifier) bool {
-	_, isExists := dm.global[string(resourceName)]
+	dm.global.mutex.RLock()
+	defer dm.global.mutex.RUnlock()
+	_, isExists := dm.global.resources[string(resourceName)]
 	return isExists
 }
+
+// TrySetGlobal marked resource as global, returns false if the resource was already marked.
+// The check and the mark are done under the same lock, so only one of the regions that are scanned in parallel detects the resource
+func (dm *DetectorManager) TrySetGlobal(resourceName collector.ResourceIdentifier) bool {
+	dm.global.mutex.Lock()
+	defer dm.global.mutex.Unlock()
+	if _, isExists := dm.global.resources[string(resourceName)]; isExists {
+		return false
+	}
+	dm.global.resources[string(resourceName)] = struct{}{}
+	return true
+}
@@ -3,6 +3,8 @@ package aws
 import (
 	"finala/collector/config"
 	collectorTestutils "finala/collector/testutils"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
@@ -54,8 +56,8 @@ func TestDetector(t *testing.T) {
 	mockAuth := &mockAuth{}
 	mockSTS := NewMockSTS()
 	collector := collectorTestutils.NewMockCollector()
-	global := make(map[string]struct{})
-	detector := NewDetectorManager(mockAuth, collector, account, mockSTS, global, region)
+	global := NewGlobalResources()
+	detector := NewDetectorManager(mockAuth, collector, account, mockSTS, global, nil, nil, region)
 
 	if detector.GetRegion() != region {
 		t.Fatalf("unexpected collector region, got %s expected %s", detector.GetRegion(), region)
@@ -71,3 +73,71 @@ func TestDetector(t *testing.T) {
 	}
 
 }
+
+func TestGlobalResources(t *testing.T) {
+
+	account := config.AWSAccount{
+		Name:    "foo",
+		Regions: []string{"bar"},
+	}
+	mockAuth := &mockAuth{}
+	mockSTS := NewMockSTS()
+	collector := collectorTestutils.NewMockCollector()
+	global := NewGlobalResources()
+
+	regions := []string{"us-east-1", "us-west-2", "eu-west-1"}
+	var wg sync.WaitGroup
+	for _, region := range regions {
+		detector := NewDetectorManager(mockAuth, collector, account, mockSTS, global, nil, nil, region)
+		wg.Add(1)
+		go func(detector *DetectorManager) {
+			defer wg.Done()
+			detector.SetGlobal(detector.GetResourceIdentifier(detector.GetRegion()))
+		}(detector)
+	}
+	wg.Wait()
+
+	detector := NewDetectorManager(mockAuth, collector, account, mockSTS, global, nil, nil, "us-east-1")
+	for _, region := range regions {
+		if !detector.IsGlobalSet(detector.GetResourceIdentifier(region)) {
+			t.Fatalf("unexpected global resource state, %s should be set", region)
+		}
+	}
+
+	if detector.IsGlobalSet(detector.GetResourceIdentifier("foo")) {
+		t.Fatalf("unexpected global resource state, foo should not be set")
+	}
+
+}
+
+func TestTrySetGlobal(t *testing.T) {
+
+	account := config.AWSAccount{
+		Name:    "foo",
+		Regions: []string{"bar"},
+	}
+	mockAuth := &mockAuth{}
+	mockSTS := NewMockSTS()
+	collector := collectorTestutils.NewMockCollector()
+	global := NewGlobalResources()
+
+	regions := []string{"us-east-1", "us-west-2", "eu-west-1", "eu-central-1"}
+	var setCount int32
+	var wg sync.WaitGroup
+	for _, region := range regions {
+		detector := NewDetectorManager(mockAuth, collector, account, mockSTS, global, nil, nil, region)
+		wg.Add(1)
+		go func(detector *DetectorManager) {
+			defer wg.Done()
+			if detector.TrySetGlobal(detector.GetResourceIdentifier("iam_users")) {
+				atomic.AddInt32(&setCount, 1)
+			}
+		}(detector)
+	}
+	wg.Wait()
+
+	if setCount != 1 {
+		t.Fatalf("unexpected global resource marks, got %d expected %d", setCount, 1)
+	}
+
+}
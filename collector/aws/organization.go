@@ -0,0 +1,181 @@
+package aws
+
+import (
+	"errors"
+	"finala/collector/config"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/organizations"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// organizationsRegion defines the region of the organizations api endpoint
+	organizationsRegion = "us-east-1"
+
+	// roleTemplateAccountID defines the placeholder of the account id in the organization role template
+	roleTemplateAccountID = "{{account_id}}"
+)
+
+// ErrMissingRoleTemplate returned when the organization block has no role template
+var ErrMissingRoleTemplate = errors.New("organization role template is required")
+
+// OrganizationsClientDescriptor defines the organizations client
+type OrganizationsClientDescriptor interface {
+	ListAccountsPages(input *organizations.ListAccountsInput, fn func(*organizations.ListAccountsOutput, bool) bool) error
+	ListParents(input *organizations.ListParentsInput) (*organizations.ListParentsOutput, error)
+}
+
+// OrganizationManager describe the organization accounts discovery
+type OrganizationManager struct {
+	client       OrganizationsClientDescriptor
+	organization config.OrganizationConfig
+	parents      map[string]*organizations.Parent
+}
+
+// NewOrganizationManager implements AWS GO SDK
+func NewOrganizationManager(client OrganizationsClientDescriptor, organization config.OrganizationConfig) *OrganizationManager {
+	return &OrganizationManager{
+		client:       client,
+		organization: organization,
+		parents:      make(map[string]*organizations.Parent),
+	}
+}
+
+// DiscoverOrganizationAccounts returns the accounts of the given organization, logged in with the organization credentials
+func DiscoverOrganizationAccounts(organization config.OrganizationConfig) ([]config.AWSAccount, error) {
+
+	awsAuth := NewAuth(organization.AWSAccount)
+	sess, awsConfig := awsAuth.Login(organizationsRegion)
+
+	return NewOrganizationManager(organizations.New(sess, awsConfig), organization).Accounts()
+}
+
+// Accounts returns the active organization accounts that match the OU filters, with the role of the role template.
+// The accounts roles are assumed with the organization credentials
+func (om *OrganizationManager) Accounts() ([]config.AWSAccount, error) {
+
+	accounts := []config.AWSAccount{}
+	if om.organization.RoleTemplate == "" {
+		return accounts, ErrMissingRoleTemplate
+	}
+
+	organizationAccounts := []*organizations.Account{}
+	err := om.client.ListAccountsPages(&organizations.ListAccountsInput{}, func(page *organizations.ListAccountsOutput, lastPage bool) bool {
+		organizationAccounts = append(organizationAccounts, page.Accounts...)
+		return true
+	})
+	if err != nil {
+		log.WithError(err).Error("could not list organization accounts")
+		return accounts, err
+	}
+
+	for _, account := range organizationAccounts {
+		logger := log.WithFields(log.Fields{
+			"account_id":   *account.Id,
+			"account_name": *account.Name,
+		})
+
+		if *account.Status != organizations.AccountStatusActive {
+			logger.WithField("status", *account.Status).Debug("organization account is not active")
+			continue
+		}
+
+		included, err := om.isIncluded(*account.Id)
+		if err != nil {
+			logger.WithError(err).Error("could not get organization account parents")
+			return accounts, err
+		}
+		if !included {
+			logger.Debug("organization account was excluded by the OU filters")
+			continue
+		}
+
+		// The account role is assumed with the organization credentials (and the organization role, if exists)
+		accounts = append(accounts, config.AWSAccount{
+			Name:           *account.Name,
+			AccessKey:      om.organization.AccessKey,
+			SecretKey:      om.organization.SecretKey,
+			SessionToken:   om.organization.SessionToken,
+			Profile:        om.organization.Profile,
+			SourceRole:     om.organization.Role,
+			Role:           strings.Replace(om.organization.RoleTemplate, roleTemplateAccountID, *account.Id, -1),
+			Regions:        om.organization.Regions,
+			ExcludeRegions: om.organization.ExcludeRegions,
+		})
+	}
+
+	log.WithFields(log.Fields{
+		"organization_accounts": len(organizationAccounts),
+		"accounts":              len(accounts),
+	}).Info("organization accounts were discovered")
+
+	return accounts, nil
+}
+
+// isIncluded returns true if the account OUs (all the parents up to the root) match the include and exclude lists
+func (om *OrganizationManager) isIncluded(accountID string) (bool, error) {
+
+	ancestors := []string{}
+	childID := accountID
+	for {
+		parent, err := om.getParent(childID)
+		if err != nil {
+			return false, err
+		}
+		if parent == nil {
+			break
+		}
+
+		ancestors = append(ancestors, *parent.Id)
+		if *parent.Type == organizations.ParentTypeRoot {
+			break
+		}
+		childID = *parent.Id
+	}
+
+	included := len(om.organization.IncludeOUs) == 0
+	for _, ancestor := range ancestors {
+		if containsString(om.organization.ExcludeOUs, ancestor) {
+			return false, nil
+		}
+		if containsString(om.organization.IncludeOUs, ancestor) {
+			included = true
+		}
+	}
+
+	return included, nil
+}
+
+// getParent returns the parent of the given account or OU, the parents are cached since accounts share OUs
+func (om *OrganizationManager) getParent(childID string) (*organizations.Parent, error) {
+
+	if parent, found := om.parents[childID]; found {
+		return parent, nil
+	}
+
+	output, err := om.client.ListParents(&organizations.ListParentsInput{
+		ChildId: &childID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var parent *organizations.Parent
+	if len(output.Parents) > 0 {
+		parent = output.Parents[0]
+	}
+	om.parents[childID] = parent
+
+	return parent, nil
+}
+
+// containsString returns true if the given value is in the list
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
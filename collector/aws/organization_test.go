@@ -0,0 +1,209 @@
+package aws
+
+import (
+	"errors"
+	"finala/collector/config"
+	"testing"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+type mockOrganizationsClient struct {
+	accounts         [][]*organizations.Account
+	parents          map[string]*organizations.Parent
+	listParentsCount int
+	err              error
+}
+
+func (moc *mockOrganizationsClient) ListAccountsPages(input *organizations.ListAccountsInput, fn func(*organizations.ListAccountsOutput, bool) bool) error {
+	if moc.err != nil {
+		return moc.err
+	}
+	for i, page := range moc.accounts {
+		if !fn(&organizations.ListAccountsOutput{Accounts: page}, i == len(moc.accounts)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (moc *mockOrganizationsClient) ListParents(input *organizations.ListParentsInput) (*organizations.ListParentsOutput, error) {
+	moc.listParentsCount++
+	return &organizations.ListParentsOutput{
+		Parents: []*organizations.Parent{moc.parents[*input.ChildId]},
+	}, nil
+}
+
+func organizationAccount(id, name, status string) *organizations.Account {
+	return &organizations.Account{
+		Id:     awsClient.String(id),
+		Name:   awsClient.String(name),
+		Status: awsClient.String(status),
+	}
+}
+
+func organizationParent(id, parentType string) *organizations.Parent {
+	return &organizations.Parent{
+		Id:   awsClient.String(id),
+		Type: awsClient.String(parentType),
+	}
+}
+
+func newMockOrganizationsClient() *mockOrganizationsClient {
+
+	// root
+	// ├── ou-prod
+	// │   ├── 111 (production)
+	// │   └── ou-sandbox
+	// │       └── 222 (sandbox)
+	// ├── 333 (management)
+	// └── 444 (suspended)
+	return &mockOrganizationsClient{
+		accounts: [][]*organizations.Account{
+			{
+				organizationAccount("111", "production", organizations.AccountStatusActive),
+				organizationAccount("222", "sandbox", organizations.AccountStatusActive),
+			},
+			{
+				organizationAccount("333", "management", organizations.AccountStatusActive),
+				organizationAccount("444", "suspended", organizations.AccountStatusSuspended),
+			},
+		},
+		parents: map[string]*organizations.Parent{
+			"111":        organizationParent("ou-prod", organizations.ParentTypeOrganizationalUnit),
+			"222":        organizationParent("ou-sandbox", organizations.ParentTypeOrganizationalUnit),
+			"333":        organizationParent("r-root", organizations.ParentTypeRoot),
+			"444":        organizationParent("r-root", organizations.ParentTypeRoot),
+			"ou-sandbox": organizationParent("ou-prod", organizations.ParentTypeOrganizationalUnit),
+			"ou-prod":    organizationParent("r-root", organizations.ParentTypeRoot),
+		},
+	}
+}
+
+func TestOrganizationAccounts(t *testing.T) {
+
+	testCases := []struct {
+		name             string
+		includeOUs       []string
+		excludeOUs       []string
+		expectedAccounts []string
+	}{
+		{"all", nil, nil, []string{"production", "sandbox", "management"}},
+		{"include", []string{"ou-prod"}, nil, []string{"production", "sandbox"}},
+		{"exclude", nil, []string{"ou-sandbox"}, []string{"production", "management"}},
+		{"include_and_exclude", []string{"ou-prod"}, []string{"ou-sandbox"}, []string{"production"}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			client := newMockOrganizationsClient()
+			organizationManager := NewOrganizationManager(client, config.OrganizationConfig{
+				AWSAccount:   config.AWSAccount{Regions: []string{"us-east-1"}},
+				RoleTemplate: "arn:aws:iam::{{account_id}}:role/finala",
+				IncludeOUs:   test.includeOUs,
+				ExcludeOUs:   test.excludeOUs,
+			})
+
+			accounts, err := organizationManager.Accounts()
+			if err != nil {
+				t.Fatalf("unexpected error, got %v expected %v", err, nil)
+			}
+
+			if len(accounts) != len(test.expectedAccounts) {
+				t.Fatalf("unexpected accounts count, got %d expected %d", len(accounts), len(test.expectedAccounts))
+			}
+
+			for i, account := range accounts {
+				if account.Name != test.expectedAccounts[i] {
+					t.Fatalf("unexpected account name, got %s expected %s", account.Name, test.expectedAccounts[i])
+				}
+				if len(account.Regions) != 1 || account.Regions[0] != "us-east-1" {
+					t.Fatalf("unexpected account regions, got %v expected %v", account.Regions, []string{"us-east-1"})
+				}
+			}
+
+			if accounts[0].Role != "arn:aws:iam::111:role/finala" {
+				t.Fatalf("unexpected account role, got %s expected %s", accounts[0].Role, "arn:aws:iam::111:role/finala")
+			}
+
+			// The parents of an OU are fetched only once
+			if client.listParentsCount != 5 {
+				t.Fatalf("unexpected list parents requests count, got %d expected %d", client.listParentsCount, 5)
+			}
+		})
+	}
+
+	t.Run("missing_role_template", func(t *testing.T) {
+		organizationManager := NewOrganizationManager(newMockOrganizationsClient(), config.OrganizationConfig{})
+		_, err := organizationManager.Accounts()
+		if err != ErrMissingRoleTemplate {
+			t.Fatalf("unexpected error, got %v expected %v", err, ErrMissingRoleTemplate)
+		}
+	})
+
+	t.Run("list_accounts_error", func(t *testing.T) {
+		listErr := errors.New("error")
+		client := newMockOrganizationsClient()
+		client.err = listErr
+		organizationManager := NewOrganizationManager(client, config.OrganizationConfig{RoleTemplate: "role"})
+		_, err := organizationManager.Accounts()
+		if err != listErr {
+			t.Fatalf("unexpected error, got %v expected %v", err, listErr)
+		}
+	})
+
+}
+
+func TestOrganizationAccountsCredentials(t *testing.T) {
+
+	organizationManager := NewOrganizationManager(newMockOrganizationsClient(), config.OrganizationConfig{
+		AWSAccount: config.AWSAccount{
+			AccessKey:    "management-key",
+			SecretKey:    "management-secret",
+			SessionToken: "management-session",
+			Regions:      []string{"us-east-1"},
+		},
+		RoleTemplate: "arn:aws:iam::{{account_id}}:role/finala",
+	})
+
+	accounts, err := organizationManager.Accounts()
+	if err != nil {
+		t.Fatalf("unexpected error, got %v expected %v", err, nil)
+	}
+
+	account := accounts[0]
+	if account.AccessKey != "management-key" || account.SecretKey != "management-secret" || account.SessionToken != "management-session" {
+		t.Fatalf("unexpected account credentials, got %s/%s/%s expected the organization credentials", account.AccessKey, account.SecretKey, account.SessionToken)
+	}
+
+	// Capture the session that assumes the account role
+	var roleSession *session.Session
+	var assumedRole string
+	defer func(provider func(*session.Session, string) *stscreds.AssumeRoleProvider) {
+		newRoleProvider = provider
+	}(newRoleProvider)
+	newRoleProvider = func(sess *session.Session, role string) *stscreds.AssumeRoleProvider {
+		roleSession = sess
+		assumedRole = role
+		return roleProvider(sess, role)
+	}
+
+	NewAuth(account).Login("us-east-1")
+
+	if assumedRole != "arn:aws:iam::111:role/finala" {
+		t.Fatalf("unexpected assumed role, got %s expected %s", assumedRole, "arn:aws:iam::111:role/finala")
+	}
+
+	credentialsValue, err := roleSession.Config.Credentials.Get()
+	if err != nil {
+		t.Fatalf("unexpected role session credentials error, got %v expected %v", err, nil)
+	}
+
+	if credentialsValue.ProviderName != credentials.StaticProviderName || credentialsValue.AccessKeyID != "management-key" {
+		t.Fatalf("unexpected role session credentials source, got %s (%s) expected %s (%s)", credentialsValue.ProviderName, credentialsValue.AccessKeyID, credentials.StaticProviderName, "management-key")
+	}
+}
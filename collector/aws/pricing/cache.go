@@ -0,0 +1,161 @@
+package pricing
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultCacheTTL defines the default time a cached price is valid
+	defaultCacheTTL = 24 * time.Hour
+)
+
+// cacheEntry describe a cached price
+type cacheEntry struct {
+	Price     float64   `json:"price"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PriceCache holds the prices by the hash of their product filters, shared by all the pricing managers of a run.
+// When a path is given, the cache is loaded from the file and can be saved back to it for the next runs
+type PriceCache struct {
+	path    string
+	ttl     time.Duration
+	entries map[uint64]cacheEntry
+	mutex   *sync.RWMutex
+	hits    int
+	misses  int
+	now     func() time.Time
+}
+
+// NewPriceCache creates new price cache, the cached prices of the given file (if exists) are loaded
+func NewPriceCache(path string, ttl time.Duration) (*PriceCache, error) {
+
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+
+	cache := &PriceCache{
+		path:    path,
+		ttl:     ttl,
+		entries: make(map[uint64]cacheEntry),
+		mutex:   &sync.RWMutex{},
+		now:     time.Now,
+	}
+
+	if path == "" {
+		return cache, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &cache.entries)
+	if err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"file":    path,
+		"entries": len(cache.entries),
+	}).Info("pricing cache was loaded")
+
+	return cache, nil
+}
+
+// Get returns the cached price of the given hash, expired prices are not returned
+func (pc *PriceCache) Get(hash uint64) (float64, bool) {
+
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+
+	entry, found := pc.entries[hash]
+	if !found || pc.now().Sub(entry.UpdatedAt) > pc.ttl {
+		pc.misses++
+		return 0, false
+	}
+
+	pc.hits++
+	return entry.Price, true
+}
+
+// Set caches the price of the given hash
+func (pc *PriceCache) Set(hash uint64, price float64) {
+
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+
+	pc.entries[hash] = cacheEntry{
+		Price:     price,
+		UpdatedAt: pc.now(),
+	}
+}
+
+// Stats returns the cache hits and misses count
+func (pc *PriceCache) Stats() (int, int) {
+
+	pc.mutex.RLock()
+	defer pc.mutex.RUnlock()
+	return pc.hits, pc.misses
+}
+
+// Save writes the valid cached prices to the cache file
+func (pc *PriceCache) Save() error {
+
+	if pc.path == "" {
+		return nil
+	}
+
+	pc.mutex.RLock()
+	entries := make(map[uint64]cacheEntry)
+	for hash, entry := range pc.entries {
+		if pc.now().Sub(entry.UpdatedAt) <= pc.ttl {
+			entries[hash] = entry
+		}
+	}
+	pc.mutex.RUnlock()
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	// The file is written to a temporary file first, an interrupted save will not corrupt the cache
+	tmpFile, err := ioutil.TempFile(filepath.Dir(pc.path), filepath.Base(pc.path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	err = os.Rename(tmpFile.Name(), pc.path)
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"file":    pc.path,
+		"entries": len(entries),
+	}).Info("pricing cache was saved")
+
+	return nil
+}
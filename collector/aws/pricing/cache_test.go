@@ -0,0 +1,85 @@
+package pricing
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/pricing"
+)
+
+func TestPriceCache(t *testing.T) {
+
+	directory, _ := ioutil.TempDir("", "pricing")
+	defer os.RemoveAll(directory)
+	path := filepath.Join(directory, "cache.json")
+
+	cache, err := NewPriceCache(path, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error, got %v expected %v", err, nil)
+	}
+
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	if _, found := cache.Get(1); found {
+		t.Fatalf("unexpected cached price of an empty cache")
+	}
+
+	cache.Set(1, 1.5)
+	price, found := cache.Get(1)
+	if !found || price != 1.5 {
+		t.Fatalf("unexpected cached price, got %v expected %v", price, 1.5)
+	}
+
+	err = cache.Save()
+	if err != nil {
+		t.Fatalf("unexpected save error, got %v expected %v", err, nil)
+	}
+
+	hits, misses := cache.Stats()
+	if hits != 1 || misses != 1 {
+		t.Fatalf("unexpected cache stats, got %d/%d expected %d/%d", hits, misses, 1, 1)
+	}
+
+	t.Run("load", func(t *testing.T) {
+		loadedCache, err := NewPriceCache(path, time.Hour)
+		if err != nil {
+			t.Fatalf("unexpected error, got %v expected %v", err, nil)
+		}
+
+		price, found := loadedCache.Get(1)
+		if !found || price != 1.5 {
+			t.Fatalf("unexpected loaded price, got %v expected %v", price, 1.5)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		loadedCache, _ := NewPriceCache(path, time.Hour)
+		loadedCache.now = func() time.Time { return now.Add(2 * time.Hour) }
+
+		if _, found := loadedCache.Get(1); found {
+			t.Fatalf("unexpected expired cached price")
+		}
+	})
+
+	t.Run("shared_by_pricing_managers", func(t *testing.T) {
+		sharedCache, _ := NewPriceCache("", 0)
+		mockPricing := newMockPricing(nil)
+
+		for _, region := range []string{"us-east-1", "us-west-2"} {
+			pricingManager := NewPricingManagerWithCache(mockPricing, sharedCache, region)
+			_, err := pricingManager.GetPrice(pricing.GetProductsInput{}, "", "us-east-1")
+			if err != nil {
+				t.Fatalf("unexpected error, got %v expected %v", err, nil)
+			}
+		}
+
+		if mockPricing.GetProductCallCount != 1 {
+			t.Fatalf("unexpected pricing api calls, got %d expected %d", mockPricing.GetProductCallCount, 1)
+		}
+	})
+
+}
@@ -0,0 +1,568 @@
+package pricing
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	awsPricing "github.com/aws/aws-sdk-go/service/pricing"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// termTypeField defines the filter field of the product term type (OnDemand, Reserved)
+	termTypeField = "termtype"
+
+	// csvMetadataRows defines the count of the metadata rows before the header row of a bulk offer CSV file
+	csvMetadataRows = 5
+)
+
+// ErrInvalidOfferFile returned when a bulk offer file could not be parsed
+var ErrInvalidOfferFile = errors.New("invalid bulk offer file")
+
+// csvProductColumns defines the bulk offer CSV columns that describe the product term and price, all the other columns are product attributes
+var csvProductColumns = map[string]struct{}{
+	"sku": {}, "offertermcode": {}, "ratecode": {}, "termtype": {}, "pricedescription": {}, "effectivedate": {},
+	"startingrange": {}, "endingrange": {}, "unit": {}, "priceperunit": {}, "currency": {}, "relatedto": {},
+	"leasecontractlength": {}, "purchaseoption": {}, "offeringclass": {}, "productfamily": {}, "servicecode": {},
+}
+
+// csvTermAttributesColumns defines the bulk offer CSV columns that describe a reserved term
+var csvTermAttributesColumns = map[string]string{
+	"leasecontractlength": "LeaseContractLength",
+	"purchaseoption":      "PurchaseOption",
+	"offeringclass":       "OfferingClass",
+}
+
+// indexedFields defines the product fields that are indexed when the products are loaded, the detectors pricing filters
+// include at least one of them so a query scans only the products of the most selective indexed filter
+var indexedFields = map[string]struct{}{
+	"usagetype":    {},
+	"instancetype": {},
+	"location":     {},
+}
+
+// offerProduct describe a product of the bulk offer file
+type offerProduct struct {
+	SKU           string            `json:"sku"`
+	ProductFamily string            `json:"productFamily"`
+	Attributes    map[string]string `json:"attributes"`
+}
+
+// offlineProduct describe a loaded product with all its terms, by term type
+type offlineProduct struct {
+	serviceCode string
+	product     *offerProduct
+	terms       map[string]map[string]interface{}
+}
+
+// OfflinePriceList answers the pricing products queries from the AWS bulk offer files (JSON or CSV) of a local directory,
+// it can replace the pricing api client of the pricing manager. The files of the directory are loaded at start, and the
+// files of a region sub directory (for example us-east-1/AmazonEC2.json) are loaded on the first query of the region
+type OfflinePriceList struct {
+	products []*offlineProduct
+	index    map[string][]*offlineProduct
+	regions  map[string]string
+	mutex    *sync.RWMutex
+}
+
+// NewOfflinePriceList loads all the bulk offer files (*.json and *.csv) of the given directory
+func NewOfflinePriceList(directory string) (*OfflinePriceList, error) {
+
+	priceList := &OfflinePriceList{
+		products: []*offlineProduct{},
+		index:    map[string][]*offlineProduct{},
+		regions:  map[string]string{},
+		mutex:    &sync.RWMutex{},
+	}
+
+	files, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if !file.IsDir() {
+			continue
+		}
+
+		info, found := regionsInfo[file.Name()]
+		if !found {
+			log.WithField("directory", file.Name()).Debug("skipping offline price list directory of an unknown region")
+			continue
+		}
+		priceList.regions[strings.ToLower(info.fullName)] = filepath.Join(directory, file.Name())
+	}
+
+	err = priceList.loadDirectory(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"directory": directory,
+		"products":  len(priceList.products),
+		"regions":   len(priceList.regions),
+	}).Info("offline price list was loaded")
+
+	return priceList, nil
+}
+
+// GetProducts returns the products that match all the given filters, in the pricing api response format
+func (opl *OfflinePriceList) GetProducts(input *awsPricing.GetProductsInput) (*awsPricing.GetProductsOutput, error) {
+
+	err := opl.loadRegion(input)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &awsPricing.GetProductsOutput{
+		PriceList: []awsClient.JSONValue{},
+	}
+
+	opl.mutex.RLock()
+	defer opl.mutex.RUnlock()
+
+	for _, product := range opl.candidates(input) {
+		if !product.match(input) {
+			continue
+		}
+
+		output.PriceList = append(output.PriceList, awsClient.JSONValue{
+			"serviceCode": product.serviceCode,
+			"product": map[string]interface{}{
+				"sku":           product.product.SKU,
+				"productFamily": product.product.ProductFamily,
+				"attributes":    product.product.Attributes,
+			},
+			"terms": product.terms,
+		})
+	}
+
+	return output, nil
+}
+
+// candidates returns the products of the most selective indexed filter of the input, or all the products when the input
+// has no service code or no indexed filter
+func (opl *OfflinePriceList) candidates(input *awsPricing.GetProductsInput) []*offlineProduct {
+
+	if input.ServiceCode == nil {
+		return opl.products
+	}
+
+	var candidates []*offlineProduct
+	indexed := false
+	for _, filter := range input.Filters {
+		if filter.Field == nil || filter.Value == nil {
+			continue
+		}
+
+		field := strings.ToLower(*filter.Field)
+		if _, found := indexedFields[field]; !found {
+			continue
+		}
+
+		products := opl.index[indexKey(*input.ServiceCode, field, *filter.Value)]
+		if !indexed || len(products) < len(candidates) {
+			candidates = products
+			indexed = true
+		}
+	}
+
+	if !indexed {
+		return opl.products
+	}
+	return candidates
+}
+
+// loadRegion loads the region sub directory of the input location filter, if it was not loaded yet.
+// A region directory that could not be loaded is not loaded again
+func (opl *OfflinePriceList) loadRegion(input *awsPricing.GetProductsInput) error {
+
+	for _, filter := range input.Filters {
+		if filter.Field == nil || filter.Value == nil || !strings.EqualFold(*filter.Field, "location") {
+			continue
+		}
+
+		location := strings.ToLower(*filter.Value)
+
+		opl.mutex.RLock()
+		_, found := opl.regions[location]
+		opl.mutex.RUnlock()
+		if !found {
+			return nil
+		}
+
+		opl.mutex.Lock()
+		defer opl.mutex.Unlock()
+
+		// Another query could load the region while waiting for the lock
+		directory, found := opl.regions[location]
+		if !found {
+			return nil
+		}
+		delete(opl.regions, location)
+
+		productsCount := len(opl.products)
+		err := opl.loadDirectory(directory)
+		if err != nil {
+			return err
+		}
+
+		log.WithFields(log.Fields{
+			"directory": directory,
+			"products":  len(opl.products) - productsCount,
+		}).Info("offline region price list was loaded")
+
+		return nil
+	}
+
+	return nil
+}
+
+// loadDirectory loads the bulk offer files of the directory, the products are added only when all the files are loaded
+func (opl *OfflinePriceList) loadDirectory(directory string) error {
+
+	files, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return err
+	}
+
+	products := []*offlineProduct{}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(directory, file.Name())
+		var fileProducts []*offlineProduct
+		switch strings.ToLower(filepath.Ext(file.Name())) {
+		case ".json":
+			fileProducts, err = loadJSON(path)
+		case ".csv":
+			fileProducts, err = loadCSV(path)
+		default:
+			continue
+		}
+
+		if err != nil {
+			log.WithError(err).WithField("file", path).Error("could not load bulk offer file")
+			return err
+		}
+		products = append(products, fileProducts...)
+	}
+
+	for _, product := range products {
+		opl.add(product)
+	}
+
+	return nil
+}
+
+// add adds the product to the products list and to the index of its indexed fields
+func (opl *OfflinePriceList) add(product *offlineProduct) {
+
+	opl.products = append(opl.products, product)
+	for attribute, value := range product.product.Attributes {
+		field := strings.ToLower(attribute)
+		if _, found := indexedFields[field]; !found {
+			continue
+		}
+
+		key := indexKey(product.serviceCode, field, value)
+		opl.index[key] = append(opl.index[key], product)
+	}
+}
+
+// indexKey returns the index key of a product field value, compared case insensitive as the pricing api
+func indexKey(serviceCode string, field string, value string) string {
+	return strings.ToLower(fmt.Sprintf("%s|%s|%s", serviceCode, field, value))
+}
+
+// match returns true if the product matches the service code and all the input filters.
+// As the pricing api, fields and values are compared case insensitive
+func (op *offlineProduct) match(input *awsPricing.GetProductsInput) bool {
+
+	if input.ServiceCode != nil && !strings.EqualFold(*input.ServiceCode, op.serviceCode) {
+		return false
+	}
+
+	for _, filter := range input.Filters {
+		if filter.Field == nil || filter.Value == nil {
+			continue
+		}
+
+		field := strings.ToLower(*filter.Field)
+		var value string
+		switch field {
+		case termTypeField:
+			for termType := range op.terms {
+				if strings.EqualFold(termType, *filter.Value) {
+					value = termType
+					break
+				}
+			}
+		case "sku":
+			value = op.product.SKU
+		case "productfamily":
+			value = op.product.ProductFamily
+		case "servicecode":
+			value = op.serviceCode
+		default:
+			for attribute, attributeValue := range op.product.Attributes {
+				if strings.EqualFold(attribute, field) {
+					value = attributeValue
+					break
+				}
+			}
+		}
+
+		if !strings.EqualFold(value, *filter.Value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// loadJSON loads a bulk offer JSON file. The file is decoded product by product and term by term,
+// so only the loaded products are kept in memory and not the whole decoded file
+func loadJSON(path string) ([]*offlineProduct, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	if !readDelim(decoder, '{') {
+		return nil, ErrInvalidOfferFile
+	}
+
+	var offerCode string
+	products := map[string]*offlineProduct{}
+	terms := map[string]map[string]map[string]interface{}{}
+	for decoder.More() {
+		var key string
+		err = decoder.Decode(&key)
+		if err != nil {
+			return nil, err
+		}
+
+		switch key {
+		case "offerCode":
+			err = decoder.Decode(&offerCode)
+		case "products":
+			err = decodeJSONObject(decoder, func(sku string) error {
+				product := &offerProduct{}
+				err := decoder.Decode(product)
+				products[sku] = &offlineProduct{
+					product: product,
+					terms:   map[string]map[string]interface{}{},
+				}
+				return err
+			})
+		case "terms":
+			err = decodeJSONObject(decoder, func(termType string) error {
+				return decodeJSONObject(decoder, func(sku string) error {
+					productTerms := map[string]interface{}{}
+					err := decoder.Decode(&productTerms)
+					if _, found := terms[sku]; !found {
+						terms[sku] = map[string]map[string]interface{}{}
+					}
+					terms[sku][termType] = productTerms
+					return err
+				})
+			})
+		default:
+			var value json.RawMessage
+			err = decoder.Decode(&value)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if offerCode == "" {
+		return nil, ErrInvalidOfferFile
+	}
+
+	offerProducts := []*offlineProduct{}
+	for sku, product := range products {
+		product.serviceCode = offerCode
+		if productTerms, found := terms[sku]; found {
+			product.terms = productTerms
+		}
+		offerProducts = append(offerProducts, product)
+	}
+
+	return offerProducts, nil
+}
+
+// decodeJSONObject decodes the next JSON object of the decoder, the decode function is called with every key of the
+// object and should decode its value
+func decodeJSONObject(decoder *json.Decoder, decode func(key string) error) error {
+
+	if !readDelim(decoder, '{') {
+		return ErrInvalidOfferFile
+	}
+
+	for decoder.More() {
+		var key string
+		err := decoder.Decode(&key)
+		if err != nil {
+			return err
+		}
+
+		err = decode(key)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !readDelim(decoder, '}') {
+		return ErrInvalidOfferFile
+	}
+	return nil
+}
+
+// readDelim returns true if the next token of the decoder is the given delimiter
+func readDelim(decoder *json.Decoder, delim json.Delim) bool {
+
+	token, err := decoder.Token()
+	if err != nil {
+		return false
+	}
+
+	value, ok := token.(json.Delim)
+	return ok && value == delim
+}
+
+// loadCSV loads a bulk offer CSV file. The file starts with metadata rows (including the offer code),
+// followed by the header row and a row for every product price dimension
+func loadCSV(path string) ([]*offlineProduct, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	var offerCode string
+	for i := 0; i < csvMetadataRows; i++ {
+		row, err := reader.Read()
+		if err != nil {
+			return nil, ErrInvalidOfferFile
+		}
+		if len(row) == 2 && strings.EqualFold(row[0], "OfferCode") {
+			offerCode = row[1]
+		}
+	}
+
+	header, err := reader.Read()
+	if err != nil || offerCode == "" {
+		return nil, ErrInvalidOfferFile
+	}
+
+	columns := make([]string, len(header))
+	for i, name := range header {
+		columns[i] = csvColumnName(name)
+	}
+
+	products := map[string]*offlineProduct{}
+	offerProducts := []*offlineProduct{}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		values := map[string]string{}
+		attributes := map[string]string{}
+		termAttributes := map[string]string{}
+		for i, column := range columns {
+			if i >= len(row) || row[i] == "" {
+				continue
+			}
+			lowerColumn := strings.ToLower(column)
+			values[lowerColumn] = row[i]
+			if name, found := csvTermAttributesColumns[lowerColumn]; found {
+				termAttributes[name] = row[i]
+			}
+			if _, found := csvProductColumns[lowerColumn]; !found {
+				attributes[column] = row[i]
+			}
+		}
+
+		sku := values["sku"]
+		product, found := products[sku]
+		if !found {
+			product = &offlineProduct{
+				serviceCode: offerCode,
+				product: &offerProduct{
+					SKU:           sku,
+					ProductFamily: values["productfamily"],
+					Attributes:    attributes,
+				},
+				terms: map[string]map[string]interface{}{},
+			}
+			products[sku] = product
+			offerProducts = append(offerProducts, product)
+		}
+
+		termType := values["termtype"]
+		if _, found := product.terms[termType]; !found {
+			product.terms[termType] = map[string]interface{}{}
+		}
+
+		termKey := sku + "." + values["offertermcode"]
+		term, found := product.terms[termType][termKey].(map[string]interface{})
+		if !found {
+			term = map[string]interface{}{
+				"sku":             sku,
+				"offerTermCode":   values["offertermcode"],
+				"effectiveDate":   values["effectivedate"],
+				"priceDimensions": map[string]interface{}{},
+				"termAttributes":  termAttributes,
+			}
+			product.terms[termType][termKey] = term
+		}
+
+		term["priceDimensions"].(map[string]interface{})[values["ratecode"]] = map[string]interface{}{
+			"rateCode":     values["ratecode"],
+			"description":  values["pricedescription"],
+			"unit":         values["unit"],
+			"beginRange":   values["startingrange"],
+			"endRange":     values["endingrange"],
+			"pricePerUnit": map[string]string{values["currency"]: values["priceperunit"]},
+		}
+	}
+
+	return offerProducts, nil
+}
+
+// csvColumnName converts the bulk offer CSV header to the attribute name of the pricing api, for example: "Instance Type" to "instanceType"
+func csvColumnName(header string) string {
+
+	name := strings.Replace(header, " ", "", -1)
+	if name == "" {
+		return name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
@@ -0,0 +1,139 @@
+package pricing
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/pricing"
+)
+
+func termMatch(field, value string) *pricing.Filter {
+	return &pricing.Filter{
+		Type:  awsClient.String("TERM_MATCH"),
+		Field: awsClient.String(field),
+		Value: awsClient.String(value),
+	}
+}
+
+func TestOfflinePriceList(t *testing.T) {
+
+	_, filename, _, _ := runtime.Caller(0)
+	priceList, err := NewOfflinePriceList(fmt.Sprintf("%s/testutil/offline", filepath.Dir(filename)))
+	if err != nil {
+		t.Fatalf("unexpected error, got %v expected %v", err, nil)
+	}
+
+	if len(priceList.regions) != 1 {
+		t.Fatalf("unexpected regions to load, got %d expected %d", len(priceList.regions), 1)
+	}
+
+	pricingManager := NewPricingManager(priceList, "us-east-1")
+
+	testCases := []struct {
+		name          string
+		input         pricing.GetProductsInput
+		region        string
+		expectedPrice float64
+	}{
+		{"json", pricing.GetProductsInput{
+			ServiceCode: awsClient.String("AmazonEC2"),
+			Filters: []*pricing.Filter{
+				termMatch("TermType", "OnDemand"),
+				termMatch("instanceType", "t2.micro"),
+				termMatch("operatingSystem", "Linux"),
+			},
+		}, "us-east-1", 0.0116},
+		{"json_other_region", pricing.GetProductsInput{
+			ServiceCode: awsClient.String("AmazonEC2"),
+			Filters: []*pricing.Filter{
+				termMatch("TermType", "OnDemand"),
+				termMatch("instanceType", "t2.micro"),
+			},
+		}, "eu-west-1", 0.0126},
+		{"json_region_directory", pricing.GetProductsInput{
+			ServiceCode: awsClient.String("AmazonEC2"),
+			Filters: []*pricing.Filter{
+				termMatch("TermType", "OnDemand"),
+				termMatch("instanceType", "t2.micro"),
+			},
+		}, "eu-west-2", 0.0132},
+		{"csv", pricing.GetProductsInput{
+			ServiceCode: awsClient.String("AmazonRDS"),
+			Filters: []*pricing.Filter{
+				termMatch("TermType", "OnDemand"),
+				termMatch("instanceType", "db.t2.micro"),
+				termMatch("databaseEngine", "mysql"),
+				termMatch("deploymentOption", "Multi-AZ"),
+			},
+		}, "us-east-1", 0.034},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			price, err := pricingManager.GetPrice(test.input, "", test.region)
+			if err != nil {
+				t.Fatalf("unexpected error, got %v expected %v", err, nil)
+			}
+
+			if price != test.expectedPrice {
+				t.Fatalf("unexpected price, got %v expected %v", price, test.expectedPrice)
+			}
+		})
+	}
+
+	t.Run("region_loaded", func(t *testing.T) {
+		if len(priceList.regions) != 0 {
+			t.Fatalf("unexpected regions to load, got %d expected %d", len(priceList.regions), 0)
+		}
+	})
+
+	t.Run("index", func(t *testing.T) {
+		candidates := priceList.candidates(&pricing.GetProductsInput{
+			ServiceCode: awsClient.String("AmazonEC2"),
+			Filters: []*pricing.Filter{
+				termMatch("instanceType", "T2.MICRO"),
+				termMatch("location", "EU (Ireland)"),
+			},
+		})
+		if len(candidates) != 1 || candidates[0].product.SKU != "QRSTUVWXYZABCDEF" {
+			t.Fatalf("unexpected candidates count, got %d expected %d", len(candidates), 1)
+		}
+	})
+
+	t.Run("no_match", func(t *testing.T) {
+		output, _ := priceList.GetProducts(&pricing.GetProductsInput{
+			ServiceCode: awsClient.String("AmazonEC2"),
+			Filters:     []*pricing.Filter{termMatch("instanceType", "m5.large")},
+		})
+		if len(output.PriceList) != 0 {
+			t.Fatalf("unexpected products count, got %d expected %d", len(output.PriceList), 0)
+		}
+	})
+
+	t.Run("term_type", func(t *testing.T) {
+		output, _ := priceList.GetProducts(&pricing.GetProductsInput{
+			ServiceCode: awsClient.String("AmazonRDS"),
+			Filters:     []*pricing.Filter{termMatch("TermType", "Reserved")},
+		})
+		if len(output.PriceList) != 1 {
+			t.Fatalf("unexpected products count, got %d expected %d", len(output.PriceList), 1)
+		}
+	})
+
+	t.Run("invalid_file", func(t *testing.T) {
+		directory, _ := ioutil.TempDir("", "offline")
+		defer os.RemoveAll(directory)
+		_ = ioutil.WriteFile(filepath.Join(directory, "invalid.json"), []byte(`{"products": {}}`), 0644)
+
+		_, err := NewOfflinePriceList(directory)
+		if err != ErrInvalidOfferFile {
+			t.Fatalf("unexpected error, got %v expected %v", err, ErrInvalidOfferFile)
+		}
+	})
+
+}
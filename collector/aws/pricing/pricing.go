@@ -18,11 +18,18 @@ const (
 
 	// defaultRateCode define the default product rate code form getting the product price
 	defaultRateCode = "6YS6EN2CT7"
+
+	// FirstTierRateCode selects the price dimension of the first usage tier of a tiered priced product, for example
+	// the first 50 TB of the s3 standard storage, instead of selecting the price dimension by its rate code
+	FirstTierRateCode = "FirstTier"
 )
 
 // ErrRegionNotFound when a region is not found
 var ErrRegionNotFound = errors.New("region was not found as part of the regionsInfo map")
 
+// ErrRateCodeNotFound when the product has no on demand price of the rate code
+var ErrRateCodeNotFound = errors.New("rate code was not found as part of the product on demand terms")
+
 // regionInfo will hold data about a region pricing options
 type regionInfo struct {
 	fullName string
@@ -64,9 +71,16 @@ type PricingClientDescreptor interface {
 
 // PricingManager Pricing
 type PricingManager struct {
-	client         PricingClientDescreptor
-	region         string
-	priceResponses map[uint64]float64
+	client PricingClientDescreptor
+	region string
+	cache  *PriceCache
+}
+
+// priceCacheKey describe the cached price key, the same product filters have a different price for each rate code
+type priceCacheKey struct {
+	Input    awsPricing.GetProductsInput
+	RateCode string
+	Region   string
 }
 
 // PricingResponse describ the response of AWS pricing
@@ -94,6 +108,7 @@ type PricingOfferTerm struct {
 // PriceRateCode describe the product price
 type PriceRateCode struct {
 	Unit         string            `json:"unit"`
+	BeginRange   string            `json:"beginRange"`
 	PricePerUnit PriceCurrencyCode `json:"pricePerUnit"`
 }
 
@@ -104,12 +119,23 @@ type PriceCurrencyCode struct {
 
 // NewPricingManager implements AWS GO SDK
 func NewPricingManager(client PricingClientDescreptor, region string) *PricingManager {
+	return NewPricingManagerWithCache(client, nil, region)
+}
+
+// NewPricingManagerWithCache implements AWS GO SDK, with a price cache that can be shared by other pricing managers.
+// When the cache is nil, a new in memory cache is used
+func NewPricingManagerWithCache(client PricingClientDescreptor, cache *PriceCache, region string) *PricingManager {
+
+	if cache == nil {
+		// An in memory cache never fails to be created
+		cache, _ = NewPriceCache("", 0)
+	}
 
 	log.Debug("Initializing aws pricing SDK client")
 	return &PricingManager{
-		client:         client,
-		region:         region,
-		priceResponses: make(map[uint64]float64),
+		client: client,
+		region: region,
+		cache:  cache,
 	}
 }
 
@@ -133,12 +159,17 @@ func (p *PricingManager) GetPrice(input awsPricing.GetProductsInput, rateCode st
 		Value: awsClient.String(regionInfo.fullName),
 	})
 
-	hash, err := hashstructure.Hash(input, nil)
+	hash, err := hashstructure.Hash(priceCacheKey{
+		Input:    input,
+		RateCode: rateCode,
+		Region:   region,
+	}, nil)
 	if err != nil {
 		return 0, errors.New("Could not hash price input filter")
 	}
 
-	if val, ok := p.priceResponses[hash]; ok {
+	val, ok := p.cache.Get(hash)
+	if ok {
 		return val, nil
 	}
 
@@ -182,7 +213,23 @@ func (p *PricingManager) GetPrice(input awsPricing.GetProductsInput, rateCode st
 
 	key := fmt.Sprintf("%s.JRTCKXETXF", v.Products.SKU)
 	keyPriceDimensions := fmt.Sprintf("%s.JRTCKXETXF.%s", v.Products.SKU, rateCode)
-	usdPrice := v.Terms.OnDemand[key].PriceDimensions[keyPriceDimensions].PricePerUnit.USD
+	var priceDimension *PriceRateCode
+	if term, found := v.Terms.OnDemand[key]; found {
+		if rateCode == FirstTierRateCode {
+			priceDimension = term.getFirstTierPriceDimension()
+		} else {
+			priceDimension = term.PriceDimensions[keyPriceDimensions]
+		}
+	}
+	if priceDimension == nil {
+		log.WithFields(log.Fields{
+			"search_query": input,
+			"rate_code":    rateCode,
+		}).Error("product has no on demand price of the rate code")
+		return 0, ErrRateCodeNotFound
+	}
+
+	usdPrice := priceDimension.PricePerUnit.USD
 	price, err := strconv.ParseFloat(usdPrice, 64)
 	if err != nil {
 		log.WithError(err).WithFields(log.Fields{
@@ -192,7 +239,7 @@ func (p *PricingManager) GetPrice(input awsPricing.GetProductsInput, rateCode st
 		return 0, err
 	}
 
-	p.priceResponses[hash] = price
+	p.cache.Set(hash, price)
 
 	log.WithFields(log.Fields{
 		"input": input,
@@ -201,6 +248,18 @@ func (p *PricingManager) GetPrice(input awsPricing.GetProductsInput, rateCode st
 	return price, nil
 }
 
+// getFirstTierPriceDimension returns the price dimension of the first usage tier, the tier that begins at zero usage
+func (t *PricingOfferTerm) getFirstTierPriceDimension() *PriceRateCode {
+
+	for _, priceDimension := range t.PriceDimensions {
+		if priceDimension != nil && priceDimension.BeginRange == "0" {
+			return priceDimension
+		}
+	}
+
+	return nil
+}
+
 // GetRegionPrefix will return the prefix for a
 // pricing filter value according to a given region.
 // For example:
@@ -220,3 +279,9 @@ func (p *PricingManager) GetRegionPrefix(region string) (string, error) {
 	}
 	return prefix, nil
 }
+
+// IsRegionSupported returns true if the region has pricing metadata
+func IsRegionSupported(region string) bool {
+	_, found := regionsInfo[region]
+	return found
+}
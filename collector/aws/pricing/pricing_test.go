@@ -111,6 +111,58 @@ func TestGetPrice(t *testing.T) {
 
 	})
 
+	t.Run("first_tier_rate_code", func(t *testing.T) {
+
+		mockResponse := []awsClient.JSONValue{{
+			"product": PricingProduct{
+				SKU: "R6PXMNYCEDGZ2EYN",
+			},
+			"Terms": PricingTerms{
+				OnDemand: map[string]*PricingOfferTerm{
+					"R6PXMNYCEDGZ2EYN.JRTCKXETXF": {
+						PriceDimensions: map[string]*PriceRateCode{
+							"R6PXMNYCEDGZ2EYN.JRTCKXETXF.PXJDJ3YRG3": {
+								Unit:       "GB-Mo",
+								BeginRange: "512000",
+								PricePerUnit: PriceCurrencyCode{
+									USD: "0.021",
+								},
+							},
+							"R6PXMNYCEDGZ2EYN.JRTCKXETXF.PGHJ3S3EYE": {
+								Unit:       "GB-Mo",
+								BeginRange: "0",
+								PricePerUnit: PriceCurrencyCode{
+									USD: "0.023",
+								},
+							},
+							"R6PXMNYCEDGZ2EYN.JRTCKXETXF.D42MF2PVJS": {
+								Unit:       "GB-Mo",
+								BeginRange: "51200",
+								PricePerUnit: PriceCurrencyCode{
+									USD: "0.022",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		}
+		mockPricing := newMockPricing(mockResponse)
+
+		pricingManager := NewPricingManager(mockPricing, "us-east-1")
+		pricingInput := pricing.GetProductsInput{}
+		result, err := pricingManager.GetPrice(pricingInput, FirstTierRateCode, "us-east-1")
+
+		if err != nil {
+			t.Fatalf("unexpected error happened, got %v expected %v", err, nil)
+		}
+		if result != 0.023 {
+			t.Fatalf("unexpected first tier price, got %f expected %f", result, 0.023)
+		}
+
+	})
+
 	t.Run("invalid region", func(t *testing.T) {
 
 		mockPricing := newMockPricing(nil)
@@ -180,6 +232,21 @@ func TestGetPrice(t *testing.T) {
 
 	})
 
+	t.Run("rate_code_cache", func(t *testing.T) {
+
+		mockPricing := newMockPricing(nil)
+		pricingManager := NewPricingManager(mockPricing, "us-east-1")
+		pricingInput := pricing.GetProductsInput{}
+		_, _ = pricingManager.GetPrice(pricingInput, "", "us-east-1")
+		// the same product with another rate code should not be returned from the cache
+		_, _ = pricingManager.GetPrice(pricingInput, "1234", "us-east-1")
+
+		if mockPricing.GetProductCallCount != 2 {
+			t.Fatalf("unexpected GetPrice function requests, got %d expected %d", mockPricing.GetProductCallCount, 2)
+		}
+
+	})
+
 	t.Run("invalid usd price", func(t *testing.T) {
 
 		mockResponse := []awsClient.JSONValue{{
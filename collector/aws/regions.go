@@ -0,0 +1,101 @@
+package aws
+
+import (
+	"finala/collector/aws/pricing"
+	"finala/collector/config"
+	"path"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// allRegions defines the account regions keyword of all the regions that are enabled in the account
+	allRegions = "all"
+
+	// regionNotOptedIn defines the opt in status of a region that was not enabled in the account
+	regionNotOptedIn = "not-opted-in"
+)
+
+// RegionsClientDescriptor defines the ec2 client that describes the account regions
+type RegionsClientDescriptor interface {
+	DescribeRegions(input *ec2.DescribeRegionsInput) (*ec2.DescribeRegionsOutput, error)
+}
+
+// hasAllRegions returns true if the account regions should be discovered
+func hasAllRegions(account config.AWSAccount) bool {
+	return containsString(account.Regions, allRegions)
+}
+
+// ResolveRegions returns the account regions to scan. The `all` keyword is expanded to the regions that are enabled in the account,
+// then the exclude region patterns are removed. Discovered regions without pricing metadata are skipped, since their resources
+// would be reported with no cost, while explicitly configured regions are always scanned
+func ResolveRegions(client RegionsClientDescriptor, account config.AWSAccount) ([]string, error) {
+
+	regions := []string{}
+	explicitRegions := map[string]struct{}{}
+	for _, region := range account.Regions {
+		if region != allRegions {
+			regions = append(regions, region)
+			explicitRegions[region] = struct{}{}
+			continue
+		}
+
+		output, err := client.DescribeRegions(&ec2.DescribeRegionsInput{})
+		if err != nil {
+			log.WithError(err).WithField("account", account.Name).Error("could not describe account regions")
+			return regions, err
+		}
+
+		for _, accountRegion := range output.Regions {
+			if accountRegion.OptInStatus != nil && *accountRegion.OptInStatus == regionNotOptedIn {
+				continue
+			}
+			regions = append(regions, *accountRegion.RegionName)
+		}
+	}
+
+	resolvedRegions := []string{}
+	for _, region := range regions {
+		logger := log.WithFields(log.Fields{
+			"account": account.Name,
+			"region":  region,
+		})
+
+		if containsString(resolvedRegions, region) {
+			continue
+		}
+
+		if isRegionExcluded(account.ExcludeRegions, region) {
+			logger.Debug("region was excluded by the account exclude regions")
+			continue
+		}
+
+		if !pricing.IsRegionSupported(region) {
+			if _, found := explicitRegions[region]; !found {
+				logger.Warn("region has no pricing metadata and will not be scanned")
+				continue
+			}
+			logger.Warn("region has no pricing metadata, its resources will be reported with no cost")
+		}
+
+		resolvedRegions = append(resolvedRegions, region)
+	}
+
+	return resolvedRegions, nil
+}
+
+// isRegionExcluded returns true if the region matches one of the exclude glob patterns
+func isRegionExcluded(patterns []string, region string) bool {
+	for _, pattern := range patterns {
+		matched, err := path.Match(pattern, region)
+		if err != nil {
+			log.WithError(err).WithField("pattern", pattern).Warn("invalid exclude region pattern")
+			continue
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
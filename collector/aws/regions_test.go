@@ -0,0 +1,76 @@
+package aws
+
+import (
+	"errors"
+	"finala/collector/config"
+	"reflect"
+	"testing"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+type mockRegionsClient struct {
+	regions []*ec2.Region
+	err     error
+}
+
+func (mrc *mockRegionsClient) DescribeRegions(input *ec2.DescribeRegionsInput) (*ec2.DescribeRegionsOutput, error) {
+	return &ec2.DescribeRegionsOutput{Regions: mrc.regions}, mrc.err
+}
+
+func accountRegion(name, optInStatus string) *ec2.Region {
+	return &ec2.Region{
+		RegionName:  awsClient.String(name),
+		OptInStatus: awsClient.String(optInStatus),
+	}
+}
+
+func TestResolveRegions(t *testing.T) {
+
+	client := &mockRegionsClient{
+		regions: []*ec2.Region{
+			accountRegion("us-east-1", "opt-in-not-required"),
+			accountRegion("us-west-2", "opt-in-not-required"),
+			accountRegion("ap-south-1", "opt-in-not-required"),
+			accountRegion("ap-east-1", "not-opted-in"),
+			accountRegion("eu-south-1", "opted-in"),
+			accountRegion("xx-unknown-1", "opt-in-not-required"),
+		},
+	}
+
+	testCases := []struct {
+		name            string
+		account         config.AWSAccount
+		expectedRegions []string
+	}{
+		{"explicit", config.AWSAccount{Regions: []string{"us-east-1", "eu-west-1"}}, []string{"us-east-1", "eu-west-1"}},
+		{"explicit_without_pricing", config.AWSAccount{Regions: []string{"us-east-1", "xx-unknown-1"}}, []string{"us-east-1", "xx-unknown-1"}},
+		{"all_with_explicit_without_pricing", config.AWSAccount{Regions: []string{"all", "xx-unknown-1"}}, []string{"us-east-1", "us-west-2", "ap-south-1", "eu-south-1", "xx-unknown-1"}},
+		{"all", config.AWSAccount{Regions: []string{"all"}}, []string{"us-east-1", "us-west-2", "ap-south-1", "eu-south-1"}},
+		{"all_with_exclude", config.AWSAccount{Regions: []string{"all"}, ExcludeRegions: []string{"ap-*", "eu-south-1"}}, []string{"us-east-1", "us-west-2"}},
+		{"all_with_explicit", config.AWSAccount{Regions: []string{"us-east-1", "all"}}, []string{"us-east-1", "us-west-2", "ap-south-1", "eu-south-1"}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			regions, err := ResolveRegions(client, test.account)
+			if err != nil {
+				t.Fatalf("unexpected error, got %v expected %v", err, nil)
+			}
+
+			if !reflect.DeepEqual(regions, test.expectedRegions) {
+				t.Fatalf("unexpected regions, got %v expected %v", regions, test.expectedRegions)
+			}
+		})
+	}
+
+	t.Run("describe_error", func(t *testing.T) {
+		describeErr := errors.New("error")
+		_, err := ResolveRegions(&mockRegionsClient{err: describeErr}, config.AWSAccount{Regions: []string{"all"}})
+		if err != describeErr {
+			t.Fatalf("unexpected error, got %v expected %v", err, describeErr)
+		}
+	})
+
+}
@@ -0,0 +1,372 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// amiCreationDateLayout defines the format of the image creation date
+	amiCreationDateLayout = "2006-01-02T15:04:05.000Z"
+
+	// amiLastLaunchedTimeAttribute defines the image attribute of the last time an instance was launched from the image
+	amiLastLaunchedTimeAttribute = "lastLaunchedTime"
+)
+
+// AMIClientDescreptor is an interface defining the aws clients that describe the images and the resources that launch them
+type AMIClientDescreptor interface {
+	DescribeImages(*ec2.DescribeImagesInput) (*ec2.DescribeImagesOutput, error)
+	DescribeInstances(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error)
+	DescribeLaunchTemplates(*ec2.DescribeLaunchTemplatesInput) (*ec2.DescribeLaunchTemplatesOutput, error)
+	DescribeLaunchTemplateVersions(*ec2.DescribeLaunchTemplateVersionsInput) (*ec2.DescribeLaunchTemplateVersionsOutput, error)
+	DescribeLaunchConfigurations(*autoscaling.DescribeLaunchConfigurationsInput) (*autoscaling.DescribeLaunchConfigurationsOutput, error)
+	DescribeImageLastLaunchedTime(*ec2.DescribeImageAttributeInput) (*ImageLastLaunchedTimeOutput, error)
+}
+
+// ImageLastLaunchedTimeOutput describes the lastLaunchedTime attribute of an image, the value is empty when
+// the image was not launched since the attribute is tracked
+type ImageLastLaunchedTimeOutput struct {
+	_ struct{} `type:"structure"`
+
+	ImageId          *string             `locationName:"imageId" type:"string"`
+	LastLaunchedTime *ec2.AttributeValue `locationName:"lastLaunchedTime" type:"structure"`
+}
+
+// amiClient combines the ec2 and the autoscaling (launch configurations) clients
+type amiClient struct {
+	*ec2.EC2
+	*autoscaling.AutoScaling
+}
+
+// DescribeImageLastLaunchedTime describes the lastLaunchedTime attribute of the image. The sdk DescribeImageAttributeOutput
+// does not have this attribute, so the DescribeImageAttribute response is read into ImageLastLaunchedTimeOutput
+func (ac *amiClient) DescribeImageLastLaunchedTime(input *ec2.DescribeImageAttributeInput) (*ImageLastLaunchedTimeOutput, error) {
+
+	operation := &request.Operation{
+		Name:       "DescribeImageAttribute",
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}
+
+	input.Attribute = awsClient.String(amiLastLaunchedTimeAttribute)
+	output := &ImageLastLaunchedTimeOutput{}
+	return output, ac.EC2.NewRequest(operation, input, output).Send()
+}
+
+// AMIManager describes the AMI struct
+type AMIManager struct {
+	client             AMIClientDescreptor
+	awsManager         common.AWSManager
+	servicePricingCode string
+	Name               collector.ResourceIdentifier
+}
+
+// DetectedAMI define the detected AWS AMI
+type DetectedAMI struct {
+	Region           string
+	Metric           string
+	Name             string
+	SnapshotsCount   int
+	SnapshotsSize    int64
+	AgeDays          int
+	LastLaunchedTime time.Time
+	UnusedDays       int
+	collector.PriceDetectedFields
+}
+
+func init() {
+	register.Registry("ami", NewAMIManager)
+}
+
+// NewAMIManager implements AWS GO SDK
+func NewAMIManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = &amiClient{
+			EC2:         ec2.New(awsManager.GetSession()),
+			AutoScaling: autoscaling.New(awsManager.GetSession()),
+		}
+	}
+
+	imagesClient, ok := client.(AMIClientDescreptor)
+	if !ok {
+		return nil, errors.New("invalid ami client")
+	}
+
+	return &AMIManager{
+		client:             imagesClient,
+		awsManager:         awsManager,
+		servicePricingCode: "AmazonEC2",
+		Name:               awsManager.GetResourceIdentifier("ami"),
+	}, nil
+}
+
+// Detect unused AMIs, the images that are not used by any instance, launch template or launch configuration of the region.
+// The constraint value is the days since an instance was last launched from the image, or since the image creation
+// when it was never launched
+func (am *AMIManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	// This resource support only one metric, the constraint value is the days the image is unused
+	metric := metrics[0]
+
+	log.WithFields(log.Fields{
+		"region":   am.awsManager.GetRegion(),
+		"resource": "ami",
+	}).Info("starting to analyze resource")
+
+	am.awsManager.GetCollector().CollectStart(am.Name)
+
+	detected := []DetectedAMI{}
+
+	images, err := am.client.DescribeImages(&ec2.DescribeImagesInput{
+		Owners: []*string{awsClient.String("self")},
+	})
+	if err != nil {
+		log.WithError(err).Error("could not describe images")
+		am.awsManager.GetCollector().CollectError(am.Name, err)
+		return detected, err
+	}
+
+	usedImages, err := am.getUsedImages()
+	if err != nil {
+		log.WithError(err).Error("could not describe the images usage")
+		am.awsManager.GetCollector().CollectError(am.Name, err)
+		return detected, err
+	}
+
+	price, err := getSnapshotStoragePrice(am.awsManager, am.servicePricingCode)
+	if err != nil {
+		log.WithError(err).Error("could not get ec2 snapshot storage price")
+		price = 0
+	}
+
+	now := time.Now()
+	for _, image := range images.Images {
+
+		log.WithField("id", *image.ImageId).Debug("checking ami")
+
+		if _, found := usedImages[*image.ImageId]; found {
+			continue
+		}
+
+		creationDate, err := time.Parse(amiCreationDateLayout, awsClient.StringValue(image.CreationDate))
+		if err != nil {
+			log.WithError(err).WithField("id", *image.ImageId).Error("could not parse image creation date")
+			continue
+		}
+
+		// An image that was never launched is unused since its creation
+		lastUsed := creationDate
+		lastLaunchedTime, err := am.getLastLaunchedTime(image.ImageId)
+		if err != nil {
+			log.WithError(err).WithField("id", *image.ImageId).Warn("could not describe image last launched time, using the image creation date")
+		} else if lastLaunchedTime.After(lastUsed) {
+			lastUsed = lastLaunchedTime
+		}
+
+		ageDays := now.Sub(creationDate).Hours() / 24
+		unusedDays := now.Sub(lastUsed).Hours() / 24
+		expression, err := metric.Constraint.Evaluate(unusedDays, nil)
+		if err != nil || !expression {
+			continue
+		}
+
+		var snapshotsCount int
+		var snapshotsSize int64
+		for _, blockDevice := range image.BlockDeviceMappings {
+			if blockDevice.Ebs == nil || blockDevice.Ebs.SnapshotId == nil {
+				continue
+			}
+			snapshotsCount++
+			snapshotsSize += awsClient.Int64Value(blockDevice.Ebs.VolumeSize)
+		}
+
+		tagsData := map[string]string{}
+		for _, tag := range image.Tags {
+			tagsData[*tag.Key] = *tag.Value
+		}
+
+		pricePerMonth := price * float64(snapshotsSize)
+		amiData := DetectedAMI{
+			Region:           am.awsManager.GetRegion(),
+			Metric:           metric.Description,
+			Name:             awsClient.StringValue(image.Name),
+			SnapshotsCount:   snapshotsCount,
+			SnapshotsSize:    snapshotsSize,
+			AgeDays:          int(ageDays),
+			LastLaunchedTime: lastLaunchedTime,
+			UnusedDays:       int(unusedDays),
+			PriceDetectedFields: collector.PriceDetectedFields{
+				ResourceID:    *image.ImageId,
+				LaunchTime:    creationDate,
+				PricePerHour:  pricePerMonth / collector.TotalMonthHours,
+				PricePerMonth: pricePerMonth,
+				Tag:           tagsData,
+			},
+		}
+
+		am.awsManager.GetCollector().AddResource(collector.EventCollector{
+			ResourceName: am.Name,
+			Data:         amiData,
+		})
+
+		detected = append(detected, amiData)
+	}
+
+	am.awsManager.GetCollector().CollectFinish(am.Name)
+
+	return detected, nil
+}
+
+// getLastLaunchedTime returns the last time an instance was launched from the image, or a zero time when it was not launched
+func (am *AMIManager) getLastLaunchedTime(imageID *string) (time.Time, error) {
+
+	resp, err := am.client.DescribeImageLastLaunchedTime(&ec2.DescribeImageAttributeInput{
+		ImageId: imageID,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if resp.LastLaunchedTime == nil || awsClient.StringValue(resp.LastLaunchedTime.Value) == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, *resp.LastLaunchedTime.Value)
+}
+
+// getUsedImages returns the image ids of the instances (not terminated), launch templates versions and launch configurations
+func (am *AMIManager) getUsedImages() (map[string]struct{}, error) {
+
+	usedImages := map[string]struct{}{}
+
+	err := am.describeInstancesImages(nil, usedImages)
+	if err != nil {
+		return nil, err
+	}
+
+	err = am.describeLaunchTemplatesImages(nil, usedImages)
+	if err != nil {
+		return nil, err
+	}
+
+	err = am.describeLaunchConfigurationsImages(nil, usedImages)
+	if err != nil {
+		return nil, err
+	}
+
+	return usedImages, nil
+}
+
+// describeInstancesImages adds the image ids of the running and stopped instances
+func (am *AMIManager) describeInstancesImages(token *string, usedImages map[string]struct{}) error {
+
+	resp, err := am.client.DescribeInstances(&ec2.DescribeInstancesInput{
+		NextToken: token,
+		Filters: []*ec2.Filter{
+			{
+				Name: awsClient.String("instance-state-name"),
+				Values: []*string{
+					awsClient.String("pending"),
+					awsClient.String("running"),
+					awsClient.String("stopping"),
+					awsClient.String("stopped"),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, reservation := range resp.Reservations {
+		for _, instance := range reservation.Instances {
+			usedImages[awsClient.StringValue(instance.ImageId)] = struct{}{}
+		}
+	}
+
+	if resp.NextToken != nil {
+		return am.describeInstancesImages(resp.NextToken, usedImages)
+	}
+
+	return nil
+}
+
+// describeLaunchTemplatesImages adds the image ids of all the launch templates versions
+func (am *AMIManager) describeLaunchTemplatesImages(token *string, usedImages map[string]struct{}) error {
+
+	resp, err := am.client.DescribeLaunchTemplates(&ec2.DescribeLaunchTemplatesInput{
+		NextToken: token,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, launchTemplate := range resp.LaunchTemplates {
+		err := am.describeLaunchTemplateVersionsImages(launchTemplate.LaunchTemplateId, nil, usedImages)
+		if err != nil {
+			return err
+		}
+	}
+
+	if resp.NextToken != nil {
+		return am.describeLaunchTemplatesImages(resp.NextToken, usedImages)
+	}
+
+	return nil
+}
+
+// describeLaunchTemplateVersionsImages adds the image ids of the launch template versions
+func (am *AMIManager) describeLaunchTemplateVersionsImages(launchTemplateID *string, token *string, usedImages map[string]struct{}) error {
+
+	resp, err := am.client.DescribeLaunchTemplateVersions(&ec2.DescribeLaunchTemplateVersionsInput{
+		LaunchTemplateId: launchTemplateID,
+		NextToken:        token,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, version := range resp.LaunchTemplateVersions {
+		if version.LaunchTemplateData != nil && version.LaunchTemplateData.ImageId != nil {
+			usedImages[*version.LaunchTemplateData.ImageId] = struct{}{}
+		}
+	}
+
+	if resp.NextToken != nil {
+		return am.describeLaunchTemplateVersionsImages(launchTemplateID, resp.NextToken, usedImages)
+	}
+
+	return nil
+}
+
+// describeLaunchConfigurationsImages adds the image ids of the autoscaling launch configurations
+func (am *AMIManager) describeLaunchConfigurationsImages(token *string, usedImages map[string]struct{}) error {
+
+	resp, err := am.client.DescribeLaunchConfigurations(&autoscaling.DescribeLaunchConfigurationsInput{
+		NextToken: token,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, launchConfiguration := range resp.LaunchConfigurations {
+		usedImages[awsClient.StringValue(launchConfiguration.ImageId)] = struct{}{}
+	}
+
+	if resp.NextToken != nil {
+		return am.describeLaunchConfigurationsImages(resp.NextToken, usedImages)
+	}
+
+	return nil
+}
@@ -0,0 +1,219 @@
+package resources
+
+import (
+	"errors"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	collectorTestutils "finala/collector/testutils"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func amiMock(imageID string, creationDate time.Time, snapshotsSizes ...int64) *ec2.Image {
+
+	blockDevices := []*ec2.BlockDeviceMapping{
+		{VirtualName: awsClient.String("ephemeral0")},
+	}
+	for i, size := range snapshotsSizes {
+		blockDevices = append(blockDevices, &ec2.BlockDeviceMapping{
+			Ebs: &ec2.EbsBlockDevice{
+				SnapshotId: awsClient.String(imageID + "-snap-" + string(rune('a'+i))),
+				VolumeSize: awsClient.Int64(size),
+			},
+		})
+	}
+
+	return &ec2.Image{
+		ImageId:             awsClient.String(imageID),
+		Name:                awsClient.String(imageID + "-name"),
+		CreationDate:        awsClient.String(creationDate.UTC().Format(amiCreationDateLayout)),
+		BlockDeviceMappings: blockDevices,
+	}
+}
+
+var defaultAMIMock = ec2.DescribeImagesOutput{
+	Images: []*ec2.Image{
+		amiMock("ami-unused", time.Now().AddDate(0, 0, -100), 8, 20),
+		amiMock("ami-instance", time.Now().AddDate(0, 0, -100), 8),
+		amiMock("ami-template", time.Now().AddDate(0, 0, -100), 8),
+		amiMock("ami-configuration", time.Now().AddDate(0, 0, -100), 8),
+		amiMock("ami-new", time.Now().AddDate(0, 0, -1), 8),
+		amiMock("ami-recently-launched", time.Now().AddDate(0, 0, -100), 8),
+	},
+}
+
+// defaultAMILastLaunchedMock defines the last launched time attribute of the images, the other images were never launched
+var defaultAMILastLaunchedMock = map[string]time.Time{
+	"ami-unused":            time.Now().AddDate(0, 0, -95),
+	"ami-recently-launched": time.Now().AddDate(0, 0, -10),
+}
+
+type MockAMIClient struct {
+	responseDescribeImages ec2.DescribeImagesOutput
+	err                    error
+	lastLaunchedErr        error
+}
+
+func (r *MockAMIClient) DescribeImageLastLaunchedTime(input *ec2.DescribeImageAttributeInput) (*ImageLastLaunchedTimeOutput, error) {
+	output := &ImageLastLaunchedTimeOutput{ImageId: input.ImageId, LastLaunchedTime: &ec2.AttributeValue{}}
+	if lastLaunched, found := defaultAMILastLaunchedMock[*input.ImageId]; found {
+		output.LastLaunchedTime.Value = awsClient.String(lastLaunched.UTC().Format(time.RFC3339))
+	}
+	return output, r.lastLaunchedErr
+}
+
+func (r *MockAMIClient) DescribeImages(input *ec2.DescribeImagesInput) (*ec2.DescribeImagesOutput, error) {
+	return &r.responseDescribeImages, r.err
+}
+
+func (r *MockAMIClient) DescribeInstances(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	return &ec2.DescribeInstancesOutput{
+		Reservations: []*ec2.Reservation{
+			{Instances: []*ec2.Instance{{ImageId: awsClient.String("ami-instance")}}},
+		},
+	}, r.err
+}
+
+func (r *MockAMIClient) DescribeLaunchTemplates(input *ec2.DescribeLaunchTemplatesInput) (*ec2.DescribeLaunchTemplatesOutput, error) {
+	return &ec2.DescribeLaunchTemplatesOutput{
+		LaunchTemplates: []*ec2.LaunchTemplate{{LaunchTemplateId: awsClient.String("lt-1")}},
+	}, r.err
+}
+
+func (r *MockAMIClient) DescribeLaunchTemplateVersions(input *ec2.DescribeLaunchTemplateVersionsInput) (*ec2.DescribeLaunchTemplateVersionsOutput, error) {
+
+	// The first page has the latest version, without an image
+	if input.NextToken == nil {
+		return &ec2.DescribeLaunchTemplateVersionsOutput{
+			LaunchTemplateVersions: []*ec2.LaunchTemplateVersion{{LaunchTemplateData: &ec2.ResponseLaunchTemplateData{}}},
+			NextToken:              awsClient.String("page-2"),
+		}, r.err
+	}
+
+	return &ec2.DescribeLaunchTemplateVersionsOutput{
+		LaunchTemplateVersions: []*ec2.LaunchTemplateVersion{
+			{LaunchTemplateData: &ec2.ResponseLaunchTemplateData{ImageId: awsClient.String("ami-template")}},
+		},
+	}, r.err
+}
+
+func (r *MockAMIClient) DescribeLaunchConfigurations(input *autoscaling.DescribeLaunchConfigurationsInput) (*autoscaling.DescribeLaunchConfigurationsOutput, error) {
+	return &autoscaling.DescribeLaunchConfigurationsOutput{
+		LaunchConfigurations: []*autoscaling.LaunchConfiguration{{ImageId: awsClient.String("ami-configuration")}},
+	}, r.err
+}
+
+func TestDetectAMI(t *testing.T) {
+
+	var defaultMetricConfig = []config.MetricConfig{
+		{
+			Description: "unused ami age",
+			Constraint: config.MetricConstraintConfig{
+				Operator: ">=",
+				Value:    90,
+			},
+		},
+	}
+
+	t.Run("detect", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, nil, mockPrice, "us-east-1")
+
+		ami, err := NewAMIManager(detector, &MockAMIClient{responseDescribeImages: defaultAMIMock})
+		if err != nil {
+			t.Fatalf("unexpected ami manager error happened, got %v expected %v", err, nil)
+		}
+
+		amiManager, ok := ami.(*AMIManager)
+		if !ok {
+			t.Fatalf("unexpected ami struct, got %s expected %s", reflect.TypeOf(ami), "*AMIManager")
+		}
+
+		response, _ := amiManager.Detect(defaultMetricConfig)
+
+		amiResponse, ok := response.([]DetectedAMI)
+		if !ok {
+			t.Fatalf("unexpected ami struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedAMI")
+		}
+
+		if len(amiResponse) != 1 {
+			t.Fatalf("unexpected ami detected, got %d expected %d", len(amiResponse), 1)
+		}
+
+		if len(collector.Events) != 1 {
+			t.Fatalf("unexpected collector ami resources, got %d expected %d", len(collector.Events), 1)
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource status events count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+
+		detectedAMI := amiResponse[0]
+		if detectedAMI.ResourceID != "ami-unused" {
+			t.Fatalf("unexpected ami id, got %s expected %s", detectedAMI.ResourceID, "ami-unused")
+		}
+
+		if detectedAMI.SnapshotsCount != 2 || detectedAMI.SnapshotsSize != 28 {
+			t.Fatalf("unexpected ami snapshots, got %d snapshots of %d GB expected %d snapshots of %d GB", detectedAMI.SnapshotsCount, detectedAMI.SnapshotsSize, 2, 28)
+		}
+
+		if detectedAMI.PricePerMonth != 28 {
+			t.Fatalf("unexpected ami price per month, got %v expected %v", detectedAMI.PricePerMonth, 28)
+		}
+
+		if detectedAMI.AgeDays != 100 || detectedAMI.UnusedDays != 95 || detectedAMI.LastLaunchedTime.IsZero() {
+			t.Fatalf("unexpected ami usage, got age %d unused %d days expected age %d unused %d days", detectedAMI.AgeDays, detectedAMI.UnusedDays, 100, 95)
+		}
+	})
+
+	t.Run("last launched time error", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, nil, mockPrice, "us-east-1")
+
+		ami, err := NewAMIManager(detector, &MockAMIClient{responseDescribeImages: defaultAMIMock, lastLaunchedErr: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected ami manager error happened, got %v expected %v", err, nil)
+		}
+
+		response, _ := ami.Detect(defaultMetricConfig)
+
+		// Without the last launched time, the images are unused since their creation
+		amiResponse, _ := response.([]DetectedAMI)
+		if len(amiResponse) != 2 {
+			t.Fatalf("unexpected ami detected, got %d expected %d", len(amiResponse), 2)
+		}
+
+		if amiResponse[0].UnusedDays != 100 {
+			t.Fatalf("unexpected ami unused days, got %d expected %d", amiResponse[0].UnusedDays, 100)
+		}
+	})
+
+	t.Run("describe error", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+		ami, err := NewAMIManager(detector, &MockAMIClient{err: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected ami manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = ami.Detect(defaultMetricConfig)
+		if err == nil {
+			t.Fatalf("unexpected describe images error, return empty")
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource status events count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+	})
+}
@@ -3,10 +3,10 @@ package resources
 import (
 	"errors"
 	"finala/collector"
+	"finala/collector/aws/cloudwatch"
 	"finala/collector/aws/common"
 	"finala/collector/aws/register"
 	"finala/collector/config"
-	"finala/expression"
 	"time"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
@@ -36,6 +36,7 @@ type DetectedAPIGateway struct {
 	Name       string
 	LaunchTime time.Time
 	Tag        map[string]string
+	collector.MetricDetectedFields
 }
 
 func init() {
@@ -81,10 +82,13 @@ func (ag *APIGatewayManager) Detect(metrics []config.MetricConfig) (interface{},
 	}
 
 	now := time.Now()
+	batch := ag.awsManager.GetCloudWatchClient().NewBatch()
 
 	for _, api := range apigateways {
+		api := api
 		log.WithField("name", *api.Name).Debug("checking apigateway")
 		for _, metric := range metrics {
+			metric := metric
 
 			log.WithFields(log.Fields{
 				"name":        *api.Name,
@@ -107,59 +111,62 @@ func (ag *APIGatewayManager) Detect(metrics []config.MetricConfig) (interface{},
 				},
 			}
 
-			formulaValue, _, err := ag.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
-
-			if err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"name":        *api.Name,
-					"metric_name": metric.Description,
-				}).Error("Could not get cloudwatch metric data")
-				continue
-			}
-			expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
-			if err != nil {
-				log.WithField("error", err).Error("could not parse expression")
-				continue
-			}
+			batch.Add(&metricInput, metric, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"name":        *api.Name,
+						"metric_name": metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					return
+				}
+				expression, err := metric.Constraint.Evaluate(formulaValue, metricsValues)
+				if err != nil {
+					log.WithField("error", err).Error("could not parse expression")
+					return
+				}
 
-			if expression {
-
-				log.WithFields(log.Fields{
-					"metric_name":         metric.Description,
-					"constraint_operator": metric.Constraint.Operator,
-					"constraint_Value":    metric.Constraint.Value,
-					"formula_value":       formulaValue,
-					"name":                *api.Name,
-					"region":              ag.awsManager.GetRegion(),
-				}).Info("APIGateway detected as unused resource")
-
-				tagsData := map[string]string{}
-				if err == nil {
-					for key, value := range api.Tags {
-						tagsData[key] = *value
+				if expression {
+
+					log.WithFields(log.Fields{
+						"metric_name":         metric.Description,
+						"constraint_operator": metric.Constraint.Operator,
+						"constraint_Value":    metric.Constraint.Value,
+						"formula_value":       formulaValue,
+						"name":                *api.Name,
+						"region":              ag.awsManager.GetRegion(),
+					}).Info("APIGateway detected as unused resource")
+
+					tagsData := map[string]string{}
+					if err == nil {
+						for key, value := range api.Tags {
+							tagsData[key] = *value
+						}
 					}
-				}
 
-				detect := DetectedAPIGateway{
-					Region:     ag.awsManager.GetRegion(),
-					Metric:     metric.Description,
-					ResourceID: *api.Id,
-					Name:       *api.Name,
-					LaunchTime: *api.CreatedDate,
-					Tag:        tagsData,
-				}
+					detect := DetectedAPIGateway{
+						Region:               ag.awsManager.GetRegion(),
+						Metric:               metric.Description,
+						ResourceID:           *api.Id,
+						Name:                 *api.Name,
+						LaunchTime:           *api.CreatedDate,
+						Tag:                  tagsData,
+						MetricDetectedFields: cloudwatch.NewMetricDetectedFields(metric, &metricInput, formulaValue, metricsValues),
+					}
 
-				ag.awsManager.GetCollector().AddResource(collector.EventCollector{
-					ResourceName: ag.Name,
-					Data:         detect,
-				})
+					ag.awsManager.GetCollector().AddResource(collector.EventCollector{
+						ResourceName: ag.Name,
+						Data:         detect,
+					})
 
-				detectAPIGateway = append(detectAPIGateway, detect)
+					detectAPIGateway = append(detectAPIGateway, detect)
 
-			}
+				}
+			})
 		}
 	}
 
+	batch.Execute()
+
 	ag.awsManager.GetCollector().CollectFinish(ag.Name)
 
 	return detectAPIGateway, nil
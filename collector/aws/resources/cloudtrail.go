@@ -0,0 +1,140 @@
+package resources
+
+import (
+	"encoding/json"
+	"finala/collector/config"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// cloudTrailEventsRetention defines the retention of the cloudtrail events history
+	cloudTrailEventsRetention = 90 * 24 * time.Hour
+
+	// cloudTrailLookupInterval defines the minimal interval between the cloudtrail lookup requests,
+	// the lookup events api is limited to 2 requests per second in each account region
+	cloudTrailLookupInterval = 500 * time.Millisecond
+
+	// cloudTrailLookupMaxResults defines the maximal events count of a lookup page
+	cloudTrailLookupMaxResults = 50
+)
+
+// cloudTrailLookupClient is an interface defining the aws cloudtrail client that looks up the events history
+type cloudTrailLookupClient interface {
+	LookupEvents(*cloudtrail.LookupEventsInput) (*cloudtrail.LookupEventsOutput, error)
+}
+
+// cloudTrailLookupStartTime returns the start time of the events lookup of a constraint on the days since the last
+// event. The lookup is limited to the constraint days and a day more, so a resource without events in the looked up
+// window exceeds the constraint without paging through the whole events history. A constraint without a days value
+// looks up the whole events history
+func cloudTrailLookupStartTime(constraint config.MetricConstraintConfig, now time.Time) time.Time {
+
+	window := cloudTrailEventsRetention
+	if constraint.Value > 0 {
+		constraintWindow := time.Duration((constraint.Value + 1) * float64(24*time.Hour))
+		if constraintWindow < window {
+			window = constraintWindow
+		}
+	}
+
+	return now.Add(-window)
+}
+
+// lookupLastEventTimes looks up the events of each event name in the given time range, and returns the time of the last
+// event of each resource by the event name. The resources ids of an event are returned by the resourceIDs function,
+// and the lookup requests are paced by the given interval
+func lookupLastEventTimes(client cloudTrailLookupClient, eventNames []string, startTime, endTime time.Time, interval time.Duration, resourceIDs func(*cloudtrail.Event) []string) (map[string]map[string]time.Time, error) {
+
+	lastEventTimes := map[string]map[string]time.Time{}
+	var lastLookup time.Time
+
+	for _, eventName := range eventNames {
+		eventTimes := map[string]time.Time{}
+		lastEventTimes[eventName] = eventTimes
+
+		input := &cloudtrail.LookupEventsInput{
+			StartTime:  &startTime,
+			EndTime:    &endTime,
+			MaxResults: awsClient.Int64(cloudTrailLookupMaxResults),
+			LookupAttributes: []*cloudtrail.LookupAttribute{
+				{
+					AttributeKey:   awsClient.String(cloudtrail.LookupAttributeKeyEventName),
+					AttributeValue: awsClient.String(eventName),
+				},
+			},
+		}
+
+		for {
+			if wait := interval - time.Since(lastLookup); wait > 0 {
+				time.Sleep(wait)
+			}
+			lastLookup = time.Now()
+
+			resp, err := client.LookupEvents(input)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, event := range resp.Events {
+				if event.EventTime == nil {
+					continue
+				}
+
+				for _, resourceID := range resourceIDs(event) {
+					if lastEventTime, found := eventTimes[resourceID]; !found || event.EventTime.After(lastEventTime) {
+						eventTimes[resourceID] = *event.EventTime
+					}
+				}
+			}
+
+			if resp.NextToken == nil {
+				break
+			}
+			input.NextToken = resp.NextToken
+		}
+	}
+
+	return lastEventTimes, nil
+}
+
+// cloudTrailEventFieldValues returns the values of the given field in the cloudtrail event request and response
+func cloudTrailEventFieldValues(event *cloudtrail.Event, field string) []string {
+
+	if event.CloudTrailEvent == nil {
+		return nil
+	}
+
+	var cloudTrailEvent map[string]interface{}
+	err := json.Unmarshal([]byte(*event.CloudTrailEvent), &cloudTrailEvent)
+	if err != nil {
+		log.WithError(err).WithField("event_id", awsClient.StringValue(event.EventId)).Debug("could not parse cloudtrail event")
+		return nil
+	}
+
+	return appendJSONFieldValues(nil, field, cloudTrailEvent["requestParameters"], cloudTrailEvent["responseElements"])
+}
+
+// appendJSONFieldValues appends the string values of the given field in the json values and their nested values
+func appendJSONFieldValues(fieldValues []string, field string, values ...interface{}) []string {
+
+	for _, value := range values {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			for key, nestedValue := range value {
+				if fieldValue, ok := nestedValue.(string); ok && key == field {
+					fieldValues = append(fieldValues, fieldValue)
+					continue
+				}
+				fieldValues = appendJSONFieldValues(fieldValues, field, nestedValue)
+			}
+		case []interface{}:
+			fieldValues = appendJSONFieldValues(fieldValues, field, value...)
+		}
+	}
+
+	return fieldValues
+}
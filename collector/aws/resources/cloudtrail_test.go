@@ -0,0 +1,31 @@
+package resources
+
+import (
+	"finala/collector/config"
+	"testing"
+	"time"
+)
+
+func TestCloudTrailLookupStartTime(t *testing.T) {
+
+	now := time.Now()
+
+	testCases := []struct {
+		name       string
+		constraint config.MetricConstraintConfig
+		expected   time.Time
+	}{
+		{"constraint days", config.MetricConstraintConfig{Operator: ">=", Value: 30}, now.Add(-31 * 24 * time.Hour)},
+		{"longer than the history", config.MetricConstraintConfig{Operator: ">=", Value: 120}, now.Add(-cloudTrailEventsRetention)},
+		{"without days", config.MetricConstraintConfig{Any: []config.MetricConstraintConfig{{Operator: ">=", Value: 30}}}, now.Add(-cloudTrailEventsRetention)},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			startTime := cloudTrailLookupStartTime(test.constraint, now)
+			if !startTime.Equal(test.expected) {
+				t.Fatalf("unexpected cloudtrail lookup start time, got %v expected %v", startTime, test.expected)
+			}
+		})
+	}
+}
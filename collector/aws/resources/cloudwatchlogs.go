@@ -0,0 +1,287 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/cloudwatch"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"fmt"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	log "github.com/sirupsen/logrus"
+)
+
+// CloudWatchLogsClientDescreptor is an interface defining the aws cloudwatch logs client
+type CloudWatchLogsClientDescreptor interface {
+	DescribeLogGroups(*cloudwatchlogs.DescribeLogGroupsInput) (*cloudwatchlogs.DescribeLogGroupsOutput, error)
+	ListTagsLogGroup(*cloudwatchlogs.ListTagsLogGroupInput) (*cloudwatchlogs.ListTagsLogGroupOutput, error)
+}
+
+// CloudWatchLogsManager describes the CloudWatch log groups struct
+type CloudWatchLogsManager struct {
+	client             CloudWatchLogsClientDescreptor
+	awsManager         common.AWSManager
+	namespace          string
+	servicePricingCode string
+	Name               collector.ResourceIdentifier
+}
+
+// DetectedCloudWatchLogGroup defines the detected AWS CloudWatch log groups
+type DetectedCloudWatchLogGroup struct {
+	Region          string
+	Metric          string
+	Name            string
+	RetentionInDays int64
+	StoredBytes     int64
+	collector.PriceDetectedFields
+	collector.MetricDetectedFields
+}
+
+func init() {
+	register.Registry("cloudwatch_logs", NewCloudWatchLogsManager)
+}
+
+// NewCloudWatchLogsManager implements AWS GO SDK
+func NewCloudWatchLogsManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = cloudwatchlogs.New(awsManager.GetSession())
+	}
+
+	logsClient, ok := client.(CloudWatchLogsClientDescreptor)
+	if !ok {
+		return nil, errors.New("invalid cloudwatch logs client")
+	}
+
+	return &CloudWatchLogsManager{
+		client:             logsClient,
+		awsManager:         awsManager,
+		namespace:          "AWS/Logs",
+		servicePricingCode: "AmazonCloudWatch",
+		Name:               awsManager.GetResourceIdentifier("cloudwatch_logs"),
+	}, nil
+}
+
+// Detect check which CloudWatch log groups are accumulating unused data. A metric with cloudwatch metrics (for example
+// IncomingBytes) is checked against the log group ingestion, a metric without cloudwatch metrics is checked against the
+// log group stored bytes. Only the log groups without retention are checked, and a log group is reported once, by the
+// first matched metric. The log group cost is the price of its stored data
+func (clm *CloudWatchLogsManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	log.WithFields(log.Fields{
+		"region":   clm.awsManager.GetRegion(),
+		"resource": "cloudwatch_logs",
+	}).Info("starting to analyze resource")
+
+	clm.awsManager.GetCollector().CollectStart(clm.Name)
+
+	detected := []DetectedCloudWatchLogGroup{}
+
+	pricingRegionPrefix, err := clm.awsManager.GetPricingClient().GetRegionPrefix(clm.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region": clm.awsManager.GetRegion(),
+		}).Error("Could not get pricing region prefix")
+		clm.awsManager.GetCollector().CollectError(clm.Name, err)
+		return detected, err
+	}
+
+	pricingFilters := clm.getPricingFilterInput(pricingRegionPrefix)
+	// The price of a stored GB per month
+	price, err := clm.awsManager.GetPricingClient().GetPrice(pricingFilters, "", clm.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region":        clm.awsManager.GetRegion(),
+			"price_filters": pricingFilters,
+		}).Error("could not get cloudwatch logs storage price")
+		clm.awsManager.GetCollector().CollectError(clm.Name, err)
+		return detected, err
+	}
+
+	logGroups, err := clm.describeLogGroups(nil, nil)
+	if err != nil {
+		log.WithError(err).Error("could not describe cloudwatch log groups")
+		clm.awsManager.GetCollector().CollectError(clm.Name, err)
+		return detected, err
+	}
+
+	// The reported log groups names, a log group that matches several metrics is reported once. The batch handlers are
+	// executed sequentially, so the map is shared between them
+	reported := map[string]struct{}{}
+
+	now := time.Now()
+	batch := clm.awsManager.GetCloudWatchClient().NewBatch()
+
+	for _, logGroup := range logGroups {
+		logGroup := logGroup
+		log.WithField("log_group_name", *logGroup.LogGroupName).Debug("checking cloudwatch log group")
+
+		// The data of a log group with retention expires, so it is not accumulating
+		if logGroup.RetentionInDays != nil {
+			continue
+		}
+
+		storedBytes := awsClient.Int64Value(logGroup.StoredBytes)
+		pricePerMonth := float64(storedBytes) / bytesInGB * price
+
+		for _, metric := range metrics {
+			metric := metric
+
+			if len(metric.Data) == 0 {
+				if _, found := reported[*logGroup.LogGroupName]; found {
+					continue
+				}
+
+				expression, err := metric.Constraint.Evaluate(float64(storedBytes), nil)
+				if err != nil || !expression {
+					continue
+				}
+
+				clm.addDetectedLogGroup(logGroup, metric, pricePerMonth, collector.MetricDetectedFields{}, &detected)
+				reported[*logGroup.LogGroupName] = struct{}{}
+				continue
+			}
+
+			period := int64(metric.Period.Seconds())
+			metricEndTime := now.Add(time.Duration(-metric.StartTime))
+			metricInput := awsCloudwatch.GetMetricStatisticsInput{
+				Namespace:  &clm.namespace,
+				MetricName: &metric.Description,
+				Period:     &period,
+				StartTime:  &metricEndTime,
+				EndTime:    &now,
+				Dimensions: []*awsCloudwatch.Dimension{
+					{
+						Name:  awsClient.String("LogGroupName"),
+						Value: logGroup.LogGroupName,
+					},
+				},
+			}
+
+			batch.Add(&metricInput, metric, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"log_group_name": *logGroup.LogGroupName,
+						"metric_name":    metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					return
+				}
+
+				if _, found := reported[*logGroup.LogGroupName]; found {
+					return
+				}
+
+				expression, err := metric.Constraint.Evaluate(formulaValue, metricsValues)
+				if err != nil || !expression {
+					return
+				}
+
+				clm.addDetectedLogGroup(logGroup, metric, pricePerMonth, cloudwatch.NewMetricDetectedFields(metric, &metricInput, formulaValue, metricsValues), &detected)
+				reported[*logGroup.LogGroupName] = struct{}{}
+			})
+		}
+	}
+
+	batch.Execute()
+
+	clm.awsManager.GetCollector().CollectFinish(clm.Name)
+
+	return detected, nil
+}
+
+// addDetectedLogGroup adds the log group to the collector and to the detected log groups
+func (clm *CloudWatchLogsManager) addDetectedLogGroup(logGroup *cloudwatchlogs.LogGroup, metric config.MetricConfig, pricePerMonth float64, metricFields collector.MetricDetectedFields, detected *[]DetectedCloudWatchLogGroup) {
+
+	log.WithFields(log.Fields{
+		"metric_name":         metric.Description,
+		"constraint_operator": metric.Constraint.Operator,
+		"constraint_Value":    metric.Constraint.Value,
+		"log_group_name":      *logGroup.LogGroupName,
+		"region":              clm.awsManager.GetRegion(),
+	}).Info("CloudWatch log group detected as unutilized resource")
+
+	tagsData := map[string]string{}
+	tags, err := clm.client.ListTagsLogGroup(&cloudwatchlogs.ListTagsLogGroupInput{
+		LogGroupName: logGroup.LogGroupName,
+	})
+	if err != nil {
+		log.WithError(err).WithField("log_group_name", *logGroup.LogGroupName).Error("could not list cloudwatch log group tags")
+	} else {
+		for key, value := range tags.Tags {
+			tagsData[key] = *value
+		}
+	}
+
+	logGroupData := DetectedCloudWatchLogGroup{
+		Region:          clm.awsManager.GetRegion(),
+		Metric:          metric.Description,
+		Name:            *logGroup.LogGroupName,
+		RetentionInDays: awsClient.Int64Value(logGroup.RetentionInDays),
+		StoredBytes:     awsClient.Int64Value(logGroup.StoredBytes),
+		PriceDetectedFields: collector.PriceDetectedFields{
+			ResourceID:    awsClient.StringValue(logGroup.Arn),
+			LaunchTime:    awsClient.MillisecondsTimeValue(logGroup.CreationTime),
+			PricePerHour:  pricePerMonth / collector.TotalMonthHours,
+			PricePerMonth: pricePerMonth,
+			Tag:           tagsData,
+		},
+		MetricDetectedFields: metricFields,
+	}
+
+	clm.awsManager.GetCollector().AddResource(collector.EventCollector{
+		ResourceName: clm.Name,
+		Data:         logGroupData,
+	})
+
+	*detected = append(*detected, logGroupData)
+}
+
+// getPricingFilterInput prepares the monthly price filter of a stored GB of logs
+func (clm *CloudWatchLogsManager) getPricingFilterInput(pricingRegionPrefix string) pricing.GetProductsInput {
+	return pricing.GetProductsInput{
+		ServiceCode: &clm.servicePricingCode,
+		Filters: []*pricing.Filter{
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("termType"),
+				Value: awsClient.String("OnDemand"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("usagetype"),
+				Value: awsClient.String(fmt.Sprintf("%sTimedStorage-ByteHrs", pricingRegionPrefix)),
+			},
+		},
+	}
+}
+
+// describeLogGroups returns the CloudWatch log groups
+func (clm *CloudWatchLogsManager) describeLogGroups(nextToken *string, logGroups []*cloudwatchlogs.LogGroup) ([]*cloudwatchlogs.LogGroup, error) {
+
+	input := &cloudwatchlogs.DescribeLogGroupsInput{
+		NextToken: nextToken,
+	}
+
+	resp, err := clm.client.DescribeLogGroups(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if logGroups == nil {
+		logGroups = []*cloudwatchlogs.LogGroup{}
+	}
+
+	logGroups = append(logGroups, resp.LogGroups...)
+
+	if resp.NextToken != nil {
+		return clm.describeLogGroups(resp.NextToken, logGroups)
+	}
+
+	return logGroups, nil
+}
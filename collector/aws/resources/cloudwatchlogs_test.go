@@ -0,0 +1,151 @@
+package resources
+
+import (
+	"errors"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	collectorTestutils "finala/collector/testutils"
+	"reflect"
+	"testing"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+)
+
+var defaultLogGroupsMock = cloudwatchlogs.DescribeLogGroupsOutput{
+	LogGroups: []*cloudwatchlogs.LogGroup{
+		{
+			// Without retention
+			LogGroupName: awsClient.String("log-group-1"),
+			Arn:          awsClient.String("arn:aws:logs:us-east-1:1234:log-group:log-group-1:*"),
+			CreationTime: awsClient.Int64(1577836800000),
+			StoredBytes:  awsClient.Int64(200 * bytesInGB),
+		},
+		{
+			// With retention
+			LogGroupName:    awsClient.String("log-group-2"),
+			Arn:             awsClient.String("arn:aws:logs:us-east-1:1234:log-group:log-group-2:*"),
+			CreationTime:    awsClient.Int64(1577836800000),
+			RetentionInDays: awsClient.Int64(30),
+			StoredBytes:     awsClient.Int64(200 * bytesInGB),
+		},
+		{
+			// Without retention, storing less than the stored bytes constraint
+			LogGroupName: awsClient.String("log-group-3"),
+			Arn:          awsClient.String("arn:aws:logs:us-east-1:1234:log-group:log-group-3:*"),
+			CreationTime: awsClient.Int64(1577836800000),
+			StoredBytes:  awsClient.Int64(50 * bytesInGB),
+		},
+	},
+}
+
+type MockAWSCloudWatchLogsClient struct {
+	err error
+}
+
+func (r *MockAWSCloudWatchLogsClient) DescribeLogGroups(*cloudwatchlogs.DescribeLogGroupsInput) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+	return &defaultLogGroupsMock, r.err
+}
+
+func (r *MockAWSCloudWatchLogsClient) ListTagsLogGroup(*cloudwatchlogs.ListTagsLogGroupInput) (*cloudwatchlogs.ListTagsLogGroupOutput, error) {
+	return &cloudwatchlogs.ListTagsLogGroupOutput{
+		Tags: map[string]*string{"team": awsClient.String("a")},
+	}, r.err
+}
+
+func TestDetectCloudWatchLogs(t *testing.T) {
+
+	metrics := append([]config.MetricConfig{
+		{
+			Description: "Stored bytes without retention",
+			Constraint: config.MetricConstraintConfig{
+				Operator: ">=",
+				Value:    100 * bytesInGB,
+			},
+		},
+	}, awsTestutils.DefaultMetricConfig...)
+
+	t.Run("detect", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		logsInterface, err := NewCloudWatchLogsManager(detector, &MockAWSCloudWatchLogsClient{})
+		if err != nil {
+			t.Fatalf("unexpected cloudwatch logs manager error happened, got %v expected %v", err, nil)
+		}
+
+		logsManager, ok := logsInterface.(*CloudWatchLogsManager)
+		if !ok {
+			t.Fatalf("unexpected cloudwatch logs struct, got %s expected %s", reflect.TypeOf(logsInterface), "*CloudWatchLogsManager")
+		}
+
+		response, err := logsManager.Detect(metrics)
+		if err != nil {
+			t.Fatalf("unexpected cloudwatch logs error happened, got %v expected %v", err, nil)
+		}
+
+		logGroupsResponse, ok := response.([]DetectedCloudWatchLogGroup)
+		if !ok {
+			t.Fatalf("unexpected cloudwatch logs struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedCloudWatchLogGroup")
+		}
+
+		if len(logGroupsResponse) != 2 {
+			t.Fatalf("unexpected cloudwatch log groups detected, got %d expected %d", len(logGroupsResponse), 2)
+		}
+
+		if len(collector.Events) != 2 {
+			t.Fatalf("unexpected collector cloudwatch logs events, got %d expected %d", len(collector.Events), 2)
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource event collection status count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+
+		// log-group-1 matches both metrics and is reported once, log-group-2 has retention and is not checked
+		expected := []struct {
+			name          string
+			metric        string
+			pricePerMonth float64
+		}{
+			{"log-group-1", "Stored bytes without retention", 200},
+			{"log-group-3", "", 50},
+		}
+		for i, logGroup := range logGroupsResponse {
+			if logGroup.Name != expected[i].name || logGroup.Metric != expected[i].metric {
+				t.Fatalf("unexpected cloudwatch log group, got %s detected by %q expected %s detected by %q", logGroup.Name, logGroup.Metric, expected[i].name, expected[i].metric)
+			}
+
+			// Priced 1 per stored GB
+			if logGroup.PricePerMonth != expected[i].pricePerMonth {
+				t.Fatalf("unexpected cloudwatch log group price, got %v expected %v", logGroup.PricePerMonth, expected[i].pricePerMonth)
+			}
+
+			if logGroup.Tag["team"] != "a" {
+				t.Fatalf("unexpected cloudwatch log group tags, got %v", logGroup.Tag)
+			}
+		}
+	})
+
+	t.Run("detection error", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		logsManager, err := NewCloudWatchLogsManager(detector, &MockAWSCloudWatchLogsClient{err: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected cloudwatch logs manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = logsManager.Detect(metrics)
+		if err == nil {
+			t.Fatalf("unexpected describe cloudwatch log groups error, return empty")
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource event collection status count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+	})
+}
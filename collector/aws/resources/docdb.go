@@ -3,10 +3,10 @@ package resources
 import (
 	"errors"
 	"finala/collector"
+	"finala/collector/aws/cloudwatch"
 	"finala/collector/aws/common"
 	"finala/collector/aws/register"
 	"finala/collector/config"
-	"finala/expression"
 	"time"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
@@ -39,6 +39,7 @@ type DetectedDocumentDB struct {
 	MultiAZ      bool
 	Engine       string
 	collector.PriceDetectedFields
+	collector.MetricDetectedFields
 }
 
 func init() {
@@ -85,13 +86,16 @@ func (dd *DocumentDBManager) Detect(metrics []config.MetricConfig) (interface{},
 	}
 
 	now := time.Now()
+	batch := dd.awsManager.GetCloudWatchClient().NewBatch()
 	for _, instance := range instances {
+		instance := instance
 
 		log.WithField("name", *instance.DBInstanceIdentifier).Debug("checking documentDB")
 
 		price, _ := dd.awsManager.GetPricingClient().GetPrice(dd.getPricingFilterInput(instance), "", dd.awsManager.GetRegion())
 
 		for _, metric := range metrics {
+			metric := metric
 			log.WithFields(log.Fields{
 				"name":        *instance.DBInstanceIdentifier,
 				"metric_name": metric.Description,
@@ -112,70 +116,73 @@ func (dd *DocumentDBManager) Detect(metrics []config.MetricConfig) (interface{},
 				},
 			}
 
-			formulaValue, _, err := dd.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
-
-			if err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"name":        *instance.DBInstanceIdentifier,
-					"metric_name": metric.Description,
-				}).Error("Could not get cloudwatch metric data")
-				continue
-			}
+			batch.Add(&metricInput, metric, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"name":        *instance.DBInstanceIdentifier,
+						"metric_name": metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					return
+				}
 
-			expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
-			if err != nil {
-				continue
-			}
+				expression, err := metric.Constraint.Evaluate(formulaValue, metricsValues)
+				if err != nil {
+					return
+				}
 
-			if expression {
-
-				log.WithFields(log.Fields{
-					"metric_name":         metric.Description,
-					"constraint_operator": metric.Constraint.Operator,
-					"constraint_Value":    metric.Constraint.Value,
-					"formula_value":       formulaValue,
-					"name":                *instance.DBInstanceIdentifier,
-					"instance_type":       *instance.DBInstanceClass,
-					"region":              dd.awsManager.GetRegion(),
-				}).Info("DocumentDB instance detected as unutilized resource")
-
-				tags, err := dd.client.ListTagsForResource(&docdb.ListTagsForResourceInput{
-					ResourceName: instance.DBInstanceArn,
-				})
-
-				tagsData := map[string]string{}
-				if err == nil {
-					for _, tag := range tags.TagList {
-						tagsData[*tag.Key] = *tag.Value
+				if expression {
+
+					log.WithFields(log.Fields{
+						"metric_name":         metric.Description,
+						"constraint_operator": metric.Constraint.Operator,
+						"constraint_Value":    metric.Constraint.Value,
+						"formula_value":       formulaValue,
+						"name":                *instance.DBInstanceIdentifier,
+						"instance_type":       *instance.DBInstanceClass,
+						"region":              dd.awsManager.GetRegion(),
+					}).Info("DocumentDB instance detected as unutilized resource")
+
+					tags, err := dd.client.ListTagsForResource(&docdb.ListTagsForResourceInput{
+						ResourceName: instance.DBInstanceArn,
+					})
+
+					tagsData := map[string]string{}
+					if err == nil {
+						for _, tag := range tags.TagList {
+							tagsData[*tag.Key] = *tag.Value
+						}
 					}
-				}
 
-				docDB := DetectedDocumentDB{
-					Region:       dd.awsManager.GetRegion(),
-					Metric:       metric.Description,
-					InstanceType: *instance.DBInstanceClass,
-					Engine:       *instance.Engine,
-					PriceDetectedFields: collector.PriceDetectedFields{
-						ResourceID:    *instance.DBInstanceArn,
-						LaunchTime:    *instance.InstanceCreateTime,
-						PricePerHour:  price,
-						PricePerMonth: price * collector.TotalMonthHours,
-						Tag:           tagsData,
-					},
-				}
+					docDB := DetectedDocumentDB{
+						Region:       dd.awsManager.GetRegion(),
+						Metric:       metric.Description,
+						InstanceType: *instance.DBInstanceClass,
+						Engine:       *instance.Engine,
+						PriceDetectedFields: collector.PriceDetectedFields{
+							ResourceID:    *instance.DBInstanceArn,
+							LaunchTime:    *instance.InstanceCreateTime,
+							PricePerHour:  price,
+							PricePerMonth: price * collector.TotalMonthHours,
+							Tag:           tagsData,
+						},
+						MetricDetectedFields: cloudwatch.NewMetricDetectedFields(metric, &metricInput, formulaValue, metricsValues),
+					}
 
-				dd.awsManager.GetCollector().AddResource(collector.EventCollector{
-					ResourceName: collector.ResourceIdentifier(dd.Name),
-					Data:         docDB,
-				})
+					dd.awsManager.GetCollector().AddResource(collector.EventCollector{
+						ResourceName: collector.ResourceIdentifier(dd.Name),
+						Data:         docDB,
+					})
 
-				detectedDocDB = append(detectedDocDB, docDB)
+					detectedDocDB = append(detectedDocDB, docDB)
 
-			}
+				}
+			})
 		}
 
 	}
 
+	batch.Execute()
+
 	dd.awsManager.GetCollector().CollectFinish(dd.Name)
 
 	return detectedDocDB, nil
@@ -3,10 +3,10 @@ package resources
 import (
 	"errors"
 	"finala/collector"
+	"finala/collector/aws/cloudwatch"
 	"finala/collector/aws/common"
 	"finala/collector/aws/register"
 	"finala/collector/config"
-	"finala/expression"
 	"strings"
 	"time"
 
@@ -42,6 +42,7 @@ type DetectedAWSDynamoDB struct {
 	Metric string
 	Name   string
 	collector.PriceDetectedFields
+	collector.MetricDetectedFields
 }
 
 func init() {
@@ -104,11 +105,14 @@ func (dd *DynamoDBManager) Detect(metrics []config.MetricConfig) (interface{}, e
 	}
 
 	now := time.Now()
+	batch := dd.awsManager.GetCloudWatchClient().NewBatch()
 	for _, table := range tables {
+		table := table
 
 		log.WithField("table_name", *table.TableName).Debug("checking dynamodb table")
 
 		for _, metric := range metrics {
+			metric := metric
 			log.WithFields(log.Fields{
 				"table_name":  *table.TableName,
 				"metric_name": metric.Description,
@@ -131,87 +135,91 @@ func (dd *DynamoDBManager) Detect(metrics []config.MetricConfig) (interface{}, e
 				},
 			}
 
-			formulaValue, metricsResponseValues, err := dd.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
-			if err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"table_name":  *table.TableName,
-					"metric_name": metric.Description,
-				}).Error("Could not get cloudwatch metric data")
-				continue
-			}
-
-			expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"table_name":                 *table.TableName,
-					"formula_value":              formulaValue,
-					"metric_constraint_value":    metric.Constraint.Value,
-					"metric_constraint_operator": metric.Constraint.Operator,
-				}).Error("bool expression error")
-				continue
-			}
+			batch.Add(&metricInput, metric, func(formulaValue float64, metricsResponseValues map[string]interface{}, err error) {
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"table_name":  *table.TableName,
+						"metric_name": metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					return
+				}
 
-			if expression {
-
-				log.WithFields(log.Fields{
-					"metric_name":         metric.Description,
-					"constraint_operator": metric.Constraint.Operator,
-					"constraint_Value":    metric.Constraint.Value,
-					"formula_value":       formulaValue,
-					"name":                *table.TableName,
-					"region":              dd.awsManager.GetRegion(),
-				}).Info("DynamoDB table detected as unutilized resource")
-
-				var pricePerHour float64
-				var pricePerMonth float64
-				if strings.Contains(metric.Description, "write capacity") {
-					provisionedWriteCapacityUnits := metricsResponseValues["ProvisionedWriteCapacityUnits"].(float64)
-					pricePerHour = writePricePerHour
-					pricePerMonth = provisionedWriteCapacityUnits * pricePerHour * collector.TotalMonthHours
-				} else if strings.Contains(metric.Description, "read capacity") {
-					provisionedReadCapacityUnits := metricsResponseValues["ProvisionedReadCapacityUnits"].(float64)
-					pricePerHour = readPricePerHour
-					pricePerMonth = provisionedReadCapacityUnits * pricePerHour * collector.TotalMonthHours
-				} else {
-					log.Warn("metric name not supported")
-					continue
+				expression, err := metric.Constraint.Evaluate(formulaValue, metricsResponseValues)
+				if err != nil {
+					log.WithFields(log.Fields{
+						"table_name":                 *table.TableName,
+						"formula_value":              formulaValue,
+						"metric_constraint_value":    metric.Constraint.Value,
+						"metric_constraint_operator": metric.Constraint.Operator,
+					}).Error("bool expression error")
+					return
 				}
 
-				tags, err := dd.client.ListTagsOfResource(&dynamodb.ListTagsOfResourceInput{
-					ResourceArn: table.TableArn,
-				})
+				if expression {
+
+					log.WithFields(log.Fields{
+						"metric_name":         metric.Description,
+						"constraint_operator": metric.Constraint.Operator,
+						"constraint_Value":    metric.Constraint.Value,
+						"formula_value":       formulaValue,
+						"name":                *table.TableName,
+						"region":              dd.awsManager.GetRegion(),
+					}).Info("DynamoDB table detected as unutilized resource")
+
+					var pricePerHour float64
+					var pricePerMonth float64
+					if strings.Contains(metric.Description, "write capacity") {
+						provisionedWriteCapacityUnits := metricsResponseValues["ProvisionedWriteCapacityUnits"].(float64)
+						pricePerHour = writePricePerHour
+						pricePerMonth = provisionedWriteCapacityUnits * pricePerHour * collector.TotalMonthHours
+					} else if strings.Contains(metric.Description, "read capacity") {
+						provisionedReadCapacityUnits := metricsResponseValues["ProvisionedReadCapacityUnits"].(float64)
+						pricePerHour = readPricePerHour
+						pricePerMonth = provisionedReadCapacityUnits * pricePerHour * collector.TotalMonthHours
+					} else {
+						log.Warn("metric name not supported")
+						return
+					}
+
+					tags, err := dd.client.ListTagsOfResource(&dynamodb.ListTagsOfResourceInput{
+						ResourceArn: table.TableArn,
+					})
 
-				tagsData := map[string]string{}
-				if err == nil {
-					for _, tag := range tags.Tags {
-						tagsData[*tag.Key] = *tag.Value
+					tagsData := map[string]string{}
+					if err == nil {
+						for _, tag := range tags.Tags {
+							tagsData[*tag.Key] = *tag.Value
+						}
 					}
-				}
 
-				detectedDynamoDBTable := DetectedAWSDynamoDB{
-					Region: dd.awsManager.GetRegion(),
-					Metric: metric.Description,
-					Name:   *table.TableName,
-					PriceDetectedFields: collector.PriceDetectedFields{
-						ResourceID:    *table.TableArn,
-						LaunchTime:    *table.CreationDateTime,
-						PricePerHour:  pricePerHour,
-						PricePerMonth: pricePerMonth,
-						Tag:           tagsData,
-					},
-				}
+					detectedDynamoDBTable := DetectedAWSDynamoDB{
+						Region: dd.awsManager.GetRegion(),
+						Metric: metric.Description,
+						Name:   *table.TableName,
+						PriceDetectedFields: collector.PriceDetectedFields{
+							ResourceID:    *table.TableArn,
+							LaunchTime:    *table.CreationDateTime,
+							PricePerHour:  pricePerHour,
+							PricePerMonth: pricePerMonth,
+							Tag:           tagsData,
+						},
+						MetricDetectedFields: cloudwatch.NewMetricDetectedFields(metric, &metricInput, formulaValue, metricsResponseValues),
+					}
 
-				dd.awsManager.GetCollector().AddResource(collector.EventCollector{
-					ResourceName: dd.Name,
-					Data:         detectedDynamoDBTable,
-				})
+					dd.awsManager.GetCollector().AddResource(collector.EventCollector{
+						ResourceName: dd.Name,
+						Data:         detectedDynamoDBTable,
+					})
 
-				detectedTables = append(detectedTables, detectedDynamoDBTable)
+					detectedTables = append(detectedTables, detectedDynamoDBTable)
 
-			}
+				}
+			})
 		}
 	}
 
+	batch.Execute()
+
 	dd.awsManager.GetCollector().CollectFinish(dd.Name)
 
 	return detectedTables, nil
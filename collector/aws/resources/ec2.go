@@ -3,10 +3,10 @@ package resources
 import (
 	"errors"
 	"finala/collector"
+	"finala/collector/aws/cloudwatch"
 	"finala/collector/aws/common"
 	"finala/collector/aws/register"
 	"finala/collector/config"
-	"finala/expression"
 	"strings"
 	"time"
 
@@ -38,6 +38,7 @@ type DetectedEC2 struct {
 	Name         string
 	InstanceType string
 	collector.PriceDetectedFields
+	collector.MetricDetectedFields
 }
 
 func init() {
@@ -83,13 +84,16 @@ func (ec *EC2Manager) Detect(metrics []config.MetricConfig) (interface{}, error)
 		return detectedEC2, err
 	}
 	now := time.Now()
+	batch := ec.awsManager.GetCloudWatchClient().NewBatch()
 
 	for _, instance := range instances {
+		instance := instance
 		log.WithField("instance_id", *instance.InstanceId).Debug("checking ec2 instance")
 
 		price, _ := ec.awsManager.GetPricingClient().GetPrice(ec.getPricingFilterInput(instance), "", ec.awsManager.GetRegion())
 
 		for _, metric := range metrics {
+			metric := metric
 			log.WithFields(log.Fields{
 				"instance_id": *instance.InstanceId,
 				"metric_name": metric.Description,
@@ -112,72 +116,75 @@ func (ec *EC2Manager) Detect(metrics []config.MetricConfig) (interface{}, error)
 				},
 			}
 
-			formulaValue, _, err := ec.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
-			if err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"instance_id": *instance.InstanceId,
-					"metric_name": metric.Description,
-				}).Error("Could not get cloudwatch metric data")
-				continue
-			}
-
-			expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
-			if err != nil {
-				continue
-			}
-			if expression {
+			batch.Add(&metricInput, metric, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"instance_id": *instance.InstanceId,
+						"metric_name": metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					return
+				}
 
-				var name string
-				for _, tag := range instance.Tags {
-					if strings.ToLower(*tag.Key) == "name" {
-						name = *tag.Value
-						break
-					}
+				expression, err := metric.Constraint.Evaluate(formulaValue, metricsValues)
+				if err != nil {
+					return
 				}
+				if expression {
 
-				log.WithFields(log.Fields{
-					"metric_name":         metric.Description,
-					"constraint_operator": metric.Constraint.Operator,
-					"constraint_Value":    metric.Constraint.Value,
-					"formula_value":       formulaValue,
-					"instance_id":         *instance.InstanceId,
-					"instance_type":       *instance.InstanceType,
-					"region":              ec.awsManager.GetRegion(),
-				}).Info("EC2 instance detected as unutilized resource")
-
-				tagsData := map[string]string{}
-				if err == nil {
+					var name string
 					for _, tag := range instance.Tags {
-						tagsData[*tag.Key] = *tag.Value
+						if strings.ToLower(*tag.Key) == "name" {
+							name = *tag.Value
+							break
+						}
 					}
-				}
 
-				ec2 := DetectedEC2{
-					Region:       ec.awsManager.GetRegion(),
-					Metric:       metric.Description,
-					Name:         name,
-					InstanceType: *instance.InstanceType,
-					PriceDetectedFields: collector.PriceDetectedFields{
-						ResourceID:    *instance.InstanceId,
-						LaunchTime:    *instance.LaunchTime,
-						PricePerHour:  price,
-						PricePerMonth: price * collector.TotalMonthHours,
-						Tag:           tagsData,
-					},
-				}
+					log.WithFields(log.Fields{
+						"metric_name":         metric.Description,
+						"constraint_operator": metric.Constraint.Operator,
+						"constraint_Value":    metric.Constraint.Value,
+						"formula_value":       formulaValue,
+						"instance_id":         *instance.InstanceId,
+						"instance_type":       *instance.InstanceType,
+						"region":              ec.awsManager.GetRegion(),
+					}).Info("EC2 instance detected as unutilized resource")
+
+					tagsData := map[string]string{}
+					if err == nil {
+						for _, tag := range instance.Tags {
+							tagsData[*tag.Key] = *tag.Value
+						}
+					}
 
-				ec.awsManager.GetCollector().AddResource(collector.EventCollector{
-					ResourceName: ec.Name,
-					Data:         ec2,
-				})
+					ec2 := DetectedEC2{
+						Region:       ec.awsManager.GetRegion(),
+						Metric:       metric.Description,
+						Name:         name,
+						InstanceType: *instance.InstanceType,
+						PriceDetectedFields: collector.PriceDetectedFields{
+							ResourceID:    *instance.InstanceId,
+							LaunchTime:    *instance.LaunchTime,
+							PricePerHour:  price,
+							PricePerMonth: price * collector.TotalMonthHours,
+							Tag:           tagsData,
+						},
+						MetricDetectedFields: cloudwatch.NewMetricDetectedFields(metric, &metricInput, formulaValue, metricsValues),
+					}
 
-				detectedEC2 = append(detectedEC2, ec2)
+					ec.awsManager.GetCollector().AddResource(collector.EventCollector{
+						ResourceName: ec.Name,
+						Data:         ec2,
+					})
 
-			}
+					detectedEC2 = append(detectedEC2, ec2)
 
+				}
+			})
 		}
 	}
 
+	batch.Execute()
+
 	ec.awsManager.GetCollector().CollectFinish(ec.Name)
 
 	return detectedEC2, nil
@@ -186,15 +193,20 @@ func (ec *EC2Manager) Detect(metrics []config.MetricConfig) (interface{}, error)
 
 // getPricingFilterInput return the price filters for EC2 instances.
 func (ec *EC2Manager) getPricingFilterInput(instance *ec2.Instance) pricing.GetProductsInput {
+	return getEC2PricingFilterInput(ec.servicePricingCode, *instance.InstanceType, instance.Platform)
+}
+
+// getEC2PricingFilterInput return the on demand price filters of an EC2 instance type, a nil platform is Linux
+func getEC2PricingFilterInput(servicePricingCode string, instanceType string, instancePlatform *string) pricing.GetProductsInput {
 
 	platform := "Linux"
 
-	if instance.Platform != nil {
-		platform = *instance.Platform
+	if instancePlatform != nil {
+		platform = *instancePlatform
 	}
 
 	input := pricing.GetProductsInput{
-		ServiceCode: &ec.servicePricingCode,
+		ServiceCode: &servicePricingCode,
 		Filters: []*pricing.Filter{
 			{
 				Type:  awsClient.String("TERM_MATCH"),
@@ -224,7 +236,7 @@ func (ec *EC2Manager) getPricingFilterInput(instance *ec2.Instance) pricing.GetP
 			{
 				Type:  awsClient.String("TERM_MATCH"),
 				Field: awsClient.String("instanceType"),
-				Value: instance.InstanceType,
+				Value: &instanceType,
 			},
 			{
 				Type:  awsClient.String("TERM_MATCH"),
@@ -147,6 +147,19 @@ func TestDetectEC2(t *testing.T) {
 		t.Fatalf("unexpected collector ec2 resources, got %d expected %d", len(collector.Events), 1)
 	}
 
+	detected := ec2Response[0]
+	if detected.FormulaValue != 5 || detected.MetricValues["TestMetric"] != float64(5) {
+		t.Fatalf("unexpected detected metric values, got %v (%v) expected %v", detected.FormulaValue, detected.MetricValues, 5)
+	}
+
+	if detected.ConstraintOperator != "==" || detected.ConstraintValue != 5 {
+		t.Fatalf("unexpected detected constraint, got %s %v expected %s %v", detected.ConstraintOperator, detected.ConstraintValue, "==", 5)
+	}
+
+	if !detected.MetricStartTime.Before(detected.MetricEndTime) {
+		t.Fatalf("unexpected detected metric time window, got %v - %v", detected.MetricStartTime, detected.MetricEndTime)
+	}
+
 	if len(collector.EventsCollectionStatus) != 2 {
 		t.Fatalf("unexpected resource status events count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
 	}
@@ -0,0 +1,266 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	log "github.com/sirupsen/logrus"
+)
+
+// EC2SnapshotsClientDescriptor is an interface defining the AWS EC2 client that describes snapshots, volumes and images
+type EC2SnapshotsClientDescriptor interface {
+	DescribeSnapshots(input *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error)
+	DescribeVolumes(input *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error)
+	DescribeImages(input *ec2.DescribeImagesInput) (*ec2.DescribeImagesOutput, error)
+}
+
+// EC2SnapshotsManager describe EBS snapshots manager
+type EC2SnapshotsManager struct {
+	client             EC2SnapshotsClientDescriptor
+	awsManager         common.AWSManager
+	servicePricingCode string
+	Name               collector.ResourceIdentifier
+}
+
+// DetectedAWSEC2Snapshot define the detected snapshot data
+type DetectedAWSEC2Snapshot struct {
+	Metric   string
+	Region   string
+	VolumeID string
+	Size     int64
+	AgeDays  int
+	collector.PriceDetectedFields
+}
+
+func init() {
+	register.Registry("ec2_snapshots", NewSnapshotsManager)
+}
+
+// NewSnapshotsManager implements AWS GO SDK
+func NewSnapshotsManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = ec2.New(awsManager.GetSession())
+	}
+
+	ec2Client, ok := client.(EC2SnapshotsClientDescriptor)
+	if !ok {
+		return nil, errors.New("invalid ec2 snapshots client")
+	}
+
+	return &EC2SnapshotsManager{
+		client:             ec2Client,
+		awsManager:         awsManager,
+		servicePricingCode: "AmazonEC2",
+		Name:               awsManager.GetResourceIdentifier("ec2_snapshots"),
+	}, nil
+
+}
+
+// Detect orphaned snapshots, the snapshots that their source volume no longer exists and are not used by any AMI
+func (es *EC2SnapshotsManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	// This resource support only one metric, the constraint value is the snapshot age in days
+	metric := metrics[0]
+
+	log.WithFields(log.Fields{
+		"region":   es.awsManager.GetRegion(),
+		"resource": "ec2_snapshots",
+	}).Info("starting to analyze resource")
+
+	es.awsManager.GetCollector().CollectStart(es.Name)
+
+	detected := []DetectedAWSEC2Snapshot{}
+	snapshots, err := es.describeSnapshots(nil, nil)
+	if err != nil {
+		log.WithError(err).Error("could not describe ec2 snapshots")
+		es.awsManager.GetCollector().CollectError(es.Name, err)
+		return detected, err
+	}
+
+	volumes, err := es.describeVolumeIDs(nil, nil)
+	if err != nil {
+		log.WithError(err).Error("could not describe ec2 volumes")
+		es.awsManager.GetCollector().CollectError(es.Name, err)
+		return detected, err
+	}
+
+	imagesSnapshots, err := es.describeImagesSnapshotIDs()
+	if err != nil {
+		log.WithError(err).Error("could not describe ec2 images")
+		es.awsManager.GetCollector().CollectError(es.Name, err)
+		return detected, err
+	}
+
+	price, err := getSnapshotStoragePrice(es.awsManager, es.servicePricingCode)
+	if err != nil {
+		log.WithError(err).Error("could not get ec2 snapshot storage price")
+		price = 0
+	}
+
+	now := time.Now()
+	for _, snapshot := range snapshots {
+
+		log.WithField("id", *snapshot.SnapshotId).Debug("checking ec2 snapshot")
+
+		if _, found := volumes[awsClient.StringValue(snapshot.VolumeId)]; found {
+			continue
+		}
+
+		if _, found := imagesSnapshots[*snapshot.SnapshotId]; found {
+			continue
+		}
+
+		ageDays := now.Sub(*snapshot.StartTime).Hours() / 24
+		expression, err := metric.Constraint.Evaluate(ageDays, nil)
+		if err != nil || !expression {
+			continue
+		}
+
+		tagsData := map[string]string{}
+		for _, tag := range snapshot.Tags {
+			tagsData[*tag.Key] = *tag.Value
+		}
+
+		// The snapshot size is the size of the source volume, the actual stored (incremental) size is not exposed by the api
+		pricePerMonth := price * float64(*snapshot.VolumeSize)
+		snapshotData := DetectedAWSEC2Snapshot{
+			Metric:   metric.Description,
+			Region:   es.awsManager.GetRegion(),
+			VolumeID: awsClient.StringValue(snapshot.VolumeId),
+			Size:     *snapshot.VolumeSize,
+			AgeDays:  int(ageDays),
+			PriceDetectedFields: collector.PriceDetectedFields{
+				ResourceID:    *snapshot.SnapshotId,
+				LaunchTime:    *snapshot.StartTime,
+				PricePerHour:  pricePerMonth / collector.TotalMonthHours,
+				PricePerMonth: pricePerMonth,
+				Tag:           tagsData,
+			},
+		}
+
+		es.awsManager.GetCollector().AddResource(collector.EventCollector{
+			ResourceName: es.Name,
+			Data:         snapshotData,
+		})
+
+		detected = append(detected, snapshotData)
+	}
+
+	es.awsManager.GetCollector().CollectFinish(es.Name)
+
+	return detected, nil
+}
+
+// getSnapshotStoragePrice returns the snapshot storage price per GB-month of the aws manager region
+func getSnapshotStoragePrice(awsManager common.AWSManager, servicePricingCode string) (float64, error) {
+
+	regionPrefix, err := awsManager.GetPricingClient().GetRegionPrefix(awsManager.GetRegion())
+	if err != nil {
+		return 0, err
+	}
+
+	input := pricing.GetProductsInput{
+		ServiceCode: &servicePricingCode,
+		Filters: []*pricing.Filter{
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("productFamily"),
+				Value: awsClient.String("Storage Snapshot"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("usagetype"),
+				Value: awsClient.String(regionPrefix + "EBS:SnapshotUsage"),
+			},
+		},
+	}
+
+	return awsManager.GetPricingClient().GetPrice(input, "", awsManager.GetRegion())
+}
+
+// describeSnapshots return list of the account owned snapshots
+func (es *EC2SnapshotsManager) describeSnapshots(token *string, snapshots []*ec2.Snapshot) ([]*ec2.Snapshot, error) {
+
+	input := &ec2.DescribeSnapshotsInput{
+		NextToken: token,
+		OwnerIds:  []*string{awsClient.String("self")},
+		Filters: []*ec2.Filter{
+			{
+				Name:   awsClient.String("status"),
+				Values: []*string{awsClient.String("completed")},
+			},
+		},
+	}
+
+	resp, err := es.client.DescribeSnapshots(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if snapshots == nil {
+		snapshots = []*ec2.Snapshot{}
+	}
+
+	snapshots = append(snapshots, resp.Snapshots...)
+
+	if resp.NextToken != nil {
+		return es.describeSnapshots(resp.NextToken, snapshots)
+	}
+
+	return snapshots, nil
+}
+
+// describeVolumeIDs return the ids of all the region volumes
+func (es *EC2SnapshotsManager) describeVolumeIDs(token *string, volumes map[string]struct{}) (map[string]struct{}, error) {
+
+	resp, err := es.client.DescribeVolumes(&ec2.DescribeVolumesInput{
+		NextToken: token,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if volumes == nil {
+		volumes = map[string]struct{}{}
+	}
+
+	for _, volume := range resp.Volumes {
+		volumes[*volume.VolumeId] = struct{}{}
+	}
+
+	if resp.NextToken != nil {
+		return es.describeVolumeIDs(resp.NextToken, volumes)
+	}
+
+	return volumes, nil
+}
+
+// describeImagesSnapshotIDs return the ids of the snapshots that are referenced by the account owned AMIs
+func (es *EC2SnapshotsManager) describeImagesSnapshotIDs() (map[string]struct{}, error) {
+
+	resp, err := es.client.DescribeImages(&ec2.DescribeImagesInput{
+		Owners: []*string{awsClient.String("self")},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := map[string]struct{}{}
+	for _, image := range resp.Images {
+		for _, blockDevice := range image.BlockDeviceMappings {
+			if blockDevice.Ebs != nil && blockDevice.Ebs.SnapshotId != nil {
+				snapshots[*blockDevice.Ebs.SnapshotId] = struct{}{}
+			}
+		}
+	}
+
+	return snapshots, nil
+}
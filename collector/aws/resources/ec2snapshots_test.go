@@ -0,0 +1,181 @@
+package resources
+
+import (
+	"errors"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	collectorTestutils "finala/collector/testutils"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+var defaultSnapshotsMock = ec2.DescribeSnapshotsOutput{
+	Snapshots: []*ec2.Snapshot{
+		{
+			// Orphaned snapshot
+			SnapshotId: awsClient.String("snap-1"),
+			VolumeId:   awsClient.String("vol-deleted"),
+			VolumeSize: awsClient.Int64(100),
+			StartTime:  awsClient.Time(time.Now().AddDate(0, 0, -60)),
+			Tags:       []*ec2.Tag{{Key: awsClient.String("team"), Value: awsClient.String("a")}},
+		},
+		{
+			// The source volume exists
+			SnapshotId: awsClient.String("snap-2"),
+			VolumeId:   awsClient.String("vol-1"),
+			VolumeSize: awsClient.Int64(100),
+			StartTime:  awsClient.Time(time.Now().AddDate(0, 0, -60)),
+		},
+		{
+			// Referenced by an AMI
+			SnapshotId: awsClient.String("snap-3"),
+			VolumeId:   awsClient.String("vol-deleted"),
+			VolumeSize: awsClient.Int64(100),
+			StartTime:  awsClient.Time(time.Now().AddDate(0, 0, -60)),
+		},
+		{
+			// Too new
+			SnapshotId: awsClient.String("snap-4"),
+			VolumeId:   awsClient.String("vol-deleted"),
+			VolumeSize: awsClient.Int64(100),
+			StartTime:  awsClient.Time(time.Now().AddDate(0, 0, -1)),
+		},
+	},
+}
+
+var defaultSnapshotsVolumesMock = ec2.DescribeVolumesOutput{
+	Volumes: []*ec2.Volume{
+		{VolumeId: awsClient.String("vol-1")},
+	},
+}
+
+var defaultSnapshotsImagesMock = ec2.DescribeImagesOutput{
+	Images: []*ec2.Image{
+		{
+			ImageId: awsClient.String("ami-1"),
+			BlockDeviceMappings: []*ec2.BlockDeviceMapping{
+				{Ebs: &ec2.EbsBlockDevice{SnapshotId: awsClient.String("snap-3")}},
+				{VirtualName: awsClient.String("ephemeral0")},
+			},
+		},
+	},
+}
+
+type MockAWSSnapshotsClient struct {
+	responseDescribeSnapshots ec2.DescribeSnapshotsOutput
+	responseDescribeVolumes   ec2.DescribeVolumesOutput
+	responseDescribeImages    ec2.DescribeImagesOutput
+	err                       error
+}
+
+func (r *MockAWSSnapshotsClient) DescribeSnapshots(input *ec2.DescribeSnapshotsInput) (*ec2.DescribeSnapshotsOutput, error) {
+	return &r.responseDescribeSnapshots, r.err
+}
+
+func (r *MockAWSSnapshotsClient) DescribeVolumes(input *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+	return &r.responseDescribeVolumes, r.err
+}
+
+func (r *MockAWSSnapshotsClient) DescribeImages(input *ec2.DescribeImagesInput) (*ec2.DescribeImagesOutput, error) {
+	return &r.responseDescribeImages, r.err
+}
+
+func TestDetectSnapshots(t *testing.T) {
+
+	var defaultMetricConfig = []config.MetricConfig{
+		{
+			Description: "orphaned snapshot age",
+			Constraint: config.MetricConstraintConfig{
+				Operator: ">=",
+				Value:    30,
+			},
+		},
+	}
+
+	t.Run("detect", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, nil, mockPrice, "us-east-1")
+
+		mockClient := MockAWSSnapshotsClient{
+			responseDescribeSnapshots: defaultSnapshotsMock,
+			responseDescribeVolumes:   defaultSnapshotsVolumesMock,
+			responseDescribeImages:    defaultSnapshotsImagesMock,
+		}
+
+		snapshots, err := NewSnapshotsManager(detector, &mockClient)
+		if err != nil {
+			t.Fatalf("unexpected ec2 snapshots manager error happened, got %v expected %v", err, nil)
+		}
+
+		snapshotsManager, ok := snapshots.(*EC2SnapshotsManager)
+		if !ok {
+			t.Fatalf("unexpected ec2 snapshots struct, got %s expected %s", reflect.TypeOf(snapshots), "*EC2SnapshotsManager")
+		}
+
+		response, _ := snapshotsManager.Detect(defaultMetricConfig)
+
+		snapshotsResponse, ok := response.([]DetectedAWSEC2Snapshot)
+		if !ok {
+			t.Fatalf("unexpected ec2 snapshots struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedAWSEC2Snapshot")
+		}
+
+		if len(snapshotsResponse) != 1 {
+			t.Fatalf("unexpected ec2 snapshots detected, got %d expected %d", len(snapshotsResponse), 1)
+		}
+
+		if len(collector.Events) != 1 {
+			t.Fatalf("unexpected collector ec2 snapshots resources, got %d expected %d", len(collector.Events), 1)
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource status events count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+
+		snapshot := snapshotsResponse[0]
+		if snapshot.ResourceID != "snap-1" {
+			t.Fatalf("unexpected snapshot id, got %s expected %s", snapshot.ResourceID, "snap-1")
+		}
+
+		if snapshot.PricePerMonth != 100 {
+			t.Fatalf("unexpected snapshot price per month, got %v expected %v", snapshot.PricePerMonth, 100)
+		}
+
+		if snapshot.AgeDays != 60 {
+			t.Fatalf("unexpected snapshot age, got %d expected %d", snapshot.AgeDays, 60)
+		}
+
+		if snapshot.Tag["team"] != "a" {
+			t.Fatalf("unexpected snapshot tags, got %v", snapshot.Tag)
+		}
+	})
+
+	t.Run("describe error", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+		mockClient := MockAWSSnapshotsClient{
+			err: errors.New("error"),
+		}
+
+		snapshots, err := NewSnapshotsManager(detector, &mockClient)
+		if err != nil {
+			t.Fatalf("unexpected ec2 snapshots manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = snapshots.Detect(defaultMetricConfig)
+		if err == nil {
+			t.Fatalf("unexpected describe snapshots error, return empty")
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource status events count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+	})
+}
@@ -0,0 +1,241 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"regexp"
+	"strings"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// stoppedTimeLayout defines the format of the stop time in the instance state transition reason
+	stoppedTimeLayout = "2006-01-02 15:04:05 MST"
+)
+
+// stoppedTimePattern extracts the stop time from the instance state transition reason,
+// for example: "User initiated (2019-08-15 13:44:55 GMT)"
+var stoppedTimePattern = regexp.MustCompile(`\((\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2} [A-Z]+)\)`)
+
+// EC2StoppedClientDescreptor is an interface defining the aws ec2 client that describes the instances and their volumes
+type EC2StoppedClientDescreptor interface {
+	DescribeInstances(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error)
+	DescribeVolumes(*ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error)
+}
+
+// EC2StoppedManager describes the stopped EC2 instances struct
+type EC2StoppedManager struct {
+	client             EC2StoppedClientDescreptor
+	awsManager         common.AWSManager
+	servicePricingCode string
+	Name               collector.ResourceIdentifier
+}
+
+// DetectedEC2Stopped define the detected AWS stopped EC2 instances
+type DetectedEC2Stopped struct {
+	Region       string
+	Metric       string
+	Name         string
+	InstanceType string
+	StoppedDays  int
+	VolumesCount int
+	VolumesSize  int64
+	collector.PriceDetectedFields
+}
+
+func init() {
+	register.Registry("ec2_stopped", NewEC2StoppedManager)
+}
+
+// NewEC2StoppedManager implements AWS GO SDK
+func NewEC2StoppedManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = ec2.New(awsManager.GetSession())
+	}
+
+	ec2Client, ok := client.(EC2StoppedClientDescreptor)
+	if !ok {
+		return nil, errors.New("invalid ec2 stopped client")
+	}
+
+	return &EC2StoppedManager{
+		client:             ec2Client,
+		awsManager:         awsManager,
+		servicePricingCode: "AmazonEC2",
+		Name:               awsManager.GetResourceIdentifier("ec2_stopped"),
+	}, nil
+}
+
+// Detect stopped EC2 instances, the instance cost is the price of its attached volumes
+func (es *EC2StoppedManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	// This resource support only one metric, the constraint value is the stopped duration in days
+	metric := metrics[0]
+
+	log.WithFields(log.Fields{
+		"region":   es.awsManager.GetRegion(),
+		"resource": "ec2_stopped",
+	}).Info("starting to analyze resource")
+
+	es.awsManager.GetCollector().CollectStart(es.Name)
+
+	detected := []DetectedEC2Stopped{}
+
+	instances, err := es.describeInstances(nil, nil)
+	if err != nil {
+		log.WithError(err).Error("could not describe stopped ec2 instances")
+		es.awsManager.GetCollector().CollectError(es.Name, err)
+		return detected, err
+	}
+
+	now := time.Now()
+	for _, instance := range instances {
+
+		log.WithField("instance_id", *instance.InstanceId).Debug("checking stopped ec2 instance")
+
+		stoppedTime, err := getStoppedTime(instance)
+		if err != nil {
+			log.WithError(err).WithField("instance_id", *instance.InstanceId).Error("could not parse the instance stop time")
+			continue
+		}
+
+		stoppedDays := now.Sub(stoppedTime).Hours() / 24
+		expression, err := metric.Constraint.Evaluate(stoppedDays, nil)
+		if err != nil || !expression {
+			continue
+		}
+
+		volumes, err := es.describeInstanceVolumes(instance)
+		if err != nil {
+			log.WithError(err).WithField("instance_id", *instance.InstanceId).Error("could not describe the instance volumes")
+			continue
+		}
+
+		var pricePerMonth float64
+		var volumesSize int64
+		for _, vol := range volumes {
+			price, err := getVolumePrice(es.awsManager, es.servicePricingCode, vol)
+			if err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"instance_id": *instance.InstanceId,
+					"volume_id":   *vol.VolumeId,
+				}).Error("Error when trying to get volume price")
+			}
+			pricePerMonth += price
+			volumesSize += awsClient.Int64Value(vol.Size)
+		}
+
+		var name string
+		tagsData := map[string]string{}
+		for _, tag := range instance.Tags {
+			tagsData[*tag.Key] = *tag.Value
+			if strings.ToLower(*tag.Key) == "name" {
+				name = *tag.Value
+			}
+		}
+
+		stoppedInstance := DetectedEC2Stopped{
+			Region:       es.awsManager.GetRegion(),
+			Metric:       metric.Description,
+			Name:         name,
+			InstanceType: *instance.InstanceType,
+			StoppedDays:  int(stoppedDays),
+			VolumesCount: len(volumes),
+			VolumesSize:  volumesSize,
+			PriceDetectedFields: collector.PriceDetectedFields{
+				ResourceID:    *instance.InstanceId,
+				LaunchTime:    *instance.LaunchTime,
+				PricePerHour:  pricePerMonth / collector.TotalMonthHours,
+				PricePerMonth: pricePerMonth,
+				Tag:           tagsData,
+			},
+		}
+
+		es.awsManager.GetCollector().AddResource(collector.EventCollector{
+			ResourceName: es.Name,
+			Data:         stoppedInstance,
+		})
+
+		detected = append(detected, stoppedInstance)
+	}
+
+	es.awsManager.GetCollector().CollectFinish(es.Name)
+
+	return detected, nil
+}
+
+// getStoppedTime returns the instance stop time from its state transition reason
+func getStoppedTime(instance *ec2.Instance) (time.Time, error) {
+
+	match := stoppedTimePattern.FindStringSubmatch(awsClient.StringValue(instance.StateTransitionReason))
+	if match == nil {
+		return time.Time{}, errors.New("stop time not found in the state transition reason")
+	}
+
+	return time.Parse(stoppedTimeLayout, match[1])
+}
+
+// describeInstanceVolumes returns the ebs volumes that are attached to the instance
+func (es *EC2StoppedManager) describeInstanceVolumes(instance *ec2.Instance) ([]*ec2.Volume, error) {
+
+	volumeIDs := []*string{}
+	for _, blockDevice := range instance.BlockDeviceMappings {
+		if blockDevice.Ebs != nil && blockDevice.Ebs.VolumeId != nil {
+			volumeIDs = append(volumeIDs, blockDevice.Ebs.VolumeId)
+		}
+	}
+
+	if len(volumeIDs) == 0 {
+		return []*ec2.Volume{}, nil
+	}
+
+	resp, err := es.client.DescribeVolumes(&ec2.DescribeVolumesInput{
+		VolumeIds: volumeIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Volumes, nil
+}
+
+// describeInstances return list of stopped instances
+func (es *EC2StoppedManager) describeInstances(nextToken *string, instances []*ec2.Instance) ([]*ec2.Instance, error) {
+
+	input := &ec2.DescribeInstancesInput{
+		NextToken: nextToken,
+		Filters: []*ec2.Filter{
+			{
+				Name:   awsClient.String("instance-state-name"),
+				Values: []*string{awsClient.String("stopped")},
+			},
+		},
+	}
+
+	resp, err := es.client.DescribeInstances(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if instances == nil {
+		instances = []*ec2.Instance{}
+	}
+
+	for _, reservation := range resp.Reservations {
+		instances = append(instances, reservation.Instances...)
+	}
+
+	if resp.NextToken != nil {
+		return es.describeInstances(resp.NextToken, instances)
+	}
+
+	return instances, nil
+}
@@ -0,0 +1,195 @@
+package resources
+
+import (
+	"errors"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	"finala/collector/testutils"
+	collectorTestutils "finala/collector/testutils"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func stoppedInstanceMock(instanceID string, stateTransitionReason string, volumeIDs ...string) *ec2.Instance {
+
+	blockDevices := []*ec2.InstanceBlockDeviceMapping{}
+	for _, volumeID := range volumeIDs {
+		blockDevices = append(blockDevices, &ec2.InstanceBlockDeviceMapping{
+			Ebs: &ec2.EbsInstanceBlockDevice{VolumeId: awsClient.String(volumeID)},
+		})
+	}
+
+	return &ec2.Instance{
+		InstanceId:            awsClient.String(instanceID),
+		InstanceType:          awsClient.String("t2.micro"),
+		LaunchTime:            testutils.TimePointer(time.Now()),
+		StateTransitionReason: awsClient.String(stateTransitionReason),
+		BlockDeviceMappings:   blockDevices,
+		Tags:                  []*ec2.Tag{{Key: awsClient.String("Name"), Value: awsClient.String(instanceID + "-name")}},
+	}
+}
+
+var defaultStoppedEC2Mock = ec2.DescribeInstancesOutput{
+	Reservations: []*ec2.Reservation{
+		{
+			Instances: []*ec2.Instance{
+				stoppedInstanceMock("i-1", "User initiated ("+time.Now().AddDate(0, 0, -60).UTC().Format("2006-01-02 15:04:05")+" GMT)", "vol-1", "vol-2"),
+				stoppedInstanceMock("i-2", "User initiated ("+time.Now().AddDate(0, 0, -1).UTC().Format("2006-01-02 15:04:05")+" GMT)", "vol-3"),
+				stoppedInstanceMock("i-3", "", "vol-4"),
+			},
+		},
+	},
+}
+
+var defaultStoppedEC2VolumesMock = ec2.DescribeVolumesOutput{
+	Volumes: []*ec2.Volume{
+		{
+			VolumeId:   awsClient.String("vol-1"),
+			VolumeType: awsClient.String("gp2"),
+			Size:       awsClient.Int64(100),
+		},
+		{
+			VolumeId:   awsClient.String("vol-2"),
+			VolumeType: awsClient.String("io1"),
+			Size:       awsClient.Int64(50),
+			Iops:       awsClient.Int64(100),
+		},
+	},
+}
+
+type MockAWSEC2StoppedClient struct {
+	responseDescribeInstances ec2.DescribeInstancesOutput
+	responseDescribeVolumes   ec2.DescribeVolumesOutput
+	err                       error
+}
+
+func (r *MockAWSEC2StoppedClient) DescribeInstances(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	return &r.responseDescribeInstances, r.err
+}
+
+func (r *MockAWSEC2StoppedClient) DescribeVolumes(*ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+	return &r.responseDescribeVolumes, r.err
+}
+
+func TestDetectEC2Stopped(t *testing.T) {
+
+	var defaultMetricConfig = []config.MetricConfig{
+		{
+			Description: "Stopped instance",
+			Constraint: config.MetricConstraintConfig{
+				Operator: ">=",
+				Value:    30,
+			},
+		},
+	}
+
+	t.Run("detect", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, nil, mockPrice, "us-east-1")
+
+		mockClient := MockAWSEC2StoppedClient{
+			responseDescribeInstances: defaultStoppedEC2Mock,
+			responseDescribeVolumes:   defaultStoppedEC2VolumesMock,
+		}
+
+		ec2Interface, err := NewEC2StoppedManager(detector, &mockClient)
+		if err != nil {
+			t.Fatalf("unexpected ec2 stopped manager error happened, got %v expected %v", err, nil)
+		}
+
+		ec2Manager, ok := ec2Interface.(*EC2StoppedManager)
+		if !ok {
+			t.Fatalf("unexpected ec2 stopped struct, got %s expected %s", reflect.TypeOf(ec2Interface), "*EC2StoppedManager")
+		}
+
+		response, _ := ec2Manager.Detect(defaultMetricConfig)
+
+		stoppedResponse, ok := response.([]DetectedEC2Stopped)
+		if !ok {
+			t.Fatalf("unexpected ec2 stopped struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedEC2Stopped")
+		}
+
+		if len(stoppedResponse) != 1 {
+			t.Fatalf("unexpected stopped instances detected, got %d expected %d", len(stoppedResponse), 1)
+		}
+
+		if len(collector.Events) != 1 {
+			t.Fatalf("unexpected collector ec2 stopped resources, got %d expected %d", len(collector.Events), 1)
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource status events count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+
+		stopped := stoppedResponse[0]
+		if stopped.ResourceID != "i-1" || stopped.Name != "i-1-name" {
+			t.Fatalf("unexpected stopped instance, got %s (%s) expected %s (%s)", stopped.ResourceID, stopped.Name, "i-1", "i-1-name")
+		}
+
+		if stopped.StoppedDays != 60 {
+			t.Fatalf("unexpected stopped days, got %d expected %d", stopped.StoppedDays, 60)
+		}
+
+		if stopped.VolumesCount != 2 || stopped.VolumesSize != 150 {
+			t.Fatalf("unexpected stopped instance volumes, got %d volumes of %d GB expected %d volumes of %d GB", stopped.VolumesCount, stopped.VolumesSize, 2, 150)
+		}
+
+		// gp2: 100GB * 1, io1: 50GB * 1 + 100 IOPS * 1
+		if stopped.PricePerMonth != 250 {
+			t.Fatalf("unexpected stopped instance price per month, got %v expected %v", stopped.PricePerMonth, 250)
+		}
+	})
+
+	t.Run("describe error", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+		ec2Interface, err := NewEC2StoppedManager(detector, &MockAWSEC2StoppedClient{err: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected ec2 stopped manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = ec2Interface.Detect(defaultMetricConfig)
+		if err == nil {
+			t.Fatalf("unexpected describe instances error, return empty")
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource status events count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+	})
+}
+
+func TestGetStoppedTime(t *testing.T) {
+
+	testCases := []struct {
+		reason      string
+		expected    time.Time
+		expectedErr bool
+	}{
+		{"User initiated (2019-08-15 13:44:55 GMT)", time.Date(2019, 8, 15, 13, 44, 55, 0, time.UTC), false},
+		{"Server.ScheduledStop: Stopped due to scheduled retirement (2020-01-02 03:04:05 GMT)", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), false},
+		{"User initiated", time.Time{}, true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.reason, func(t *testing.T) {
+
+			stoppedTime, err := getStoppedTime(&ec2.Instance{StateTransitionReason: awsClient.String(test.reason)})
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("unexpected error, got %v", err)
+			}
+
+			if !stoppedTime.Equal(test.expected) {
+				t.Fatalf("unexpected stopped time, got %v expected %v", stoppedTime, test.expected)
+			}
+		})
+	}
+}
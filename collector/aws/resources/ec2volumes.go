@@ -29,13 +29,14 @@ type EC2VolumeManager struct {
 
 // DetectedAWSEC2Volume define the detected volume data
 type DetectedAWSEC2Volume struct {
-	Metric        string
-	Region        string
-	ResourceID    string
-	Type          string
-	Size          int64
-	PricePerMonth float64
-	Tag           map[string]string
+	Metric                 string
+	Region                 string
+	ResourceID             string
+	Type                   string
+	Size                   int64
+	PricePerMonth          float64
+	EffectivePricePerMonth float64
+	Tag                    map[string]string
 }
 
 func init() {
@@ -86,24 +87,14 @@ func (ev *EC2VolumeManager) Detect(metrics []config.MetricConfig) (interface{},
 		return detected, err
 	}
 
-	// Set storage filters to for pricing API
-	filters := []*pricing.Filter{
-		{
-			Type:  awsClient.String("TERM_MATCH"),
-			Field: awsClient.String("productFamily"),
-			Value: awsClient.String("Storage"),
-		},
-	}
-
 	for _, vol := range volumes {
 
 		log.WithField("id", *vol.VolumeId).Debug("cheking ec2 volume")
 
-		price, err := ev.awsManager.GetPricingClient().GetPrice(ev.getBasePricingFilterInput(vol, filters), "", ev.awsManager.GetRegion())
+		price, err := getVolumePrice(ev.awsManager, ev.servicePricingCode, vol)
 		if err != nil {
 			log.WithError(err).WithFields(log.Fields{
 				"volume_id": *vol.VolumeId,
-				"filters":   filters,
 			}).Error("Error when trying to get volume price")
 			price = 0
 		}
@@ -122,7 +113,7 @@ func (ev *EC2VolumeManager) Detect(metrics []config.MetricConfig) (interface{},
 			ResourceID:    *vol.VolumeId,
 			Type:          *vol.VolumeType,
 			Size:          volumeSize,
-			PricePerMonth: ev.getCalculatedPrice(vol, price),
+			PricePerMonth: price,
 			Tag:           tagsData,
 		}
 
@@ -141,8 +132,22 @@ func (ev *EC2VolumeManager) Detect(metrics []config.MetricConfig) (interface{},
 
 }
 
-// getCalculatedPrice calculate the volume price by volume type
-func (ev *EC2VolumeManager) getCalculatedPrice(vol *ec2.Volume, basePrice float64) float64 {
+// getVolumePrice returns the volume price per month by the volume type, size and provisioned IOPS
+func getVolumePrice(awsManager common.AWSManager, servicePricingCode string, vol *ec2.Volume) (float64, error) {
+
+	// Set storage filters to for pricing API
+	filters := []*pricing.Filter{
+		{
+			Type:  awsClient.String("TERM_MATCH"),
+			Field: awsClient.String("productFamily"),
+			Value: awsClient.String("Storage"),
+		},
+	}
+
+	basePrice, err := awsManager.GetPricingClient().GetPrice(getVolumePricingFilterInput(servicePricingCode, vol, filters), "", awsManager.GetRegion())
+	if err != nil {
+		return 0, err
+	}
 
 	volumeSize := *vol.Size
 	switch *vol.VolumeType {
@@ -156,19 +161,19 @@ func (ev *EC2VolumeManager) getCalculatedPrice(vol *ec2.Volume, basePrice float6
 			},
 		}
 
-		iopsPrice, err := ev.awsManager.GetPricingClient().GetPrice(ev.getBasePricingFilterInput(vol, extraFilter), "", ev.awsManager.GetRegion())
+		iopsPrice, err := awsManager.GetPricingClient().GetPrice(getVolumePricingFilterInput(servicePricingCode, vol, extraFilter), "", awsManager.GetRegion())
 		if err != nil {
 			iopsPrice = 0
 		}
-		return basePrice*float64(volumeSize) + iopsPrice*float64(*vol.Iops)
+		return basePrice*float64(volumeSize) + iopsPrice*float64(awsClient.Int64Value(vol.Iops)), nil
 	default:
-		return basePrice * float64(volumeSize)
+		return basePrice * float64(volumeSize), nil
 	}
 
 }
 
-// getBasePricingFilterInput set the pricing product filters
-func (ev *EC2VolumeManager) getBasePricingFilterInput(vol *ec2.Volume, extraFilters []*pricing.Filter) pricing.GetProductsInput {
+// getVolumePricingFilterInput set the pricing product filters
+func getVolumePricingFilterInput(servicePricingCode string, vol *ec2.Volume, extraFilters []*pricing.Filter) pricing.GetProductsInput {
 
 	filters := []*pricing.Filter{
 		{
@@ -183,7 +188,7 @@ func (ev *EC2VolumeManager) getBasePricingFilterInput(vol *ec2.Volume, extraFilt
 	}
 
 	return pricing.GetProductsInput{
-		ServiceCode: &ev.servicePricingCode,
+		ServiceCode: &servicePricingCode,
 		Filters:     filters,
 	}
 
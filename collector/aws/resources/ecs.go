@@ -0,0 +1,419 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/cloudwatch"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	log "github.com/sirupsen/logrus"
+)
+
+// ECSClientDescreptor is an interface defining the aws clients that describe the ecs clusters, services and their ec2 instances
+type ECSClientDescreptor interface {
+	ListClusters(*ecs.ListClustersInput) (*ecs.ListClustersOutput, error)
+	DescribeClusters(*ecs.DescribeClustersInput) (*ecs.DescribeClustersOutput, error)
+	ListServices(*ecs.ListServicesInput) (*ecs.ListServicesOutput, error)
+	DescribeServices(*ecs.DescribeServicesInput) (*ecs.DescribeServicesOutput, error)
+	ListContainerInstances(*ecs.ListContainerInstancesInput) (*ecs.ListContainerInstancesOutput, error)
+	DescribeContainerInstances(*ecs.DescribeContainerInstancesInput) (*ecs.DescribeContainerInstancesOutput, error)
+	DescribeInstances(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error)
+}
+
+// ecsClient combines the ecs and the ec2 (container instances) clients
+type ecsClient struct {
+	*ecs.ECS
+	*ec2.EC2
+}
+
+// ECSManager describes the ECS struct
+type ECSManager struct {
+	client             ECSClientDescreptor
+	awsManager         common.AWSManager
+	namespace          string
+	servicePricingCode string
+	Name               collector.ResourceIdentifier
+}
+
+// DetectedECS defines the detected AWS ECS clusters and services, a cluster has an empty service name
+// and a service is linked to its cluster by the cluster arn
+type DetectedECS struct {
+	Region      string
+	Metric      string
+	ClusterName string
+	ClusterARN  string
+	ServiceName string
+	LaunchType  string
+	Instances   int
+	collector.PriceDetectedFields
+	collector.MetricDetectedFields
+}
+
+// ecsClusterInstances describe the ec2 container instances of a cluster
+type ecsClusterInstances struct {
+	count        int
+	pricePerHour float64
+	registeredAt time.Time
+}
+
+func init() {
+	register.Registry("ecs", NewECSManager)
+}
+
+// NewECSManager implements AWS GO SDK
+func NewECSManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = &ecsClient{
+			ECS: ecs.New(awsManager.GetSession()),
+			EC2: ec2.New(awsManager.GetSession()),
+		}
+	}
+
+	ecsClusterClient, ok := client.(ECSClientDescreptor)
+	if !ok {
+		return nil, errors.New("invalid ecs client")
+	}
+
+	return &ECSManager{
+		client:             ecsClusterClient,
+		awsManager:         awsManager,
+		namespace:          "AWS/ECS",
+		servicePricingCode: "AmazonEC2",
+		Name:               awsManager.GetResourceIdentifier("ecs"),
+	}, nil
+}
+
+// Detect check which ECS clusters and services are under utilized. The cluster cost is the price of its ec2 container instances,
+// the services run on the cluster capacity so they are not priced, otherwise their cost would be counted twice.
+// Each cluster and service is reported once, by the first metric that detects it
+func (ecm *ECSManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	log.WithFields(log.Fields{
+		"region":   ecm.awsManager.GetRegion(),
+		"resource": "ecs",
+	}).Info("starting to analyze resource")
+
+	ecm.awsManager.GetCollector().CollectStart(ecm.Name)
+
+	detected := []DetectedECS{}
+
+	clusters, err := ecm.describeClusters(nil, nil)
+	if err != nil {
+		log.WithError(err).Error("could not describe ecs clusters")
+		ecm.awsManager.GetCollector().CollectError(ecm.Name, err)
+		return detected, err
+	}
+
+	now := time.Now()
+	batch := ecm.awsManager.GetCloudWatchClient().NewBatch()
+	reported := map[string]struct{}{}
+
+	for _, cluster := range clusters {
+
+		log.WithField("cluster_name", *cluster.ClusterName).Debug("checking ecs cluster")
+
+		instances, err := ecm.describeClusterInstances(cluster.ClusterArn, nil, nil)
+		if err != nil {
+			log.WithError(err).WithField("cluster_name", *cluster.ClusterName).Error("could not describe ecs cluster instances")
+			continue
+		}
+
+		clusterDimensions := []*awsCloudwatch.Dimension{
+			{
+				Name:  awsClient.String("ClusterName"),
+				Value: cluster.ClusterName,
+			},
+		}
+
+		clusterData := DetectedECS{
+			Region:      ecm.awsManager.GetRegion(),
+			ClusterName: *cluster.ClusterName,
+			ClusterARN:  *cluster.ClusterArn,
+			LaunchType:  ecs.LaunchTypeEc2,
+			Instances:   instances.count,
+			PriceDetectedFields: collector.PriceDetectedFields{
+				ResourceID:    *cluster.ClusterArn,
+				LaunchTime:    instances.registeredAt,
+				PricePerHour:  instances.pricePerHour,
+				PricePerMonth: instances.pricePerHour * collector.TotalMonthHours,
+				Tag:           getECSTags(cluster.Tags),
+			},
+		}
+
+		for _, metric := range metrics {
+			ecm.addMetric(batch, metric, now, clusterDimensions, clusterData, reported, &detected)
+		}
+
+		services, err := ecm.describeServices(cluster.ClusterArn, nil, nil)
+		if err != nil {
+			log.WithError(err).WithField("cluster_name", *cluster.ClusterName).Error("could not describe ecs services")
+			continue
+		}
+
+		for _, service := range services {
+
+			log.WithFields(log.Fields{
+				"cluster_name": *cluster.ClusterName,
+				"service_name": *service.ServiceName,
+			}).Debug("checking ecs service")
+
+			serviceDimensions := []*awsCloudwatch.Dimension{
+				{
+					Name:  awsClient.String("ClusterName"),
+					Value: cluster.ClusterName,
+				},
+				{
+					Name:  awsClient.String("ServiceName"),
+					Value: service.ServiceName,
+				},
+			}
+
+			serviceData := DetectedECS{
+				Region:      ecm.awsManager.GetRegion(),
+				ClusterName: *cluster.ClusterName,
+				ClusterARN:  *cluster.ClusterArn,
+				ServiceName: *service.ServiceName,
+				LaunchType:  awsClient.StringValue(service.LaunchType),
+				PriceDetectedFields: collector.PriceDetectedFields{
+					ResourceID: *service.ServiceArn,
+					LaunchTime: awsClient.TimeValue(service.CreatedAt),
+					Tag:        getECSTags(service.Tags),
+				},
+			}
+
+			for _, metric := range metrics {
+				ecm.addMetric(batch, metric, now, serviceDimensions, serviceData, reported, &detected)
+			}
+		}
+	}
+
+	batch.Execute()
+
+	ecm.awsManager.GetCollector().CollectFinish(ecm.Name)
+
+	return detected, nil
+}
+
+// addMetric adds the metric of the cluster or service to the batch, the resource is detected when the metric constraint is met
+// and the resource was not reported yet
+func (ecm *ECSManager) addMetric(batch *cloudwatch.MetricBatch, metric config.MetricConfig, now time.Time, dimensions []*awsCloudwatch.Dimension, resource DetectedECS, reported map[string]struct{}, detected *[]DetectedECS) {
+
+	period := int64(metric.Period.Seconds())
+	metricEndTime := now.Add(time.Duration(-metric.StartTime))
+	metricInput := awsCloudwatch.GetMetricStatisticsInput{
+		Namespace:  &ecm.namespace,
+		MetricName: &metric.Description,
+		Period:     &period,
+		StartTime:  &metricEndTime,
+		EndTime:    &now,
+		Dimensions: dimensions,
+	}
+
+	batch.Add(&metricInput, metric, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"resource_id": resource.ResourceID,
+				"metric_name": metric.Description,
+			}).Error("Could not get cloudwatch metric data")
+			return
+		}
+
+		if _, found := reported[resource.ResourceID]; found {
+			return
+		}
+
+		expression, err := metric.Constraint.Evaluate(formulaValue, metricsValues)
+		if err != nil || !expression {
+			return
+		}
+		reported[resource.ResourceID] = struct{}{}
+
+		log.WithFields(log.Fields{
+			"metric_name":         metric.Description,
+			"constraint_operator": metric.Constraint.Operator,
+			"constraint_Value":    metric.Constraint.Value,
+			"formula_value":       formulaValue,
+			"cluster_name":        resource.ClusterName,
+			"service_name":        resource.ServiceName,
+			"region":              ecm.awsManager.GetRegion(),
+		}).Info("ECS resource detected as unutilized resource")
+
+		resource.Metric = metric.Description
+		resource.MetricDetectedFields = cloudwatch.NewMetricDetectedFields(metric, &metricInput, formulaValue, metricsValues)
+
+		ecm.awsManager.GetCollector().AddResource(collector.EventCollector{
+			ResourceName: ecm.Name,
+			Data:         resource,
+		})
+
+		*detected = append(*detected, resource)
+	})
+}
+
+// describeClusterInstances returns the count, the price per hour and the first registration time of the cluster ec2 container instances
+func (ecm *ECSManager) describeClusterInstances(clusterArn *string, nextToken *string, instances *ecsClusterInstances) (*ecsClusterInstances, error) {
+
+	if instances == nil {
+		instances = &ecsClusterInstances{}
+	}
+
+	resp, err := ecm.client.ListContainerInstances(&ecs.ListContainerInstancesInput{
+		Cluster:   clusterArn,
+		NextToken: nextToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.ContainerInstanceArns) > 0 {
+		containerInstances, err := ecm.client.DescribeContainerInstances(&ecs.DescribeContainerInstancesInput{
+			Cluster:            clusterArn,
+			ContainerInstances: resp.ContainerInstanceArns,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		instanceIDs := []*string{}
+		for _, containerInstance := range containerInstances.ContainerInstances {
+			if containerInstance.Ec2InstanceId != nil {
+				instanceIDs = append(instanceIDs, containerInstance.Ec2InstanceId)
+			}
+			registeredAt := awsClient.TimeValue(containerInstance.RegisteredAt)
+			if instances.registeredAt.IsZero() || registeredAt.Before(instances.registeredAt) {
+				instances.registeredAt = registeredAt
+			}
+		}
+
+		err = ecm.priceInstances(instanceIDs, instances)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.NextToken != nil {
+		return ecm.describeClusterInstances(clusterArn, resp.NextToken, instances)
+	}
+
+	return instances, nil
+}
+
+// priceInstances adds the ec2 instances count and price per hour
+func (ecm *ECSManager) priceInstances(instanceIDs []*string, instances *ecsClusterInstances) error {
+
+	if len(instanceIDs) == 0 {
+		return nil
+	}
+
+	input := &ec2.DescribeInstancesInput{
+		InstanceIds: instanceIDs,
+	}
+
+	for {
+		resp, err := ecm.client.DescribeInstances(input)
+		if err != nil {
+			return err
+		}
+
+		for _, reservation := range resp.Reservations {
+			for _, instance := range reservation.Instances {
+				price, err := ecm.awsManager.GetPricingClient().GetPrice(getEC2PricingFilterInput(ecm.servicePricingCode, *instance.InstanceType, instance.Platform), "", ecm.awsManager.GetRegion())
+				if err != nil {
+					log.WithError(err).WithField("instance_id", *instance.InstanceId).Error("could not get ecs container instance price")
+				}
+				instances.count++
+				instances.pricePerHour += price
+			}
+		}
+
+		if resp.NextToken == nil {
+			return nil
+		}
+		input.NextToken = resp.NextToken
+	}
+}
+
+// describeClusters returns the ecs clusters
+func (ecm *ECSManager) describeClusters(nextToken *string, clusters []*ecs.Cluster) ([]*ecs.Cluster, error) {
+
+	resp, err := ecm.client.ListClusters(&ecs.ListClustersInput{
+		NextToken: nextToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if clusters == nil {
+		clusters = []*ecs.Cluster{}
+	}
+
+	if len(resp.ClusterArns) > 0 {
+		clustersResp, err := ecm.client.DescribeClusters(&ecs.DescribeClustersInput{
+			Clusters: resp.ClusterArns,
+			Include:  []*string{awsClient.String(ecs.ClusterFieldTags)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, clustersResp.Clusters...)
+	}
+
+	if resp.NextToken != nil {
+		return ecm.describeClusters(resp.NextToken, clusters)
+	}
+
+	return clusters, nil
+}
+
+// describeServices returns the services of the ecs cluster
+func (ecm *ECSManager) describeServices(clusterArn *string, nextToken *string, services []*ecs.Service) ([]*ecs.Service, error) {
+
+	resp, err := ecm.client.ListServices(&ecs.ListServicesInput{
+		Cluster:   clusterArn,
+		NextToken: nextToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if services == nil {
+		services = []*ecs.Service{}
+	}
+
+	if len(resp.ServiceArns) > 0 {
+		servicesResp, err := ecm.client.DescribeServices(&ecs.DescribeServicesInput{
+			Cluster:  clusterArn,
+			Services: resp.ServiceArns,
+			Include:  []*string{awsClient.String(ecs.ServiceFieldTags)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, servicesResp.Services...)
+	}
+
+	if resp.NextToken != nil {
+		return ecm.describeServices(clusterArn, resp.NextToken, services)
+	}
+
+	return services, nil
+}
+
+// getECSTags returns the ecs resource tags as a map
+func getECSTags(tags []*ecs.Tag) map[string]string {
+
+	tagsData := map[string]string{}
+	for _, tag := range tags {
+		tagsData[*tag.Key] = *tag.Value
+	}
+
+	return tagsData
+}
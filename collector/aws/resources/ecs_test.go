@@ -0,0 +1,218 @@
+package resources
+
+import (
+	"errors"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	collectorTestutils "finala/collector/testutils"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+var defaultECSClustersMock = ecs.DescribeClustersOutput{
+	Clusters: []*ecs.Cluster{
+		{
+			ClusterArn:        awsClient.String("arn:aws:ecs:us-east-1:1234:cluster/cluster-1"),
+			ClusterName:       awsClient.String("cluster-1"),
+			RunningTasksCount: awsClient.Int64(4),
+			Tags:              []*ecs.Tag{{Key: awsClient.String("team"), Value: awsClient.String("a")}},
+		},
+	},
+}
+
+var defaultECSServicesMock = ecs.DescribeServicesOutput{
+	Services: []*ecs.Service{
+		{
+			ServiceArn:   awsClient.String("arn:aws:ecs:us-east-1:1234:service/service-ec2"),
+			ServiceName:  awsClient.String("service-ec2"),
+			LaunchType:   awsClient.String("EC2"),
+			RunningCount: awsClient.Int64(2),
+			CreatedAt:    collectorTestutils.TimePointer(time.Now()),
+		},
+		{
+			ServiceArn:   awsClient.String("arn:aws:ecs:us-east-1:1234:service/service-fargate"),
+			ServiceName:  awsClient.String("service-fargate"),
+			LaunchType:   awsClient.String("FARGATE"),
+			RunningCount: awsClient.Int64(2),
+			CreatedAt:    collectorTestutils.TimePointer(time.Now()),
+		},
+	},
+}
+
+var defaultECSContainerInstancesMock = ecs.DescribeContainerInstancesOutput{
+	ContainerInstances: []*ecs.ContainerInstance{
+		{Ec2InstanceId: awsClient.String("i-1"), RegisteredAt: collectorTestutils.TimePointer(time.Now().AddDate(0, 0, -2))},
+		{Ec2InstanceId: awsClient.String("i-2"), RegisteredAt: collectorTestutils.TimePointer(time.Now().AddDate(0, 0, -1))},
+	},
+}
+
+var defaultECSInstancesMock = ec2.DescribeInstancesOutput{
+	Reservations: []*ec2.Reservation{
+		{
+			Instances: []*ec2.Instance{
+				{InstanceId: awsClient.String("i-1"), InstanceType: awsClient.String("t2.micro")},
+				{InstanceId: awsClient.String("i-2"), InstanceType: awsClient.String("t2.micro")},
+			},
+		},
+	},
+}
+
+type MockAWSECSClient struct {
+	err error
+}
+
+func (r *MockAWSECSClient) ListClusters(*ecs.ListClustersInput) (*ecs.ListClustersOutput, error) {
+	return &ecs.ListClustersOutput{ClusterArns: []*string{defaultECSClustersMock.Clusters[0].ClusterArn}}, r.err
+}
+
+func (r *MockAWSECSClient) DescribeClusters(*ecs.DescribeClustersInput) (*ecs.DescribeClustersOutput, error) {
+	return &defaultECSClustersMock, r.err
+}
+
+func (r *MockAWSECSClient) ListServices(*ecs.ListServicesInput) (*ecs.ListServicesOutput, error) {
+	return &ecs.ListServicesOutput{ServiceArns: []*string{awsClient.String("service-ec2"), awsClient.String("service-fargate")}}, r.err
+}
+
+func (r *MockAWSECSClient) DescribeServices(*ecs.DescribeServicesInput) (*ecs.DescribeServicesOutput, error) {
+	return &defaultECSServicesMock, r.err
+}
+
+func (r *MockAWSECSClient) ListContainerInstances(*ecs.ListContainerInstancesInput) (*ecs.ListContainerInstancesOutput, error) {
+	return &ecs.ListContainerInstancesOutput{ContainerInstanceArns: []*string{awsClient.String("ci-1"), awsClient.String("ci-2")}}, r.err
+}
+
+func (r *MockAWSECSClient) DescribeContainerInstances(*ecs.DescribeContainerInstancesInput) (*ecs.DescribeContainerInstancesOutput, error) {
+	return &defaultECSContainerInstancesMock, r.err
+}
+
+func (r *MockAWSECSClient) DescribeInstances(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	return &defaultECSInstancesMock, r.err
+}
+
+func TestDetectECS(t *testing.T) {
+
+	t.Run("detect", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		ecsInterface, err := NewECSManager(detector, &MockAWSECSClient{})
+		if err != nil {
+			t.Fatalf("unexpected ecs manager error happened, got %v expected %v", err, nil)
+		}
+
+		ecsManager, ok := ecsInterface.(*ECSManager)
+		if !ok {
+			t.Fatalf("unexpected ecs struct, got %s expected %s", reflect.TypeOf(ecsInterface), "*ECSManager")
+		}
+
+		response, err := ecsManager.Detect(awsTestutils.DefaultMetricConfig)
+		if err != nil {
+			t.Fatalf("unexpected ecs error happened, got %v expected %v", err, nil)
+		}
+
+		ecsResponse, ok := response.([]DetectedECS)
+		if !ok {
+			t.Fatalf("unexpected ecs struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedECS")
+		}
+
+		if len(ecsResponse) != 3 {
+			t.Fatalf("unexpected ecs resources detected, got %d expected %d", len(ecsResponse), 3)
+		}
+
+		if len(collector.Events) != 3 {
+			t.Fatalf("unexpected collector ecs events, got %d expected %d", len(collector.Events), 3)
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource event collection status count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+
+		expected := []struct {
+			serviceName  string
+			pricePerHour float64
+		}{
+			// The cluster 2 instances
+			{"", 2},
+			// The services run on the cluster instances, the cost is reported by the cluster
+			{"service-ec2", 0},
+			{"service-fargate", 0},
+		}
+		for i, resource := range ecsResponse {
+			if resource.ServiceName != expected[i].serviceName || resource.PricePerHour != expected[i].pricePerHour {
+				t.Fatalf("unexpected ecs resource, got %q priced %v expected %q priced %v", resource.ServiceName, resource.PricePerHour, expected[i].serviceName, expected[i].pricePerHour)
+			}
+
+			if resource.ClusterARN != *defaultECSClustersMock.Clusters[0].ClusterArn {
+				t.Fatalf("unexpected ecs resource cluster arn, got %q expected %q", resource.ClusterARN, *defaultECSClustersMock.Clusters[0].ClusterArn)
+			}
+		}
+
+		cluster := ecsResponse[0]
+		if cluster.Instances != 2 || cluster.Tag["team"] != "a" {
+			t.Fatalf("unexpected ecs cluster, got %d instances and tags %v", cluster.Instances, cluster.Tag)
+		}
+
+		if !cluster.LaunchTime.Equal(*defaultECSContainerInstancesMock.ContainerInstances[0].RegisteredAt) {
+			t.Fatalf("unexpected ecs cluster launch time, got %v expected %v", cluster.LaunchTime, *defaultECSContainerInstancesMock.ContainerInstances[0].RegisteredAt)
+		}
+	})
+
+	t.Run("multiple metrics", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		ecsManager, err := NewECSManager(detector, &MockAWSECSClient{})
+		if err != nil {
+			t.Fatalf("unexpected ecs manager error happened, got %v expected %v", err, nil)
+		}
+
+		metrics := []config.MetricConfig{awsTestutils.DefaultMetricConfig[0], awsTestutils.DefaultMetricConfig[0]}
+		response, err := ecsManager.Detect(metrics)
+		if err != nil {
+			t.Fatalf("unexpected ecs error happened, got %v expected %v", err, nil)
+		}
+
+		ecsResponse, ok := response.([]DetectedECS)
+		if !ok {
+			t.Fatalf("unexpected ecs struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedECS")
+		}
+
+		if len(ecsResponse) != 3 {
+			t.Fatalf("unexpected ecs resources detected, got %d expected %d", len(ecsResponse), 3)
+		}
+
+		if len(collector.Events) != 3 {
+			t.Fatalf("unexpected collector ecs events, got %d expected %d", len(collector.Events), 3)
+		}
+	})
+
+	t.Run("detection error", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		ecsManager, err := NewECSManager(detector, &MockAWSECSClient{err: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected ecs manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = ecsManager.Detect(awsTestutils.DefaultMetricConfig)
+		if err == nil {
+			t.Fatalf("unexpected describe ecs clusters error, return empty")
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource event collection status count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+	})
+}
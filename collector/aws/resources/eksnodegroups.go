@@ -0,0 +1,388 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/cloudwatch"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/eks"
+	log "github.com/sirupsen/logrus"
+)
+
+// EKSNodeGroupsClientDescreptor is an interface defining the aws eks and the node groups autoscaling groups clients
+type EKSNodeGroupsClientDescreptor interface {
+	ListClusters(*eks.ListClustersInput) (*eks.ListClustersOutput, error)
+	ListNodegroups(*eks.ListNodegroupsInput) (*eks.ListNodegroupsOutput, error)
+	DescribeNodegroup(*eks.DescribeNodegroupInput) (*eks.DescribeNodegroupOutput, error)
+	DescribeAutoScalingGroups(*autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error)
+}
+
+// eksNodeGroupsClient combines the eks and the autoscaling clients
+type eksNodeGroupsClient struct {
+	*eks.EKS
+	*autoscaling.AutoScaling
+}
+
+// EKSNodeGroupsManager describes the EKS node groups struct
+type EKSNodeGroupsManager struct {
+	client             EKSNodeGroupsClientDescreptor
+	awsManager         common.AWSManager
+	namespace          string
+	servicePricingCode string
+	Name               collector.ResourceIdentifier
+}
+
+// DetectedEKSNodeGroup defines the detected AWS EKS managed node groups. The price is an estimate when the node group
+// runs spot instances or a custom AMI, it is priced at the on demand rate of the AMI type operating system
+type DetectedEKSNodeGroup struct {
+	Region         string
+	Metric         string
+	ClusterName    string
+	NodeGroupName  string
+	InstanceType   string
+	DesiredSize    int64
+	PriceEstimated bool
+	collector.PriceDetectedFields
+	collector.MetricDetectedFields
+}
+
+func init() {
+	register.Registry("eks_nodegroups", NewEKSNodeGroupsManager)
+}
+
+// NewEKSNodeGroupsManager implements AWS GO SDK
+func NewEKSNodeGroupsManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = &eksNodeGroupsClient{
+			EKS:         eks.New(awsManager.GetSession()),
+			AutoScaling: autoscaling.New(awsManager.GetSession()),
+		}
+	}
+
+	eksClient, ok := client.(EKSNodeGroupsClientDescreptor)
+	if !ok {
+		return nil, errors.New("invalid eks client")
+	}
+
+	return &EKSNodeGroupsManager{
+		client:             eksClient,
+		awsManager:         awsManager,
+		namespace:          "AWS/EC2",
+		servicePricingCode: "AmazonEC2",
+		Name:               awsManager.GetResourceIdentifier("eks_nodegroups"),
+	}, nil
+}
+
+// Detect check which EKS node groups instances are under utilized. The metrics are the node group autoscaling group
+// instances metrics, and the node group cost is the price of the autoscaling group instances by their instance type,
+// so node groups with a launch template or with mixed instance types are priced by the instances they actually run.
+// Each node group is reported once, by the first metric that detects it
+func (ekm *EKSNodeGroupsManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	log.WithFields(log.Fields{
+		"region":   ekm.awsManager.GetRegion(),
+		"resource": "eks_nodegroups",
+	}).Info("starting to analyze resource")
+
+	ekm.awsManager.GetCollector().CollectStart(ekm.Name)
+
+	detected := []DetectedEKSNodeGroup{}
+
+	nodeGroups, err := ekm.describeNodeGroups()
+	if err != nil {
+		log.WithError(err).Error("could not describe eks node groups")
+		ekm.awsManager.GetCollector().CollectError(ekm.Name, err)
+		return detected, err
+	}
+
+	// The hourly price of each instance type and platform, most of the node groups share the same few instance types
+	instanceTypePrices := map[string]float64{}
+
+	now := time.Now()
+	batch := ekm.awsManager.GetCloudWatchClient().NewBatch()
+	reported := map[string]struct{}{}
+
+	for _, nodeGroup := range nodeGroups {
+		nodeGroup := nodeGroup
+
+		if nodeGroup.Resources == nil || len(nodeGroup.Resources.AutoScalingGroups) == 0 {
+			log.WithFields(log.Fields{
+				"cluster_name":   *nodeGroup.ClusterName,
+				"nodegroup_name": *nodeGroup.NodegroupName,
+				"status":         awsClient.StringValue(nodeGroup.Status),
+			}).Info("skipping eks node group without an autoscaling group")
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"cluster_name":   *nodeGroup.ClusterName,
+			"nodegroup_name": *nodeGroup.NodegroupName,
+		}).Debug("checking eks node group")
+
+		var desiredSize int64
+		if nodeGroup.ScalingConfig != nil {
+			desiredSize = awsClient.Int64Value(nodeGroup.ScalingConfig.DesiredSize)
+		}
+
+		instances, spot, err := ekm.getNodeGroupInstances(nodeGroup, desiredSize)
+		if err != nil {
+			log.WithError(err).WithField("nodegroup_name", *nodeGroup.NodegroupName).Error("could not describe eks node group autoscaling groups")
+			continue
+		}
+
+		// Custom AMIs are launched from the node group launch template, their operating system is unknown
+		platform, customAMI := getEKSNodeGroupPlatform(nodeGroup.AmiType)
+		priceEstimated := spot || customAMI
+
+		var pricePerHour float64
+		instanceTypes := []string{}
+		for instanceType, count := range instances {
+			instanceTypes = append(instanceTypes, instanceType)
+
+			priceKey := fmt.Sprintf("%s:%s", instanceType, platform)
+			price, ok := instanceTypePrices[priceKey]
+			if !ok {
+				price, err = ekm.awsManager.GetPricingClient().GetPrice(getEC2PricingFilterInput(ekm.servicePricingCode, instanceType, &platform), "", ekm.awsManager.GetRegion())
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"instance_type": instanceType,
+						"platform":      platform,
+					}).Error("could not get eks node group instance price")
+				}
+				instanceTypePrices[priceKey] = price
+			}
+			pricePerHour += price * float64(count)
+		}
+		sort.Strings(instanceTypes)
+		instanceType := strings.Join(instanceTypes, ",")
+
+		if priceEstimated {
+			log.WithFields(log.Fields{
+				"nodegroup_name": *nodeGroup.NodegroupName,
+				"ami_type":       awsClient.StringValue(nodeGroup.AmiType),
+				"spot":           spot,
+			}).Debug("eks node group price is estimated by the on demand price")
+		}
+
+		for _, metric := range metrics {
+			metric := metric
+
+			period := int64(metric.Period.Seconds())
+			metricEndTime := now.Add(time.Duration(-metric.StartTime))
+			metricInput := awsCloudwatch.GetMetricStatisticsInput{
+				Namespace:  &ekm.namespace,
+				MetricName: &metric.Description,
+				Period:     &period,
+				StartTime:  &metricEndTime,
+				EndTime:    &now,
+				Dimensions: []*awsCloudwatch.Dimension{
+					{
+						Name:  awsClient.String("AutoScalingGroupName"),
+						Value: nodeGroup.Resources.AutoScalingGroups[0].Name,
+					},
+				},
+			}
+
+			batch.Add(&metricInput, metric, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"nodegroup_name": *nodeGroup.NodegroupName,
+						"metric_name":    metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					return
+				}
+
+				if _, found := reported[*nodeGroup.NodegroupArn]; found {
+					return
+				}
+
+				expression, err := metric.Constraint.Evaluate(formulaValue, metricsValues)
+				if err != nil || !expression {
+					return
+				}
+				reported[*nodeGroup.NodegroupArn] = struct{}{}
+
+				log.WithFields(log.Fields{
+					"metric_name":         metric.Description,
+					"constraint_operator": metric.Constraint.Operator,
+					"constraint_Value":    metric.Constraint.Value,
+					"formula_value":       formulaValue,
+					"cluster_name":        *nodeGroup.ClusterName,
+					"nodegroup_name":      *nodeGroup.NodegroupName,
+					"region":              ekm.awsManager.GetRegion(),
+				}).Info("EKS node group detected as unutilized resource")
+
+				tagsData := map[string]string{}
+				for key, value := range nodeGroup.Tags {
+					tagsData[key] = *value
+				}
+
+				nodeGroupData := DetectedEKSNodeGroup{
+					Region:         ekm.awsManager.GetRegion(),
+					Metric:         metric.Description,
+					ClusterName:    *nodeGroup.ClusterName,
+					NodeGroupName:  *nodeGroup.NodegroupName,
+					InstanceType:   instanceType,
+					DesiredSize:    desiredSize,
+					PriceEstimated: priceEstimated,
+					PriceDetectedFields: collector.PriceDetectedFields{
+						ResourceID:    *nodeGroup.NodegroupArn,
+						LaunchTime:    awsClient.TimeValue(nodeGroup.CreatedAt),
+						PricePerHour:  pricePerHour,
+						PricePerMonth: pricePerHour * collector.TotalMonthHours,
+						Tag:           tagsData,
+					},
+					MetricDetectedFields: cloudwatch.NewMetricDetectedFields(metric, &metricInput, formulaValue, metricsValues),
+				}
+
+				ekm.awsManager.GetCollector().AddResource(collector.EventCollector{
+					ResourceName: ekm.Name,
+					Data:         nodeGroupData,
+				})
+
+				detected = append(detected, nodeGroupData)
+			})
+		}
+	}
+
+	batch.Execute()
+
+	ekm.awsManager.GetCollector().CollectFinish(ekm.Name)
+
+	return detected, nil
+}
+
+// getNodeGroupInstances returns the number of in service instances of each instance type in the node group autoscaling
+// groups, and whether the autoscaling groups launch spot instances. When the autoscaling groups have no instances,
+// the desired size is counted with the first instance type of the node group
+func (ekm *EKSNodeGroupsManager) getNodeGroupInstances(nodeGroup *eks.Nodegroup, desiredSize int64) (map[string]int64, bool, error) {
+
+	names := []*string{}
+	for _, autoScalingGroup := range nodeGroup.Resources.AutoScalingGroups {
+		names = append(names, autoScalingGroup.Name)
+	}
+
+	instances := map[string]int64{}
+	spot := false
+
+	input := &autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: names,
+	}
+	for {
+		resp, err := ekm.client.DescribeAutoScalingGroups(input)
+		if err != nil {
+			return nil, false, err
+		}
+
+		for _, autoScalingGroup := range resp.AutoScalingGroups {
+			if isSpotAutoScalingGroup(autoScalingGroup) {
+				spot = true
+			}
+
+			for _, instance := range autoScalingGroup.Instances {
+				if awsClient.StringValue(instance.LifecycleState) != autoscaling.LifecycleStateInService || instance.InstanceType == nil {
+					continue
+				}
+				instances[*instance.InstanceType]++
+			}
+		}
+
+		if resp.NextToken == nil {
+			break
+		}
+		input.NextToken = resp.NextToken
+	}
+
+	if len(instances) == 0 && len(nodeGroup.InstanceTypes) > 0 && desiredSize > 0 {
+		instances[*nodeGroup.InstanceTypes[0]] = desiredSize
+	}
+
+	return instances, spot, nil
+}
+
+// isSpotAutoScalingGroup returns true when the autoscaling group launches spot instances above its on demand capacity
+func isSpotAutoScalingGroup(autoScalingGroup *autoscaling.Group) bool {
+
+	if autoScalingGroup.MixedInstancesPolicy == nil || autoScalingGroup.MixedInstancesPolicy.InstancesDistribution == nil {
+		return false
+	}
+
+	onDemandPercentage := autoScalingGroup.MixedInstancesPolicy.InstancesDistribution.OnDemandPercentageAboveBaseCapacity
+	return onDemandPercentage != nil && *onDemandPercentage < 100
+}
+
+// getEKSNodeGroupPlatform returns the pricing operating system of the node group AMI type, and whether the node group
+// uses a custom AMI which its operating system is unknown
+func getEKSNodeGroupPlatform(amiType *string) (string, bool) {
+
+	switch value := awsClient.StringValue(amiType); {
+	case strings.HasPrefix(value, "WINDOWS"):
+		return "windows", false
+	case value == "CUSTOM":
+		return "Linux", true
+	default:
+		return "Linux", false
+	}
+}
+
+// describeNodeGroups returns the managed node groups of all the eks clusters
+func (ekm *EKSNodeGroupsManager) describeNodeGroups() ([]*eks.Nodegroup, error) {
+
+	nodeGroups := []*eks.Nodegroup{}
+
+	clustersInput := &eks.ListClustersInput{}
+	for {
+		clusters, err := ekm.client.ListClusters(clustersInput)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, clusterName := range clusters.Clusters {
+			nodeGroupsInput := &eks.ListNodegroupsInput{
+				ClusterName: clusterName,
+			}
+
+			for {
+				resp, err := ekm.client.ListNodegroups(nodeGroupsInput)
+				if err != nil {
+					return nil, err
+				}
+
+				for _, nodeGroupName := range resp.Nodegroups {
+					nodeGroup, err := ekm.client.DescribeNodegroup(&eks.DescribeNodegroupInput{
+						ClusterName:   clusterName,
+						NodegroupName: nodeGroupName,
+					})
+					if err != nil {
+						log.WithError(err).WithField("nodegroup_name", *nodeGroupName).Error("could not describe eks node group")
+						continue
+					}
+					nodeGroups = append(nodeGroups, nodeGroup.Nodegroup)
+				}
+
+				if resp.NextToken == nil {
+					break
+				}
+				nodeGroupsInput.NextToken = resp.NextToken
+			}
+		}
+
+		if clusters.NextToken == nil {
+			break
+		}
+		clustersInput.NextToken = clusters.NextToken
+	}
+
+	return nodeGroups, nil
+}
@@ -0,0 +1,211 @@
+package resources
+
+import (
+	"errors"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	collectorTestutils "finala/collector/testutils"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/eks"
+)
+
+var defaultEKSNodeGroupsMock = map[string]*eks.Nodegroup{
+	"nodegroup-1": {
+		ClusterName:   awsClient.String("cluster-1"),
+		NodegroupName: awsClient.String("nodegroup-1"),
+		NodegroupArn:  awsClient.String("arn:aws:eks:us-east-1:1234:nodegroup/cluster-1/nodegroup-1"),
+		CreatedAt:     collectorTestutils.TimePointer(time.Now()),
+		InstanceTypes: []*string{awsClient.String("t3.large")},
+		ScalingConfig: &eks.NodegroupScalingConfig{DesiredSize: awsClient.Int64(3)},
+		Resources: &eks.NodegroupResources{
+			AutoScalingGroups: []*eks.AutoScalingGroup{{Name: awsClient.String("eks-nodegroup-1")}},
+		},
+		Tags: map[string]*string{"team": awsClient.String("a")},
+	},
+	// Launch template node group, without instance types
+	"nodegroup-3": {
+		ClusterName:   awsClient.String("cluster-1"),
+		NodegroupName: awsClient.String("nodegroup-3"),
+		NodegroupArn:  awsClient.String("arn:aws:eks:us-east-1:1234:nodegroup/cluster-1/nodegroup-3"),
+		CreatedAt:     collectorTestutils.TimePointer(time.Now()),
+		AmiType:       awsClient.String("CUSTOM"),
+		ScalingConfig: &eks.NodegroupScalingConfig{DesiredSize: awsClient.Int64(2)},
+		Resources: &eks.NodegroupResources{
+			AutoScalingGroups: []*eks.AutoScalingGroup{{Name: awsClient.String("eks-nodegroup-3")}},
+		},
+	},
+	// Still creating, without an autoscaling group
+	"nodegroup-2": {
+		ClusterName:   awsClient.String("cluster-1"),
+		NodegroupName: awsClient.String("nodegroup-2"),
+		NodegroupArn:  awsClient.String("arn:aws:eks:us-east-1:1234:nodegroup/cluster-1/nodegroup-2"),
+		InstanceTypes: []*string{awsClient.String("t3.large")},
+	},
+}
+
+var defaultEKSAutoScalingGroupsMock = map[string]*autoscaling.Group{
+	"eks-nodegroup-1": {
+		Instances: []*autoscaling.Instance{
+			{InstanceType: awsClient.String("t3.large"), LifecycleState: awsClient.String(autoscaling.LifecycleStateInService)},
+			{InstanceType: awsClient.String("t3.large"), LifecycleState: awsClient.String(autoscaling.LifecycleStateInService)},
+			{InstanceType: awsClient.String("t3.large"), LifecycleState: awsClient.String(autoscaling.LifecycleStateInService)},
+			{InstanceType: awsClient.String("t3.large"), LifecycleState: awsClient.String(autoscaling.LifecycleStateTerminating)},
+		},
+	},
+	"eks-nodegroup-3": {
+		MixedInstancesPolicy: &autoscaling.MixedInstancesPolicy{
+			InstancesDistribution: &autoscaling.InstancesDistribution{OnDemandPercentageAboveBaseCapacity: awsClient.Int64(0)},
+		},
+		Instances: []*autoscaling.Instance{
+			{InstanceType: awsClient.String("m5.large"), LifecycleState: awsClient.String(autoscaling.LifecycleStateInService)},
+			{InstanceType: awsClient.String("m5a.large"), LifecycleState: awsClient.String(autoscaling.LifecycleStateInService)},
+		},
+	},
+}
+
+type MockAWSEKSClient struct {
+	err error
+}
+
+func (r *MockAWSEKSClient) ListClusters(*eks.ListClustersInput) (*eks.ListClustersOutput, error) {
+	return &eks.ListClustersOutput{Clusters: []*string{awsClient.String("cluster-1")}}, r.err
+}
+
+func (r *MockAWSEKSClient) ListNodegroups(*eks.ListNodegroupsInput) (*eks.ListNodegroupsOutput, error) {
+	return &eks.ListNodegroupsOutput{Nodegroups: []*string{awsClient.String("nodegroup-1"), awsClient.String("nodegroup-2"), awsClient.String("nodegroup-3")}}, r.err
+}
+
+func (r *MockAWSEKSClient) DescribeAutoScalingGroups(input *autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+	groups := []*autoscaling.Group{}
+	for _, name := range input.AutoScalingGroupNames {
+		groups = append(groups, defaultEKSAutoScalingGroupsMock[*name])
+	}
+	return &autoscaling.DescribeAutoScalingGroupsOutput{AutoScalingGroups: groups}, r.err
+}
+
+func (r *MockAWSEKSClient) DescribeNodegroup(input *eks.DescribeNodegroupInput) (*eks.DescribeNodegroupOutput, error) {
+	return &eks.DescribeNodegroupOutput{Nodegroup: defaultEKSNodeGroupsMock[*input.NodegroupName]}, r.err
+}
+
+func TestDetectEKSNodeGroups(t *testing.T) {
+
+	t.Run("detect", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		eksInterface, err := NewEKSNodeGroupsManager(detector, &MockAWSEKSClient{})
+		if err != nil {
+			t.Fatalf("unexpected eks node groups manager error happened, got %v expected %v", err, nil)
+		}
+
+		eksManager, ok := eksInterface.(*EKSNodeGroupsManager)
+		if !ok {
+			t.Fatalf("unexpected eks node groups struct, got %s expected %s", reflect.TypeOf(eksInterface), "*EKSNodeGroupsManager")
+		}
+
+		// The node groups match both metrics, and each node group is reported once
+		metrics := append(append([]config.MetricConfig{}, awsTestutils.DefaultMetricConfig...), awsTestutils.DefaultMetricConfig...)
+		response, err := eksManager.Detect(metrics)
+		if err != nil {
+			t.Fatalf("unexpected eks node groups error happened, got %v expected %v", err, nil)
+		}
+
+		nodeGroupsResponse, ok := response.([]DetectedEKSNodeGroup)
+		if !ok {
+			t.Fatalf("unexpected eks node groups struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedEKSNodeGroup")
+		}
+
+		if len(nodeGroupsResponse) != 2 {
+			t.Fatalf("unexpected eks node groups detected, got %d expected %d", len(nodeGroupsResponse), 2)
+		}
+
+		if len(collector.Events) != 2 {
+			t.Fatalf("unexpected collector eks node groups events, got %d expected %d", len(collector.Events), 2)
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource event collection status count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+
+		nodeGroup := nodeGroupsResponse[0]
+		if nodeGroup.NodeGroupName != "nodegroup-1" || nodeGroup.PricePerHour != 3 {
+			t.Fatalf("unexpected eks node group, got %s priced %v expected %s priced %v", nodeGroup.NodeGroupName, nodeGroup.PricePerHour, "nodegroup-1", 3)
+		}
+
+		if nodeGroup.Tag["team"] != "a" || nodeGroup.InstanceType != "t3.large" || nodeGroup.PriceEstimated {
+			t.Fatalf("unexpected eks node group, got tags %v instance type %q estimated %v", nodeGroup.Tag, nodeGroup.InstanceType, nodeGroup.PriceEstimated)
+		}
+
+		// The launch template node group is priced by its spot autoscaling group instances
+		launchTemplateNodeGroup := nodeGroupsResponse[1]
+		if launchTemplateNodeGroup.NodeGroupName != "nodegroup-3" || launchTemplateNodeGroup.PricePerHour != 2 {
+			t.Fatalf("unexpected eks node group, got %s priced %v expected %s priced %v", launchTemplateNodeGroup.NodeGroupName, launchTemplateNodeGroup.PricePerHour, "nodegroup-3", 2)
+		}
+
+		if launchTemplateNodeGroup.InstanceType != "m5.large,m5a.large" || !launchTemplateNodeGroup.PriceEstimated {
+			t.Fatalf("unexpected eks node group, got instance type %q estimated %v expected %q estimated %v", launchTemplateNodeGroup.InstanceType, launchTemplateNodeGroup.PriceEstimated, "m5.large,m5a.large", true)
+		}
+	})
+
+	t.Run("detection error", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		eksManager, err := NewEKSNodeGroupsManager(detector, &MockAWSEKSClient{err: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected eks node groups manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = eksManager.Detect(awsTestutils.DefaultMetricConfig)
+		if err == nil {
+			t.Fatalf("unexpected list eks clusters error, return empty")
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource event collection status count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+	})
+}
+
+func TestGetEKSNodeGroupPlatform(t *testing.T) {
+
+	testCases := []struct {
+		amiType          *string
+		expectedPlatform string
+		expectedCustom   bool
+	}{
+		{awsClient.String("AL2_x86_64"), "Linux", false},
+		{awsClient.String("WINDOWS_CORE_2019_x86_64"), "windows", false},
+		{awsClient.String("CUSTOM"), "Linux", true},
+		{nil, "Linux", false},
+	}
+
+	for _, test := range testCases {
+		t.Run(awsClient.StringValue(test.amiType), func(t *testing.T) {
+			platform, custom := getEKSNodeGroupPlatform(test.amiType)
+			if platform != test.expectedPlatform || custom != test.expectedCustom {
+				t.Fatalf("unexpected eks node group platform, got %q custom %v expected %q custom %v", platform, custom, test.expectedPlatform, test.expectedCustom)
+			}
+
+			// The windows platform is priced without the license included products
+			var licenseModel string
+			for _, filter := range getEC2PricingFilterInput("AmazonEC2", "t3.large", &platform).Filters {
+				if *filter.Field == "licenseModel" {
+					licenseModel = *filter.Value
+				}
+			}
+			if test.expectedPlatform == "windows" && licenseModel != "No License required" {
+				t.Fatalf("unexpected eks windows node group license model filter, got %q expected %q", licenseModel, "No License required")
+			}
+		})
+	}
+}
@@ -3,10 +3,10 @@ package resources
 import (
 	"errors"
 	"finala/collector"
+	"finala/collector/aws/cloudwatch"
 	"finala/collector/aws/common"
 	"finala/collector/aws/register"
 	"finala/collector/config"
-	"finala/expression"
 	"time"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
@@ -39,6 +39,7 @@ type DetectedElasticache struct {
 	CacheNodeType string
 	CacheNodes    int
 	collector.PriceDetectedFields
+	collector.MetricDetectedFields
 }
 
 func init() {
@@ -85,13 +86,16 @@ func (ec *ElasticacheManager) Detect(metrics []config.MetricConfig) (interface{}
 	}
 
 	now := time.Now()
+	batch := ec.awsManager.GetCloudWatchClient().NewBatch()
 
 	for _, instance := range instances {
+		instance := instance
 		log.WithField("cluster_id", *instance.CacheClusterId).Debug("checking elasticache")
 
 		price, _ := ec.awsManager.GetPricingClient().GetPrice(ec.getPricingFilterInput(instance), "", ec.awsManager.GetRegion())
 
 		for _, metric := range metrics {
+			metric := metric
 			log.WithFields(log.Fields{
 				"cluster_id":  *instance.CacheClusterId,
 				"metric_name": metric.Description,
@@ -113,68 +117,72 @@ func (ec *ElasticacheManager) Detect(metrics []config.MetricConfig) (interface{}
 				},
 			}
 
-			formulaValue, _, err := ec.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
-			if err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"cluster_id":  *instance.CacheClusterId,
-					"metric_name": metric.Description,
-				}).Error("Could not get cloudwatch metric data")
-				continue
-			}
+			batch.Add(&metricInput, metric, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"cluster_id":  *instance.CacheClusterId,
+						"metric_name": metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					return
+				}
 
-			expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
-			if err != nil {
-				continue
-			}
+				expression, err := metric.Constraint.Evaluate(formulaValue, metricsValues)
+				if err != nil {
+					return
+				}
 
-			if expression {
-
-				log.WithFields(log.Fields{
-					"metric_name":         metric.Description,
-					"constraint_operator": metric.Constraint.Operator,
-					"constraint_Value":    metric.Constraint.Value,
-					"formula_value":       formulaValue,
-					"cluster_id":          *instance.CacheClusterId,
-					"node_type":           *instance.CacheNodeType,
-					"region":              ec.awsManager.GetRegion(),
-				}).Info("Elasticache instance detected as unutilized resource")
-
-				tags, err := ec.client.ListTagsForResource(&elasticache.ListTagsForResourceInput{
-					ResourceName: instance.CacheClusterId,
-				})
-
-				tagsData := map[string]string{}
-				if err == nil {
-					for _, tag := range tags.TagList {
-						tagsData[*tag.Key] = *tag.Value
+				if expression {
+
+					log.WithFields(log.Fields{
+						"metric_name":         metric.Description,
+						"constraint_operator": metric.Constraint.Operator,
+						"constraint_Value":    metric.Constraint.Value,
+						"formula_value":       formulaValue,
+						"cluster_id":          *instance.CacheClusterId,
+						"node_type":           *instance.CacheNodeType,
+						"region":              ec.awsManager.GetRegion(),
+					}).Info("Elasticache instance detected as unutilized resource")
+
+					tags, err := ec.client.ListTagsForResource(&elasticache.ListTagsForResourceInput{
+						ResourceName: instance.CacheClusterId,
+					})
+
+					tagsData := map[string]string{}
+					if err == nil {
+						for _, tag := range tags.TagList {
+							tagsData[*tag.Key] = *tag.Value
+						}
 					}
-				}
 
-				es := DetectedElasticache{
-					Region:        ec.awsManager.GetRegion(),
-					Metric:        metric.Description,
-					CacheEngine:   *instance.Engine,
-					CacheNodeType: *instance.CacheNodeType,
-					CacheNodes:    len(instance.CacheNodes),
-					PriceDetectedFields: collector.PriceDetectedFields{
-						LaunchTime:    *instance.CacheClusterCreateTime,
-						ResourceID:    *instance.CacheClusterId,
-						PricePerHour:  price,
-						PricePerMonth: price * collector.TotalMonthHours,
-						Tag:           tagsData,
-					},
-				}
+					es := DetectedElasticache{
+						Region:        ec.awsManager.GetRegion(),
+						Metric:        metric.Description,
+						CacheEngine:   *instance.Engine,
+						CacheNodeType: *instance.CacheNodeType,
+						CacheNodes:    len(instance.CacheNodes),
+						PriceDetectedFields: collector.PriceDetectedFields{
+							LaunchTime:    *instance.CacheClusterCreateTime,
+							ResourceID:    *instance.CacheClusterId,
+							PricePerHour:  price,
+							PricePerMonth: price * collector.TotalMonthHours,
+							Tag:           tagsData,
+						},
+						MetricDetectedFields: cloudwatch.NewMetricDetectedFields(metric, &metricInput, formulaValue, metricsValues),
+					}
 
-				ec.awsManager.GetCollector().AddResource(collector.EventCollector{
-					ResourceName: ec.Name,
-					Data:         es,
-				})
+					ec.awsManager.GetCollector().AddResource(collector.EventCollector{
+						ResourceName: ec.Name,
+						Data:         es,
+					})
 
-				detectedelasticache = append(detectedelasticache, es)
-			}
+					detectedelasticache = append(detectedelasticache, es)
+				}
+			})
 		}
 	}
 
+	batch.Execute()
+
 	ec.awsManager.GetCollector().CollectFinish(ec.Name)
 
 	return detectedelasticache, nil
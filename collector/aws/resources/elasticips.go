@@ -29,12 +29,14 @@ type ElasticIPManager struct {
 
 // DetectedElasticIP defines the detected AWS elastic ip
 type DetectedElasticIP struct {
-	Region        string
-	Metric        string
-	IP            string
-	PricePerHour  float64
-	PricePerMonth float64
-	Tag           map[string]string
+	Region                 string
+	Metric                 string
+	IP                     string
+	PricePerHour           float64
+	PricePerMonth          float64
+	EffectivePricePerHour  float64
+	EffectivePricePerMonth float64
+	Tag                    map[string]string
 }
 
 func init() {
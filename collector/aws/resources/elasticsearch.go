@@ -3,10 +3,10 @@ package resources
 import (
 	"errors"
 	"finala/collector"
+	"finala/collector/aws/cloudwatch"
 	"finala/collector/aws/common"
 	"finala/collector/aws/register"
 	"finala/collector/config"
-	"finala/expression"
 	"finala/interpolation"
 	"time"
 
@@ -46,6 +46,7 @@ type DetectedElasticSearch struct {
 	InstanceType  string
 	InstanceCount int64
 	collector.PriceDetectedFields
+	collector.MetricDetectedFields
 }
 
 // elasticSearchVolumeType will hold the available volume types for ESCluster EBS
@@ -99,8 +100,10 @@ func (esm *ElasticSearchManager) Detect(metrics []config.MetricConfig) (interfac
 	}
 
 	now := time.Now()
+	batch := esm.awsManager.GetCloudWatchClient().NewBatch()
 
 	for _, cluster := range clusters {
+		cluster := cluster
 		log.WithField("cluster_arn", *cluster.ARN).Debug("checking elasticsearch cluster")
 
 		instancePricingFilters := esm.getPricingFilterInput([]*pricing.Filter{
@@ -144,6 +147,7 @@ func (esm *ElasticSearchManager) Detect(metrics []config.MetricConfig) (interfac
 			"region":              esm.awsManager.GetRegion()}).Debug("Found the following price list")
 
 		for _, metric := range metrics {
+			metric := metric
 			log.WithFields(log.Fields{
 				"cluster_arn": *cluster.ARN,
 				"metric_name": metric.Description,
@@ -169,69 +173,73 @@ func (esm *ElasticSearchManager) Detect(metrics []config.MetricConfig) (interfac
 				},
 			}
 
-			formulaValue, _, err := esm.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
-			if err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"cluster_id":  *cluster.ARN,
-					"metric_name": metric.Description,
-				}).Error("Could not get cloudwatch metric data")
-				continue
-			}
-
-			expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
-			if err != nil {
-				log.WithField("error", err).Error("could not parse expression")
-				continue
-			}
-
-			if expression {
-				hourlyClusterPrice := instancePrice*float64(*cluster.ElasticsearchClusterConfig.InstanceCount) + hourlyEBSVolumePrice
-				log.WithFields(log.Fields{
-					"metric_name":         metric.Description,
-					"constraint_operator": metric.Constraint.Operator,
-					"constraint_Value":    metric.Constraint.Value,
-					"formula_value":       formulaValue,
-					"cluster_id":          *cluster.ARN,
-					"node_type":           *cluster.ElasticsearchClusterConfig.InstanceType,
-					"region":              esm.awsManager.GetRegion(),
-				}).Info("ElasticSearch cluster detected as unutilized resource")
-
-				tags, err := esm.client.ListTags(&elasticsearch.ListTagsInput{
-					ARN: cluster.ARN,
-				})
+			batch.Add(&metricInput, metric, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
 				if err != nil {
-					log.WithField("error", err).Error("could not list tags")
-					continue
+					log.WithError(err).WithFields(log.Fields{
+						"cluster_id":  *cluster.ARN,
+						"metric_name": metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					return
 				}
 
-				tagsData := map[string]string{}
-				for _, tag := range tags.TagList {
-					tagsData[*tag.Key] = *tag.Value
+				expression, err := metric.Constraint.Evaluate(formulaValue, metricsValues)
+				if err != nil {
+					log.WithField("error", err).Error("could not parse expression")
+					return
 				}
 
-				elasticsearch := DetectedElasticSearch{
-					Region:        esm.awsManager.GetRegion(),
-					Metric:        metric.Description,
-					InstanceType:  *cluster.ElasticsearchClusterConfig.InstanceType,
-					InstanceCount: *cluster.ElasticsearchClusterConfig.InstanceCount,
-					PriceDetectedFields: collector.PriceDetectedFields{
-						ResourceID:    *cluster.ARN,
-						PricePerHour:  hourlyClusterPrice,
-						PricePerMonth: hourlyClusterPrice * collector.TotalMonthHours,
-						Tag:           tagsData,
-					},
+				if expression {
+					hourlyClusterPrice := instancePrice*float64(*cluster.ElasticsearchClusterConfig.InstanceCount) + hourlyEBSVolumePrice
+					log.WithFields(log.Fields{
+						"metric_name":         metric.Description,
+						"constraint_operator": metric.Constraint.Operator,
+						"constraint_Value":    metric.Constraint.Value,
+						"formula_value":       formulaValue,
+						"cluster_id":          *cluster.ARN,
+						"node_type":           *cluster.ElasticsearchClusterConfig.InstanceType,
+						"region":              esm.awsManager.GetRegion(),
+					}).Info("ElasticSearch cluster detected as unutilized resource")
+
+					tags, err := esm.client.ListTags(&elasticsearch.ListTagsInput{
+						ARN: cluster.ARN,
+					})
+					if err != nil {
+						log.WithField("error", err).Error("could not list tags")
+						return
+					}
+
+					tagsData := map[string]string{}
+					for _, tag := range tags.TagList {
+						tagsData[*tag.Key] = *tag.Value
+					}
+
+					elasticsearch := DetectedElasticSearch{
+						Region:        esm.awsManager.GetRegion(),
+						Metric:        metric.Description,
+						InstanceType:  *cluster.ElasticsearchClusterConfig.InstanceType,
+						InstanceCount: *cluster.ElasticsearchClusterConfig.InstanceCount,
+						PriceDetectedFields: collector.PriceDetectedFields{
+							ResourceID:    *cluster.ARN,
+							PricePerHour:  hourlyClusterPrice,
+							PricePerMonth: hourlyClusterPrice * collector.TotalMonthHours,
+							Tag:           tagsData,
+						},
+						MetricDetectedFields: cloudwatch.NewMetricDetectedFields(metric, &metricInput, formulaValue, metricsValues),
+					}
+
+					esm.awsManager.GetCollector().AddResource(collector.EventCollector{
+						ResourceName: esm.Name,
+						Data:         elasticsearch,
+					})
+
+					detectedElasticSearchClusters = append(detectedElasticSearchClusters, elasticsearch)
 				}
-
-				esm.awsManager.GetCollector().AddResource(collector.EventCollector{
-					ResourceName: esm.Name,
-					Data:         elasticsearch,
-				})
-
-				detectedElasticSearchClusters = append(detectedElasticSearchClusters, elasticsearch)
-			}
+			})
 		}
 	}
 
+	batch.Execute()
+
 	esm.awsManager.GetCollector().CollectFinish(esm.Name)
 
 	return detectedElasticSearchClusters, nil
@@ -3,10 +3,10 @@ package resources
 import (
 	"errors"
 	"finala/collector"
+	"finala/collector/aws/cloudwatch"
 	"finala/collector/aws/common"
 	"finala/collector/aws/register"
 	"finala/collector/config"
-	"finala/expression"
 	"fmt"
 	"time"
 
@@ -37,6 +37,7 @@ type DetectedELB struct {
 	Metric string
 	Region string
 	collector.PriceDetectedFields
+	collector.MetricDetectedFields
 }
 
 func init() {
@@ -92,8 +93,10 @@ func (el *ELBManager) Detect(metrics []config.MetricConfig) (interface{}, error)
 	}
 
 	now := time.Now()
+	batch := el.awsManager.GetCloudWatchClient().NewBatch()
 
 	for _, instance := range instances {
+		instance := instance
 		log.WithField("name", *instance.LoadBalancerName).Debug("checking elb")
 		price, _ := el.awsManager.GetPricingClient().GetPrice(el.getPricingFilterInput([]*pricing.Filter{
 			{
@@ -104,6 +107,7 @@ func (el *ELBManager) Detect(metrics []config.MetricConfig) (interface{}, error)
 		}), "", el.awsManager.GetRegion())
 
 		for _, metric := range metrics {
+			metric := metric
 
 			log.WithFields(log.Fields{
 				"name":        *instance.LoadBalancerName,
@@ -126,70 +130,72 @@ func (el *ELBManager) Detect(metrics []config.MetricConfig) (interface{}, error)
 				},
 			}
 
-			formulaValue, _, err := el.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
+			batch.Add(&metricInput, metric, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"name":        *instance.LoadBalancerName,
+						"metric_name": metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					return
+				}
 
-			if err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"name":        *instance.LoadBalancerName,
-					"metric_name": metric.Description,
-				}).Error("Could not get cloudwatch metric data")
-				continue
-			}
+				expression, err := metric.Constraint.Evaluate(formulaValue, metricsValues)
+				if err != nil {
+					return
+				}
 
-			expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
-			if err != nil {
-				continue
-			}
+				if expression {
 
-			if expression {
-
-				log.WithFields(log.Fields{
-					"metric_name":         metric.Description,
-					"constraint_operator": metric.Constraint.Operator,
-					"constraint_Value":    metric.Constraint.Value,
-					"formula_value":       formulaValue,
-					"name":                *instance.LoadBalancerName,
-					"region":              el.awsManager.GetRegion(),
-				}).Info("LoadBalancer detected as unutilized resource")
-
-				tags, err := el.client.DescribeTags(&elb.DescribeTagsInput{
-					LoadBalancerNames: []*string{instance.LoadBalancerName},
-				})
-
-				tagsData := map[string]string{}
-				if err == nil {
-					for _, tags := range tags.TagDescriptions {
-						for _, tag := range tags.Tags {
-							tagsData[*tag.Key] = *tag.Value
-						}
+					log.WithFields(log.Fields{
+						"metric_name":         metric.Description,
+						"constraint_operator": metric.Constraint.Operator,
+						"constraint_Value":    metric.Constraint.Value,
+						"formula_value":       formulaValue,
+						"name":                *instance.LoadBalancerName,
+						"region":              el.awsManager.GetRegion(),
+					}).Info("LoadBalancer detected as unutilized resource")
 
-					}
-				}
+					tags, err := el.client.DescribeTags(&elb.DescribeTagsInput{
+						LoadBalancerNames: []*string{instance.LoadBalancerName},
+					})
 
-				elb := DetectedELB{
-					Region: el.awsManager.GetRegion(),
-					Metric: metric.Description,
-					PriceDetectedFields: collector.PriceDetectedFields{
-						ResourceID:    *instance.LoadBalancerName,
-						LaunchTime:    *instance.CreatedTime,
-						PricePerHour:  price,
-						PricePerMonth: price * collector.TotalMonthHours,
-						Tag:           tagsData,
-					},
-				}
+					tagsData := map[string]string{}
+					if err == nil {
+						for _, tags := range tags.TagDescriptions {
+							for _, tag := range tags.Tags {
+								tagsData[*tag.Key] = *tag.Value
+							}
+
+						}
+					}
 
-				el.awsManager.GetCollector().AddResource(collector.EventCollector{
-					ResourceName: el.Name,
-					Data:         elb,
-				})
+					elb := DetectedELB{
+						Region: el.awsManager.GetRegion(),
+						Metric: metric.Description,
+						PriceDetectedFields: collector.PriceDetectedFields{
+							ResourceID:    *instance.LoadBalancerName,
+							LaunchTime:    *instance.CreatedTime,
+							PricePerHour:  price,
+							PricePerMonth: price * collector.TotalMonthHours,
+							Tag:           tagsData,
+						},
+						MetricDetectedFields: cloudwatch.NewMetricDetectedFields(metric, &metricInput, formulaValue, metricsValues),
+					}
 
-				detectedELB = append(detectedELB, elb)
+					el.awsManager.GetCollector().AddResource(collector.EventCollector{
+						ResourceName: el.Name,
+						Data:         elb,
+					})
 
-			}
+					detectedELB = append(detectedELB, elb)
 
+				}
+			})
 		}
 	}
 
+	batch.Execute()
+
 	el.awsManager.GetCollector().CollectFinish(el.Name)
 
 	return detectedELB, nil
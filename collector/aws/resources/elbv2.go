@@ -3,10 +3,10 @@ package resources
 import (
 	"errors"
 	"finala/collector"
+	"finala/collector/aws/cloudwatch"
 	"finala/collector/aws/common"
 	"finala/collector/aws/register"
 	"finala/collector/config"
-	"finala/expression"
 	"fmt"
 	"regexp"
 	"time"
@@ -38,6 +38,7 @@ type DetectedELBV2 struct {
 	Region string
 	Type   string
 	collector.PriceDetectedFields
+	collector.MetricDetectedFields
 }
 
 // loadBalancerConfig defines loadbalancer's configuration of metrics and pricing
@@ -123,8 +124,10 @@ func (el *ELBV2Manager) Detect(metrics []config.MetricConfig) (interface{}, erro
 	}
 
 	now := time.Now()
+	batch := el.awsManager.GetCloudWatchClient().NewBatch()
 
 	for _, instance := range instances {
+		instance := instance
 		var cloudWatchNameSpace string
 		var price float64
 		if loadBalancerConfig, found := loadBalancersConfig[*instance.Type]; found {
@@ -141,6 +144,7 @@ func (el *ELBV2Manager) Detect(metrics []config.MetricConfig) (interface{}, erro
 			price, _ = el.awsManager.GetPricingClient().GetPrice(el.getPricingFilterInput(loadBalancerConfig.pricingfilters), "", el.awsManager.GetRegion())
 		}
 		for _, metric := range metrics {
+			metric := metric
 
 			log.WithFields(log.Fields{
 				"name":        *instance.LoadBalancerName,
@@ -169,68 +173,71 @@ func (el *ELBV2Manager) Detect(metrics []config.MetricConfig) (interface{}, erro
 				},
 			}
 
-			formulaValue, _, err := el.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
-
-			if err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"name":        *instance.LoadBalancerName,
-					"metric_name": metric.Description,
-				}).Error("Could not get cloudwatch metric data")
-				continue
-			}
-
-			expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
-			if err != nil {
-				continue
-			}
+			batch.Add(&metricInput, metric, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"name":        *instance.LoadBalancerName,
+						"metric_name": metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					return
+				}
 
-			if expression {
+				expression, err := metric.Constraint.Evaluate(formulaValue, metricsValues)
+				if err != nil {
+					return
+				}
 
-				log.WithFields(log.Fields{
-					"metric_name":         metric.Description,
-					"constraint_operator": metric.Constraint.Operator,
-					"constraint_Value":    metric.Constraint.Value,
-					"formula_value":       formulaValue,
-					"name":                *instance.LoadBalancerName,
-					"region":              el.awsManager.GetRegion(),
-				}).Info("LoadBalancer detected as unutilized resource")
+				if expression {
+
+					log.WithFields(log.Fields{
+						"metric_name":         metric.Description,
+						"constraint_operator": metric.Constraint.Operator,
+						"constraint_Value":    metric.Constraint.Value,
+						"formula_value":       formulaValue,
+						"name":                *instance.LoadBalancerName,
+						"region":              el.awsManager.GetRegion(),
+					}).Info("LoadBalancer detected as unutilized resource")
+
+					tags, err := el.client.DescribeTags(&elbv2.DescribeTagsInput{
+						ResourceArns: []*string{instance.LoadBalancerArn},
+					})
+					tagsData := map[string]string{}
+					if err == nil {
+						for _, tags := range tags.TagDescriptions {
+							for _, tag := range tags.Tags {
+								tagsData[*tag.Key] = *tag.Value
+							}
 
-				tags, err := el.client.DescribeTags(&elbv2.DescribeTagsInput{
-					ResourceArns: []*string{instance.LoadBalancerArn},
-				})
-				tagsData := map[string]string{}
-				if err == nil {
-					for _, tags := range tags.TagDescriptions {
-						for _, tag := range tags.Tags {
-							tagsData[*tag.Key] = *tag.Value
 						}
-
 					}
-				}
 
-				elbv2 := DetectedELBV2{
-					Region: el.awsManager.GetRegion(),
-					Metric: metric.Description,
-					Type:   *instance.Type,
-					PriceDetectedFields: collector.PriceDetectedFields{
-						ResourceID:    *instance.LoadBalancerName,
-						LaunchTime:    *instance.CreatedTime,
-						PricePerHour:  price,
-						PricePerMonth: price * collector.TotalMonthHours,
-						Tag:           tagsData,
-					},
-				}
+					elbv2 := DetectedELBV2{
+						Region: el.awsManager.GetRegion(),
+						Metric: metric.Description,
+						Type:   *instance.Type,
+						PriceDetectedFields: collector.PriceDetectedFields{
+							ResourceID:    *instance.LoadBalancerName,
+							LaunchTime:    *instance.CreatedTime,
+							PricePerHour:  price,
+							PricePerMonth: price * collector.TotalMonthHours,
+							Tag:           tagsData,
+						},
+						MetricDetectedFields: cloudwatch.NewMetricDetectedFields(metric, &metricInput, formulaValue, metricsValues),
+					}
 
-				el.awsManager.GetCollector().AddResource(collector.EventCollector{
-					ResourceName: el.Name,
-					Data:         elbv2,
-				})
+					el.awsManager.GetCollector().AddResource(collector.EventCollector{
+						ResourceName: el.Name,
+						Data:         elbv2,
+					})
 
-				detectedELBV2 = append(detectedELBV2, elbv2)
-			}
+					detectedELBV2 = append(detectedELBV2, elbv2)
+				}
+			})
 		}
 	}
 
+	batch.Execute()
+
 	el.awsManager.GetCollector().CollectFinish(el.Name)
 
 	return detectedELBV2, nil
@@ -0,0 +1,402 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/cloudwatch"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/emr"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	log "github.com/sirupsen/logrus"
+)
+
+// EMRClientDescreptor is an interface defining the aws emr client
+type EMRClientDescreptor interface {
+	ListClusters(*emr.ListClustersInput) (*emr.ListClustersOutput, error)
+	DescribeCluster(*emr.DescribeClusterInput) (*emr.DescribeClusterOutput, error)
+	ListInstances(*emr.ListInstancesInput) (*emr.ListInstancesOutput, error)
+	ListSteps(*emr.ListStepsInput) (*emr.ListStepsOutput, error)
+}
+
+// EMRManager describes the EMR clusters struct
+type EMRManager struct {
+	client                EMRClientDescreptor
+	awsManager            common.AWSManager
+	namespace             string
+	servicePricingCode    string
+	ec2ServicePricingCode string
+	Name                  collector.ResourceIdentifier
+}
+
+// DetectedEMR defines the detected AWS EMR clusters
+type DetectedEMR struct {
+	Region         string
+	Metric         string
+	Name           string
+	State          string
+	InstancesCount int
+	collector.PriceDetectedFields
+	collector.MetricDetectedFields
+}
+
+// emrCluster describes a running EMR cluster and its hourly price
+type emrCluster struct {
+	summary        *emr.ClusterSummary
+	instancesCount int
+	pricePerHour   float64
+	tags           map[string]string
+}
+
+func init() {
+	register.Registry("emr", NewEMRManager)
+}
+
+// NewEMRManager implements AWS GO SDK
+func NewEMRManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = emr.New(awsManager.GetSession())
+	}
+
+	emrClient, ok := client.(EMRClientDescreptor)
+	if !ok {
+		return nil, errors.New("invalid emr client")
+	}
+
+	return &EMRManager{
+		client:                emrClient,
+		awsManager:            awsManager,
+		namespace:             "AWS/ElasticMapReduce",
+		servicePricingCode:    "ElasticMapReduce",
+		ec2ServicePricingCode: "AmazonEC2",
+		Name:                  awsManager.GetResourceIdentifier("emr"),
+	}, nil
+}
+
+// Detect check which running EMR clusters are idle. A metric with cloudwatch metrics (for example IsIdle) is checked
+// against the cluster metrics, a metric without cloudwatch metrics is checked against the days since the last step was
+// submitted to the cluster. A cluster is reported once, by the first matched metric. The cluster cost is the EC2 and the
+// EMR price of all its instance groups and fleets instances
+func (em *EMRManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	log.WithFields(log.Fields{
+		"region":   em.awsManager.GetRegion(),
+		"resource": "emr",
+	}).Info("starting to analyze resource")
+
+	em.awsManager.GetCollector().CollectStart(em.Name)
+
+	detected := []DetectedEMR{}
+
+	clusters, err := em.listClusters(nil, nil)
+	if err != nil {
+		log.WithError(err).Error("could not list emr clusters")
+		em.awsManager.GetCollector().CollectError(em.Name, err)
+		return detected, err
+	}
+
+	// The hourly price of each instance type, the instance types are shared between the clusters instance groups
+	instanceTypePrices := map[string]float64{}
+
+	// The reported clusters ids, a cluster that matches several metrics is reported once. The batch handlers are
+	// executed sequentially, so the map is shared between them
+	reported := map[string]struct{}{}
+
+	now := time.Now()
+	batch := em.awsManager.GetCloudWatchClient().NewBatch()
+
+	for _, summary := range clusters {
+		log.WithField("cluster_id", *summary.Id).Debug("checking emr cluster")
+
+		cluster, err := em.describeCluster(summary, instanceTypePrices)
+		if err != nil {
+			log.WithError(err).WithField("cluster_id", *summary.Id).Error("could not describe emr cluster")
+			continue
+		}
+
+		for _, metric := range metrics {
+			metric := metric
+
+			if len(metric.Data) == 0 {
+				if _, found := reported[*summary.Id]; found {
+					continue
+				}
+
+				idleDays, err := em.getDaysSinceLastStep(cluster, now)
+				if err != nil {
+					log.WithError(err).WithField("cluster_id", *summary.Id).Error("could not list emr cluster steps")
+					continue
+				}
+
+				expression, err := metric.Constraint.Evaluate(idleDays, nil)
+				if err != nil || !expression {
+					continue
+				}
+
+				em.addDetectedCluster(cluster, metric, collector.MetricDetectedFields{}, &detected)
+				reported[*summary.Id] = struct{}{}
+				continue
+			}
+
+			period := int64(metric.Period.Seconds())
+			metricEndTime := now.Add(time.Duration(-metric.StartTime))
+			metricInput := awsCloudwatch.GetMetricStatisticsInput{
+				Namespace:  &em.namespace,
+				MetricName: &metric.Description,
+				Period:     &period,
+				StartTime:  &metricEndTime,
+				EndTime:    &now,
+				Dimensions: []*awsCloudwatch.Dimension{
+					{
+						Name:  awsClient.String("JobFlowId"),
+						Value: summary.Id,
+					},
+				},
+			}
+
+			batch.Add(&metricInput, metric, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"cluster_id":  *cluster.summary.Id,
+						"metric_name": metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					return
+				}
+
+				if _, found := reported[*cluster.summary.Id]; found {
+					return
+				}
+
+				expression, err := metric.Constraint.Evaluate(formulaValue, metricsValues)
+				if err != nil || !expression {
+					return
+				}
+
+				log.WithFields(log.Fields{
+					"metric_name":         metric.Description,
+					"constraint_operator": metric.Constraint.Operator,
+					"constraint_Value":    metric.Constraint.Value,
+					"formula_value":       formulaValue,
+				}).Debug("emr cluster metric constraint matched")
+
+				em.addDetectedCluster(cluster, metric, cloudwatch.NewMetricDetectedFields(metric, &metricInput, formulaValue, metricsValues), &detected)
+				reported[*cluster.summary.Id] = struct{}{}
+			})
+		}
+	}
+
+	batch.Execute()
+
+	em.awsManager.GetCollector().CollectFinish(em.Name)
+
+	return detected, nil
+}
+
+// addDetectedCluster adds the idle cluster to the collector and to the detected clusters
+func (em *EMRManager) addDetectedCluster(cluster *emrCluster, metric config.MetricConfig, metricFields collector.MetricDetectedFields, detected *[]DetectedEMR) {
+
+	log.WithFields(log.Fields{
+		"metric_name": metric.Description,
+		"cluster_id":  *cluster.summary.Id,
+		"region":      em.awsManager.GetRegion(),
+	}).Info("EMR cluster detected as unutilized resource")
+
+	var state string
+	var launchTime time.Time
+	if cluster.summary.Status != nil {
+		state = awsClient.StringValue(cluster.summary.Status.State)
+		if cluster.summary.Status.Timeline != nil {
+			launchTime = awsClient.TimeValue(cluster.summary.Status.Timeline.CreationDateTime)
+		}
+	}
+
+	clusterData := DetectedEMR{
+		Region:         em.awsManager.GetRegion(),
+		Metric:         metric.Description,
+		Name:           awsClient.StringValue(cluster.summary.Name),
+		State:          state,
+		InstancesCount: cluster.instancesCount,
+		PriceDetectedFields: collector.PriceDetectedFields{
+			ResourceID:    *cluster.summary.Id,
+			LaunchTime:    launchTime,
+			PricePerHour:  cluster.pricePerHour,
+			PricePerMonth: cluster.pricePerHour * collector.TotalMonthHours,
+			Tag:           cluster.tags,
+		},
+		MetricDetectedFields: metricFields,
+	}
+
+	em.awsManager.GetCollector().AddResource(collector.EventCollector{
+		ResourceName: em.Name,
+		Data:         clusterData,
+	})
+
+	*detected = append(*detected, clusterData)
+}
+
+// describeCluster returns the cluster tags and the hourly price of its running instances
+func (em *EMRManager) describeCluster(summary *emr.ClusterSummary, instanceTypePrices map[string]float64) (*emrCluster, error) {
+
+	resp, err := em.client.DescribeCluster(&emr.DescribeClusterInput{
+		ClusterId: summary.Id,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cluster := &emrCluster{
+		summary: summary,
+		tags:    map[string]string{},
+	}
+
+	if resp.Cluster != nil {
+		for _, tag := range resp.Cluster.Tags {
+			cluster.tags[*tag.Key] = *tag.Value
+		}
+	}
+
+	input := &emr.ListInstancesInput{
+		ClusterId:      summary.Id,
+		InstanceStates: []*string{awsClient.String(emr.InstanceStateRunning)},
+	}
+
+	for {
+		instances, err := em.client.ListInstances(input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, instance := range instances.Instances {
+			instanceType := awsClient.StringValue(instance.InstanceType)
+
+			price, ok := instanceTypePrices[instanceType]
+			if !ok {
+				price = em.getInstanceTypePrice(instanceType)
+				instanceTypePrices[instanceType] = price
+			}
+
+			cluster.instancesCount++
+			cluster.pricePerHour += price
+		}
+
+		if instances.Marker == nil {
+			break
+		}
+		input.Marker = instances.Marker
+	}
+
+	return cluster, nil
+}
+
+// getInstanceTypePrice returns the hourly price of an EMR instance, the EC2 instance price and the EMR uplift
+func (em *EMRManager) getInstanceTypePrice(instanceType string) float64 {
+
+	var price float64
+
+	ec2PricingFilters := getEC2PricingFilterInput(em.ec2ServicePricingCode, instanceType, nil)
+	ec2Price, err := em.awsManager.GetPricingClient().GetPrice(ec2PricingFilters, "", em.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"instance_type": instanceType,
+			"price_filters": ec2PricingFilters,
+		}).Error("could not get emr instance ec2 price")
+	}
+	price += ec2Price
+
+	emrPricingFilters := em.getPricingFilterInput(instanceType)
+	emrPrice, err := em.awsManager.GetPricingClient().GetPrice(emrPricingFilters, "", em.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"instance_type": instanceType,
+			"price_filters": emrPricingFilters,
+		}).Error("could not get emr instance price")
+	}
+	price += emrPrice
+
+	return price
+}
+
+// getDaysSinceLastStep returns the days since the last step was submitted to the cluster, or since the cluster was
+// ready when no steps were submitted
+func (em *EMRManager) getDaysSinceLastStep(cluster *emrCluster, now time.Time) (float64, error) {
+
+	// ListSteps returns the steps in reverse order of submission, so the last submitted step is the first step of the
+	// first page and the next pages are not requested
+	resp, err := em.client.ListSteps(&emr.ListStepsInput{
+		ClusterId: cluster.summary.Id,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var lastStepTime time.Time
+	if len(resp.Steps) > 0 && resp.Steps[0].Status != nil && resp.Steps[0].Status.Timeline != nil {
+		lastStepTime = awsClient.TimeValue(resp.Steps[0].Status.Timeline.CreationDateTime)
+	} else if cluster.summary.Status != nil && cluster.summary.Status.Timeline != nil {
+		lastStepTime = awsClient.TimeValue(cluster.summary.Status.Timeline.ReadyDateTime)
+		if lastStepTime.IsZero() {
+			lastStepTime = awsClient.TimeValue(cluster.summary.Status.Timeline.CreationDateTime)
+		}
+	}
+
+	return now.Sub(lastStepTime).Hours() / 24, nil
+}
+
+// getPricingFilterInput prepares the hourly EMR uplift price filter of an instance type
+func (em *EMRManager) getPricingFilterInput(instanceType string) pricing.GetProductsInput {
+	return pricing.GetProductsInput{
+		ServiceCode: &em.servicePricingCode,
+		Filters: []*pricing.Filter{
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("termType"),
+				Value: awsClient.String("OnDemand"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("softwareType"),
+				Value: awsClient.String("EMR"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("instanceType"),
+				Value: &instanceType,
+			},
+		},
+	}
+}
+
+// listClusters returns the running and waiting EMR clusters
+func (em *EMRManager) listClusters(marker *string, clusters []*emr.ClusterSummary) ([]*emr.ClusterSummary, error) {
+
+	input := &emr.ListClustersInput{
+		Marker: marker,
+		ClusterStates: []*string{
+			awsClient.String(emr.ClusterStateRunning),
+			awsClient.String(emr.ClusterStateWaiting),
+		},
+	}
+
+	resp, err := em.client.ListClusters(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if clusters == nil {
+		clusters = []*emr.ClusterSummary{}
+	}
+
+	clusters = append(clusters, resp.Clusters...)
+
+	if resp.Marker != nil {
+		return em.listClusters(resp.Marker, clusters)
+	}
+
+	return clusters, nil
+}
@@ -0,0 +1,182 @@
+package resources
+
+import (
+	"errors"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	collectorTestutils "finala/collector/testutils"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/emr"
+)
+
+var defaultEMRClustersMock = emr.ListClustersOutput{
+	Clusters: []*emr.ClusterSummary{
+		{
+			// Without steps, ready 10 days ago
+			Id:   awsClient.String("j-1"),
+			Name: awsClient.String("cluster-1"),
+			Status: &emr.ClusterStatus{
+				State: awsClient.String(emr.ClusterStateWaiting),
+				Timeline: &emr.ClusterTimeline{
+					CreationDateTime: collectorTestutils.TimePointer(time.Now().AddDate(0, 0, -10)),
+					ReadyDateTime:    collectorTestutils.TimePointer(time.Now().AddDate(0, 0, -10)),
+				},
+			},
+		},
+		{
+			// Step submitted yesterday
+			Id:   awsClient.String("j-2"),
+			Name: awsClient.String("cluster-2"),
+			Status: &emr.ClusterStatus{
+				State: awsClient.String(emr.ClusterStateRunning),
+				Timeline: &emr.ClusterTimeline{
+					CreationDateTime: collectorTestutils.TimePointer(time.Now().AddDate(0, 0, -10)),
+					ReadyDateTime:    collectorTestutils.TimePointer(time.Now().AddDate(0, 0, -10)),
+				},
+			},
+		},
+	},
+}
+
+var defaultEMRStepsMock = map[string][]*emr.StepSummary{
+	"j-2": {
+		{
+			Id: awsClient.String("s-1"),
+			Status: &emr.StepStatus{
+				Timeline: &emr.StepTimeline{
+					CreationDateTime: collectorTestutils.TimePointer(time.Now().AddDate(0, 0, -1)),
+				},
+			},
+		},
+	},
+}
+
+type MockAWSEMRClient struct {
+	err error
+}
+
+func (r *MockAWSEMRClient) ListClusters(*emr.ListClustersInput) (*emr.ListClustersOutput, error) {
+	return &defaultEMRClustersMock, r.err
+}
+
+func (r *MockAWSEMRClient) DescribeCluster(input *emr.DescribeClusterInput) (*emr.DescribeClusterOutput, error) {
+	return &emr.DescribeClusterOutput{
+		Cluster: &emr.Cluster{
+			Id:   input.ClusterId,
+			Tags: []*emr.Tag{{Key: awsClient.String("team"), Value: awsClient.String("a")}},
+		},
+	}, r.err
+}
+
+func (r *MockAWSEMRClient) ListInstances(*emr.ListInstancesInput) (*emr.ListInstancesOutput, error) {
+	return &emr.ListInstancesOutput{
+		Instances: []*emr.Instance{
+			{InstanceType: awsClient.String("m5.xlarge"), InstanceGroupId: awsClient.String("ig-1")},
+			{InstanceType: awsClient.String("m5.xlarge"), InstanceFleetId: awsClient.String("if-1")},
+		},
+	}, r.err
+}
+
+func (r *MockAWSEMRClient) ListSteps(input *emr.ListStepsInput) (*emr.ListStepsOutput, error) {
+	return &emr.ListStepsOutput{Steps: defaultEMRStepsMock[*input.ClusterId]}, r.err
+}
+
+func TestDetectEMR(t *testing.T) {
+
+	metrics := append([]config.MetricConfig{
+		{
+			Description: "No steps submitted",
+			Constraint: config.MetricConstraintConfig{
+				Operator: ">=",
+				Value:    7,
+			},
+		},
+	}, awsTestutils.DefaultMetricConfig...)
+
+	t.Run("detect", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		emrInterface, err := NewEMRManager(detector, &MockAWSEMRClient{})
+		if err != nil {
+			t.Fatalf("unexpected emr manager error happened, got %v expected %v", err, nil)
+		}
+
+		emrManager, ok := emrInterface.(*EMRManager)
+		if !ok {
+			t.Fatalf("unexpected emr struct, got %s expected %s", reflect.TypeOf(emrInterface), "*EMRManager")
+		}
+
+		response, err := emrManager.Detect(metrics)
+		if err != nil {
+			t.Fatalf("unexpected emr error happened, got %v expected %v", err, nil)
+		}
+
+		emrResponse, ok := response.([]DetectedEMR)
+		if !ok {
+			t.Fatalf("unexpected emr struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedEMR")
+		}
+
+		if len(emrResponse) != 2 {
+			t.Fatalf("unexpected emr clusters detected, got %d expected %d", len(emrResponse), 2)
+		}
+
+		if len(collector.Events) != 2 {
+			t.Fatalf("unexpected collector emr events, got %d expected %d", len(collector.Events), 2)
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource event collection status count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+
+		expected := []struct {
+			id     string
+			metric string
+		}{
+			// j-1 matches both metrics and is reported once, by the steps metric
+			{"j-1", "No steps submitted"},
+			{"j-2", ""},
+		}
+		for i, cluster := range emrResponse {
+			if cluster.ResourceID != expected[i].id || cluster.Metric != expected[i].metric {
+				t.Fatalf("unexpected emr cluster, got %s detected by %q expected %s detected by %q", cluster.ResourceID, cluster.Metric, expected[i].id, expected[i].metric)
+			}
+
+			// 2 instances, each is priced by the ec2 and the emr price
+			if cluster.InstancesCount != 2 || cluster.PricePerHour != 4 {
+				t.Fatalf("unexpected emr cluster price, got %v for %d instances expected %v for %d", cluster.PricePerHour, cluster.InstancesCount, 4, 2)
+			}
+
+			if cluster.Tag["team"] != "a" {
+				t.Fatalf("unexpected emr cluster tags, got %v", cluster.Tag)
+			}
+		}
+	})
+
+	t.Run("detection error", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		emrManager, err := NewEMRManager(detector, &MockAWSEMRClient{err: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected emr manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = emrManager.Detect(metrics)
+		if err == nil {
+			t.Fatalf("unexpected list emr clusters error, return empty")
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource event collection status count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+	})
+}
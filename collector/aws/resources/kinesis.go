@@ -3,10 +3,10 @@ package resources
 import (
 	"errors"
 	"finala/collector"
+	"finala/collector/aws/cloudwatch"
 	"finala/collector/aws/common"
 	"finala/collector/aws/register"
 	"finala/collector/config"
-	"finala/expression"
 	"time"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
@@ -38,6 +38,7 @@ type DetectedKinesis struct {
 	Metric string
 	Region string
 	collector.PriceDetectedFields
+	collector.MetricDetectedFields
 }
 
 func init() {
@@ -113,9 +114,12 @@ func (km *KinesisManager) Detect(metrics []config.MetricConfig) (interface{}, er
 		"region":                              km.awsManager.GetRegion()}).Info("Found the following price list")
 
 	now := time.Now()
+	batch := km.awsManager.GetCloudWatchClient().NewBatch()
 	for _, stream := range streams {
+		stream := stream
 		log.WithField("stream_name", *stream.StreamName).Debug("checking kinesis stearm")
 		for _, metric := range metrics {
+			metric := metric
 
 			log.WithFields(log.Fields{
 				"name":        *stream.StreamName,
@@ -138,72 +142,76 @@ func (km *KinesisManager) Detect(metrics []config.MetricConfig) (interface{}, er
 				},
 			}
 
-			metricResponse, _, err := km.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
-			if err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"name":        *stream.StreamName,
-					"metric_name": metric.Description,
-				}).Error("Could not get cloudwatch metric data")
-				continue
-			}
+			batch.Add(&metricInput, metric, func(metricResponse float64, metricsValues map[string]interface{}, err error) {
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"name":        *stream.StreamName,
+						"metric_name": metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					return
+				}
 
-			expression, err := expression.BoolExpression(metricResponse, metric.Constraint.Value, metric.Constraint.Operator)
-			if err != nil {
-				continue
-			}
+				expression, err := metric.Constraint.Evaluate(metricResponse, metricsValues)
+				if err != nil {
+					return
+				}
 
-			if expression {
-
-				log.WithFields(log.Fields{
-					"metric_name":         metric.Description,
-					"constraint_operator": metric.Constraint.Operator,
-					"constraint_Value":    metric.Constraint.Value,
-					"metric_response":     metricResponse,
-					"name":                *stream.StreamName,
-					"region":              km.awsManager.GetRegion(),
-				}).Info("Kinesis stream was detected as unutilized resource")
-
-				tags, err := km.client.ListTagsForStream(&kinesis.ListTagsForStreamInput{
-					StreamName: stream.StreamName,
-				})
-
-				tagsData := map[string]string{}
-				if err == nil {
-					for _, tag := range tags.Tags {
-						tagsData[*tag.Key] = *tag.Value
+				if expression {
+
+					log.WithFields(log.Fields{
+						"metric_name":         metric.Description,
+						"constraint_operator": metric.Constraint.Operator,
+						"constraint_Value":    metric.Constraint.Value,
+						"metric_response":     metricResponse,
+						"name":                *stream.StreamName,
+						"region":              km.awsManager.GetRegion(),
+					}).Info("Kinesis stream was detected as unutilized resource")
+
+					tags, err := km.client.ListTagsForStream(&kinesis.ListTagsForStreamInput{
+						StreamName: stream.StreamName,
+					})
+
+					tagsData := map[string]string{}
+					if err == nil {
+						for _, tag := range tags.Tags {
+							tagsData[*tag.Key] = *tag.Value
+						}
 					}
-				}
 
-				// AWS Kinesis charges for extended data retention bigger than the deafult
-				// which is 24 Hours
-				var finalExtendedRetentionPrice float64
-				if *stream.RetentionPeriodHours > int64(24) {
-					finalExtendedRetentionPrice = extendedRetentionPrice
-				}
+					// AWS Kinesis charges for extended data retention bigger than the deafult
+					// which is 24 Hours
+					var finalExtendedRetentionPrice float64
+					if *stream.RetentionPeriodHours > int64(24) {
+						finalExtendedRetentionPrice = extendedRetentionPrice
+					}
 
-				totalShardsPerHourPrice := (shardPrice + finalExtendedRetentionPrice) * float64(len(stream.Shards))
-
-				stream := DetectedKinesis{
-					Region: km.awsManager.GetRegion(),
-					Metric: metric.Description,
-					PriceDetectedFields: collector.PriceDetectedFields{
-						ResourceID:    *stream.StreamName,
-						LaunchTime:    *stream.StreamCreationTimestamp,
-						PricePerHour:  totalShardsPerHourPrice,
-						PricePerMonth: totalShardsPerHourPrice * collector.TotalMonthHours,
-						Tag:           tagsData,
-					},
-				}
+					totalShardsPerHourPrice := (shardPrice + finalExtendedRetentionPrice) * float64(len(stream.Shards))
+
+					stream := DetectedKinesis{
+						Region: km.awsManager.GetRegion(),
+						Metric: metric.Description,
+						PriceDetectedFields: collector.PriceDetectedFields{
+							ResourceID:    *stream.StreamName,
+							LaunchTime:    *stream.StreamCreationTimestamp,
+							PricePerHour:  totalShardsPerHourPrice,
+							PricePerMonth: totalShardsPerHourPrice * collector.TotalMonthHours,
+							Tag:           tagsData,
+						},
+						MetricDetectedFields: cloudwatch.NewMetricDetectedFields(metric, &metricInput, metricResponse, metricsValues),
+					}
 
-				km.awsManager.GetCollector().AddResource(collector.EventCollector{
-					ResourceName: collector.ResourceIdentifier(km.Name),
-					Data:         stream,
-				})
+					km.awsManager.GetCollector().AddResource(collector.EventCollector{
+						ResourceName: collector.ResourceIdentifier(km.Name),
+						Data:         stream,
+					})
 
-				detectedStreams = append(detectedStreams, stream)
-			}
+					detectedStreams = append(detectedStreams, stream)
+				}
+			})
 		}
 	}
+	batch.Execute()
+
 	km.awsManager.GetCollector().CollectFinish(km.Name)
 	return detectedStreams, nil
 }
@@ -3,10 +3,10 @@ package resources
 import (
 	"errors"
 	"finala/collector"
+	"finala/collector/aws/cloudwatch"
 	"finala/collector/aws/common"
 	"finala/collector/aws/register"
 	"finala/collector/config"
-	"finala/expression"
 	"time"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
@@ -36,6 +36,7 @@ type DetectedAWSLambda struct {
 	ResourceID string
 	Name       string
 	Tag        map[string]string
+	collector.MetricDetectedFields
 }
 
 func init() {
@@ -81,11 +82,14 @@ func (lm *LambdaManager) Detect(metrics []config.MetricConfig) (interface{}, err
 	}
 
 	now := time.Now()
+	batch := lm.awsManager.GetCloudWatchClient().NewBatch()
 	for _, fun := range functions {
+		fun := fun
 
 		log.WithField("name", *fun.FunctionName).Debug("checking lambda")
 
 		for _, metric := range metrics {
+			metric := metric
 			log.WithFields(log.Fields{
 				"name":        *fun.FunctionName,
 				"metric_name": metric.Description,
@@ -106,61 +110,64 @@ func (lm *LambdaManager) Detect(metrics []config.MetricConfig) (interface{}, err
 				},
 			}
 
-			formulaValue, _, err := lm.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
-
-			if err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"name":        *fun.FunctionName,
-					"metric_name": metric.Description,
-				}).Error("Could not get cloudwatch metric data")
-				continue
-			}
+			batch.Add(&metricInput, metric, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"name":        *fun.FunctionName,
+						"metric_name": metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					return
+				}
 
-			expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
-			if err != nil {
-				continue
-			}
+				expression, err := metric.Constraint.Evaluate(formulaValue, metricsValues)
+				if err != nil {
+					return
+				}
 
-			if expression {
-
-				log.WithFields(log.Fields{
-					"metric_name":         metric.Description,
-					"constraint_operator": metric.Constraint.Operator,
-					"constraint_Value":    metric.Constraint.Value,
-					"formula_value":       formulaValue,
-					"name":                *fun.FunctionName,
-					"region":              lm.awsManager.GetRegion(),
-				}).Info("Lambda function detected as unutilized resource")
-
-				tags, err := lm.client.ListTags(&lambda.ListTagsInput{
-					Resource: fun.FunctionArn,
-				})
-
-				tagsData := map[string]string{}
-				if err == nil {
-					for key, value := range tags.Tags {
-						tagsData[key] = *value
+				if expression {
+
+					log.WithFields(log.Fields{
+						"metric_name":         metric.Description,
+						"constraint_operator": metric.Constraint.Operator,
+						"constraint_Value":    metric.Constraint.Value,
+						"formula_value":       formulaValue,
+						"name":                *fun.FunctionName,
+						"region":              lm.awsManager.GetRegion(),
+					}).Info("Lambda function detected as unutilized resource")
+
+					tags, err := lm.client.ListTags(&lambda.ListTagsInput{
+						Resource: fun.FunctionArn,
+					})
+
+					tagsData := map[string]string{}
+					if err == nil {
+						for key, value := range tags.Tags {
+							tagsData[key] = *value
+						}
 					}
-				}
 
-				lambdaData := DetectedAWSLambda{
-					Region:     lm.awsManager.GetRegion(),
-					Metric:     metric.Description,
-					ResourceID: *fun.FunctionArn,
-					Name:       *fun.FunctionName,
-					Tag:        tagsData,
-				}
+					lambdaData := DetectedAWSLambda{
+						Region:               lm.awsManager.GetRegion(),
+						Metric:               metric.Description,
+						ResourceID:           *fun.FunctionArn,
+						Name:                 *fun.FunctionName,
+						Tag:                  tagsData,
+						MetricDetectedFields: cloudwatch.NewMetricDetectedFields(metric, &metricInput, formulaValue, metricsValues),
+					}
 
-				lm.awsManager.GetCollector().AddResource(collector.EventCollector{
-					ResourceName: collector.ResourceIdentifier(lm.Name),
-					Data:         lambdaData,
-				})
+					lm.awsManager.GetCollector().AddResource(collector.EventCollector{
+						ResourceName: collector.ResourceIdentifier(lm.Name),
+						Data:         lambdaData,
+					})
 
-				detected = append(detected, lambdaData)
-			}
+					detected = append(detected, lambdaData)
+				}
+			})
 		}
 	}
 
+	batch.Execute()
+
 	lm.awsManager.GetCollector().CollectFinish(lm.Name)
 	return detected, nil
 
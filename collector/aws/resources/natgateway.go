@@ -3,10 +3,10 @@ package resources
 import (
 	"errors"
 	"finala/collector"
+	"finala/collector/aws/cloudwatch"
 	"finala/collector/aws/common"
 	"finala/collector/aws/register"
 	"finala/collector/config"
-	"finala/expression"
 	"fmt"
 	"time"
 
@@ -39,6 +39,7 @@ type DetectedNATGateway struct {
 	SubnetID string
 	VPCID    string
 	collector.PriceDetectedFields
+	collector.MetricDetectedFields
 }
 
 func init() {
@@ -107,11 +108,14 @@ func (ngw *NatGatewayManager) Detect(metrics []config.MetricConfig) (interface{}
 	}
 
 	now := time.Now()
+	batch := ngw.awsManager.GetCloudWatchClient().NewBatch()
 
 	for _, natgateway := range natGateways {
+		natgateway := natgateway
 		log.WithField("gateway_id", *natgateway.NatGatewayId).Debug("checking NAT gateway")
 
 		for _, metric := range metrics {
+			metric := metric
 			log.WithFields(log.Fields{
 				"gateway_id":  *natgateway.NatGatewayId,
 				"metric_name": metric.Description,
@@ -133,63 +137,67 @@ func (ngw *NatGatewayManager) Detect(metrics []config.MetricConfig) (interface{}
 				},
 			}
 
-			formulaValue, _, err := ngw.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
-			if err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"gateway_id":  *natgateway.NatGatewayId,
-					"metric_name": metric.Description,
-				}).Error("Could not get cloudwatch metric data")
-				continue
-			}
+			batch.Add(&metricInput, metric, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"gateway_id":  *natgateway.NatGatewayId,
+						"metric_name": metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					return
+				}
 
-			expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
-			if err != nil {
-				log.WithField("error", err).Error("could not parse expression")
-				continue
-			}
+				expression, err := metric.Constraint.Evaluate(formulaValue, metricsValues)
+				if err != nil {
+					log.WithField("error", err).Error("could not parse expression")
+					return
+				}
 
-			if expression {
-				log.WithFields(log.Fields{
-					"metric_name":         metric.Description,
-					"constraint_operator": metric.Constraint.Operator,
-					"constraint_Value":    metric.Constraint.Value,
-					"formula_value":       formulaValue,
-					"gateway_id":          *natgateway.NatGatewayId,
-					"vpc":                 *natgateway.VpcId,
-					"region":              ngw.awsManager.GetRegion(),
-				}).Info("NAT gateway detected as unutilized resource")
-
-				tagsData := map[string]string{}
-				if err == nil {
-					for _, tag := range natgateway.Tags {
-						tagsData[*tag.Key] = *tag.Value
+				if expression {
+					log.WithFields(log.Fields{
+						"metric_name":         metric.Description,
+						"constraint_operator": metric.Constraint.Operator,
+						"constraint_Value":    metric.Constraint.Value,
+						"formula_value":       formulaValue,
+						"gateway_id":          *natgateway.NatGatewayId,
+						"vpc":                 *natgateway.VpcId,
+						"region":              ngw.awsManager.GetRegion(),
+					}).Info("NAT gateway detected as unutilized resource")
+
+					tagsData := map[string]string{}
+					if err == nil {
+						for _, tag := range natgateway.Tags {
+							tagsData[*tag.Key] = *tag.Value
+						}
 					}
-				}
 
-				natGateway := DetectedNATGateway{
-					Region:   ngw.awsManager.GetRegion(),
-					Metric:   metric.Description,
-					SubnetID: *natgateway.SubnetId,
-					VPCID:    *natgateway.VpcId,
-					PriceDetectedFields: collector.PriceDetectedFields{
-						LaunchTime:    *natgateway.CreateTime,
-						ResourceID:    *natgateway.NatGatewayId,
-						PricePerHour:  price,
-						PricePerMonth: price * collector.TotalMonthHours,
-						Tag:           tagsData,
-					},
-				}
+					natGateway := DetectedNATGateway{
+						Region:   ngw.awsManager.GetRegion(),
+						Metric:   metric.Description,
+						SubnetID: *natgateway.SubnetId,
+						VPCID:    *natgateway.VpcId,
+						PriceDetectedFields: collector.PriceDetectedFields{
+							LaunchTime:    *natgateway.CreateTime,
+							ResourceID:    *natgateway.NatGatewayId,
+							PricePerHour:  price,
+							PricePerMonth: price * collector.TotalMonthHours,
+							Tag:           tagsData,
+						},
+						MetricDetectedFields: cloudwatch.NewMetricDetectedFields(metric, &metricInput, formulaValue, metricsValues),
+					}
 
-				ngw.awsManager.GetCollector().AddResource(collector.EventCollector{
-					ResourceName: collector.ResourceIdentifier(ngw.Name),
-					Data:         natGateway,
-				})
+					ngw.awsManager.GetCollector().AddResource(collector.EventCollector{
+						ResourceName: collector.ResourceIdentifier(ngw.Name),
+						Data:         natGateway,
+					})
 
-				DetectedNATGateways = append(DetectedNATGateways, natGateway)
-			}
+					DetectedNATGateways = append(DetectedNATGateways, natGateway)
+				}
+			})
 		}
 	}
 
+	batch.Execute()
+
 	ngw.awsManager.GetCollector().CollectFinish(ngw.Name)
 
 	return DetectedNATGateways, nil
@@ -3,10 +3,10 @@ package resources
 import (
 	"errors"
 	"finala/collector"
+	"finala/collector/aws/cloudwatch"
 	"finala/collector/aws/common"
 	"finala/collector/aws/register"
 	"finala/collector/config"
-	"finala/expression"
 	"time"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
@@ -39,6 +39,7 @@ type DetectedAWSNeptune struct {
 	MultiAZ      bool
 	Engine       string
 	collector.PriceDetectedFields
+	collector.MetricDetectedFields
 }
 
 func init() {
@@ -85,13 +86,16 @@ func (np *NeptuneManager) Detect(metrics []config.MetricConfig) (interface{}, er
 	}
 
 	now := time.Now()
+	batch := np.awsManager.GetCloudWatchClient().NewBatch()
 	for _, instance := range instances {
+		instance := instance
 
 		log.WithField("name", *instance.DBInstanceIdentifier).Debug("checking Neptune instances")
 
 		price, _ := np.awsManager.GetPricingClient().GetPrice(np.getPricingFilterInput(instance), "", np.awsManager.GetRegion())
 
 		for _, metric := range metrics {
+			metric := metric
 			log.WithFields(log.Fields{
 				"name":        *instance.DBInstanceIdentifier,
 				"metric_name": metric.Description,
@@ -112,67 +116,70 @@ func (np *NeptuneManager) Detect(metrics []config.MetricConfig) (interface{}, er
 				},
 			}
 
-			metricResponse, _, err := np.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
+			batch.Add(&metricInput, metric, func(metricResponse float64, metricsValues map[string]interface{}, err error) {
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"name":        *instance.DBInstanceIdentifier,
+						"metric_name": metric.Description,
+					}).Error("Could not get any cloudwatch metric data")
+					return
+				}
 
-			if err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"name":        *instance.DBInstanceIdentifier,
-					"metric_name": metric.Description,
-				}).Error("Could not get any cloudwatch metric data")
-				continue
-			}
+				expression, err := metric.Constraint.Evaluate(metricResponse, metricsValues)
+				if err != nil {
+					return
+				}
 
-			expression, err := expression.BoolExpression(metricResponse, metric.Constraint.Value, metric.Constraint.Operator)
-			if err != nil {
-				continue
-			}
+				if expression {
+
+					log.WithFields(log.Fields{
+						"metric_name":         metric.Description,
+						"constraint_operator": metric.Constraint.Operator,
+						"constraint_Value":    metric.Constraint.Value,
+						"metric_response":     metricResponse,
+						"name":                *instance.DBInstanceIdentifier,
+						"instance_type":       *instance.DBInstanceClass,
+						"region":              np.awsManager.GetRegion(),
+					}).Info("detected unutilized neptune resource")
+
+					tags, err := np.client.ListTagsForResource(&neptune.ListTagsForResourceInput{
+						ResourceName: instance.DBInstanceArn,
+					})
+
+					tagsData := map[string]string{}
+					if err == nil {
+						for _, tag := range tags.TagList {
+							tagsData[*tag.Key] = *tag.Value
+						}
+					}
 
-			if expression {
-
-				log.WithFields(log.Fields{
-					"metric_name":         metric.Description,
-					"constraint_operator": metric.Constraint.Operator,
-					"constraint_Value":    metric.Constraint.Value,
-					"metric_response":     metricResponse,
-					"name":                *instance.DBInstanceIdentifier,
-					"instance_type":       *instance.DBInstanceClass,
-					"region":              np.awsManager.GetRegion(),
-				}).Info("detected unutilized neptune resource")
-
-				tags, err := np.client.ListTagsForResource(&neptune.ListTagsForResourceInput{
-					ResourceName: instance.DBInstanceArn,
-				})
-
-				tagsData := map[string]string{}
-				if err == nil {
-					for _, tag := range tags.TagList {
-						tagsData[*tag.Key] = *tag.Value
+					neptune := DetectedAWSNeptune{
+						Region:       np.awsManager.GetRegion(),
+						Metric:       metric.Description,
+						InstanceType: *instance.DBInstanceClass,
+						MultiAZ:      *instance.MultiAZ,
+						Engine:       *instance.Engine,
+						PriceDetectedFields: collector.PriceDetectedFields{
+							ResourceID:    *instance.DBInstanceArn,
+							LaunchTime:    *instance.InstanceCreateTime,
+							PricePerHour:  price,
+							PricePerMonth: price * collector.TotalMonthHours,
+							Tag:           tagsData,
+						},
+						MetricDetectedFields: cloudwatch.NewMetricDetectedFields(metric, &metricInput, metricResponse, metricsValues),
 					}
-				}
 
-				neptune := DetectedAWSNeptune{
-					Region:       np.awsManager.GetRegion(),
-					Metric:       metric.Description,
-					InstanceType: *instance.DBInstanceClass,
-					MultiAZ:      *instance.MultiAZ,
-					Engine:       *instance.Engine,
-					PriceDetectedFields: collector.PriceDetectedFields{
-						ResourceID:    *instance.DBInstanceArn,
-						LaunchTime:    *instance.InstanceCreateTime,
-						PricePerHour:  price,
-						PricePerMonth: price * collector.TotalMonthHours,
-						Tag:           tagsData,
-					},
+					np.awsManager.GetCollector().AddResource(collector.EventCollector{
+						ResourceName: collector.ResourceIdentifier(np.Name),
+						Data:         neptune,
+					})
+					detected = append(detected, neptune)
 				}
-
-				np.awsManager.GetCollector().AddResource(collector.EventCollector{
-					ResourceName: collector.ResourceIdentifier(np.Name),
-					Data:         neptune,
-				})
-				detected = append(detected, neptune)
-			}
+			})
 		}
 	}
+	batch.Execute()
+
 	np.awsManager.GetCollector().CollectFinish(np.Name)
 	return detected, nil
 
@@ -0,0 +1,232 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"strings"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+)
+
+// networkInterfaceEventNames defines the cloudtrail events that leave a network interface available,
+// detached from its instance or created without an attachment
+var networkInterfaceEventNames = []string{"DetachNetworkInterface", "CreateNetworkInterface"}
+
+// NetworkInterfaceClientDescriptor is an interface defining the aws clients that describe the network interfaces and their events
+type NetworkInterfaceClientDescriptor interface {
+	DescribeNetworkInterfaces(*ec2.DescribeNetworkInterfacesInput) (*ec2.DescribeNetworkInterfacesOutput, error)
+	LookupEvents(*cloudtrail.LookupEventsInput) (*cloudtrail.LookupEventsOutput, error)
+}
+
+// networkInterfaceClient combines the ec2 and the cloudtrail (network interface events) clients
+type networkInterfaceClient struct {
+	*ec2.EC2
+	*cloudtrail.CloudTrail
+}
+
+// NetworkInterfaceManager describes the network interfaces struct
+type NetworkInterfaceManager struct {
+	client         NetworkInterfaceClientDescriptor
+	awsManager     common.AWSManager
+	lookupInterval time.Duration
+	Name           collector.ResourceIdentifier
+}
+
+// DetectedNetworkInterface defines the detected AWS unattached network interfaces
+type DetectedNetworkInterface struct {
+	Metric           string
+	Region           string
+	ResourceID       string
+	VPCID            string
+	SubnetID         string
+	AvailabilityZone string
+	Description      string
+	AvailableDays    int
+	LaunchTime       time.Time
+	Tag              map[string]string
+}
+
+func init() {
+	register.Registry("network_interfaces", NewNetworkInterfaceManager)
+}
+
+// NewNetworkInterfaceManager implements AWS GO SDK
+func NewNetworkInterfaceManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = &networkInterfaceClient{
+			EC2:        ec2.New(awsManager.GetSession()),
+			CloudTrail: cloudtrail.New(awsManager.GetSession()),
+		}
+	}
+
+	interfacesClient, ok := client.(NetworkInterfaceClientDescriptor)
+	if !ok {
+		return nil, errors.New("invalid network interface client")
+	}
+
+	return &NetworkInterfaceManager{
+		client:         interfacesClient,
+		awsManager:     awsManager,
+		lookupInterval: cloudTrailLookupInterval,
+		Name:           awsManager.GetResourceIdentifier("network_interfaces"),
+	}, nil
+}
+
+// Detect unattached network interfaces. The api does not expose the interface detach time, so the available duration
+// is the time since the last detach (or create) cloudtrail event of the interface. The events of all the interfaces are
+// looked up once for the region, in the window of the constraint days, and an interface without events in the window is
+// considered as available since the window start
+func (nim *NetworkInterfaceManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	// This resource support only one metric, the constraint value is the available duration in days
+	metric := metrics[0]
+
+	log.WithFields(log.Fields{
+		"region":   nim.awsManager.GetRegion(),
+		"resource": "network_interfaces",
+	}).Info("starting to analyze resource")
+
+	nim.awsManager.GetCollector().CollectStart(nim.Name)
+
+	detected := []DetectedNetworkInterface{}
+
+	networkInterfaces, err := nim.describeNetworkInterfaces(nil, nil)
+	if err != nil {
+		log.WithError(err).Error("could not describe network interfaces")
+		nim.awsManager.GetCollector().CollectError(nim.Name, err)
+		return detected, err
+	}
+
+	if len(networkInterfaces) == 0 {
+		nim.awsManager.GetCollector().CollectFinish(nim.Name)
+		return detected, nil
+	}
+
+	now := time.Now()
+	lookupStartTime := cloudTrailLookupStartTime(metric.Constraint, now)
+	lastEventTimes, err := nim.getLastEventTimes(lookupStartTime, now)
+	if err != nil {
+		log.WithError(err).Error("could not lookup network interfaces events")
+		nim.awsManager.GetCollector().CollectError(nim.Name, err)
+		return detected, err
+	}
+
+	for _, networkInterface := range networkInterfaces {
+
+		log.WithField("id", *networkInterface.NetworkInterfaceId).Debug("checking network interface")
+
+		lastEventTime, found := lastEventTimes[*networkInterface.NetworkInterfaceId]
+		if !found {
+			lastEventTime = lookupStartTime
+		}
+
+		availableDays := now.Sub(lastEventTime).Hours() / 24
+		expression, err := metric.Constraint.Evaluate(availableDays, nil)
+		if err != nil || !expression {
+			continue
+		}
+
+		tagsData := map[string]string{}
+		for _, tag := range networkInterface.TagSet {
+			tagsData[*tag.Key] = *tag.Value
+		}
+
+		networkInterfaceData := DetectedNetworkInterface{
+			Metric:           metric.Description,
+			Region:           nim.awsManager.GetRegion(),
+			ResourceID:       *networkInterface.NetworkInterfaceId,
+			VPCID:            awsClient.StringValue(networkInterface.VpcId),
+			SubnetID:         awsClient.StringValue(networkInterface.SubnetId),
+			AvailabilityZone: awsClient.StringValue(networkInterface.AvailabilityZone),
+			Description:      awsClient.StringValue(networkInterface.Description),
+			AvailableDays:    int(availableDays),
+			LaunchTime:       lastEventTime,
+			Tag:              tagsData,
+		}
+
+		nim.awsManager.GetCollector().AddResource(collector.EventCollector{
+			ResourceName: nim.Name,
+			Data:         networkInterfaceData,
+		})
+
+		detected = append(detected, networkInterfaceData)
+	}
+
+	nim.awsManager.GetCollector().CollectFinish(nim.Name)
+
+	return detected, nil
+}
+
+// getLastEventTimes returns the time of the last detach or create event of each network interface in the given time range
+func (nim *NetworkInterfaceManager) getLastEventTimes(startTime, endTime time.Time) (map[string]time.Time, error) {
+
+	eventTimes, err := lookupLastEventTimes(nim.client, networkInterfaceEventNames, startTime, endTime, nim.lookupInterval, eventNetworkInterfaceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	lastEventTimes := map[string]time.Time{}
+	for _, networkInterfacesEventTimes := range eventTimes {
+		for networkInterfaceID, eventTime := range networkInterfacesEventTimes {
+			if lastEventTime, found := lastEventTimes[networkInterfaceID]; !found || eventTime.After(lastEventTime) {
+				lastEventTimes[networkInterfaceID] = eventTime
+			}
+		}
+	}
+
+	return lastEventTimes, nil
+}
+
+// eventNetworkInterfaceIDs returns the network interfaces ids of the cloudtrail event, from the event resources
+// and from the networkInterfaceId fields of the event request and response
+func eventNetworkInterfaceIDs(event *cloudtrail.Event) []string {
+
+	networkInterfaceIDs := []string{}
+	for _, resource := range event.Resources {
+		name := awsClient.StringValue(resource.ResourceName)
+		if strings.HasPrefix(name, "eni-") && !strings.HasPrefix(name, "eni-attach-") {
+			networkInterfaceIDs = append(networkInterfaceIDs, name)
+		}
+	}
+
+	return append(networkInterfaceIDs, cloudTrailEventFieldValues(event, "networkInterfaceId")...)
+}
+
+// describeNetworkInterfaces returns the network interfaces with available status
+func (nim *NetworkInterfaceManager) describeNetworkInterfaces(nextToken *string, networkInterfaces []*ec2.NetworkInterface) ([]*ec2.NetworkInterface, error) {
+
+	input := &ec2.DescribeNetworkInterfacesInput{
+		NextToken: nextToken,
+		Filters: []*ec2.Filter{
+			{
+				Name:   awsClient.String("status"),
+				Values: []*string{awsClient.String(ec2.NetworkInterfaceStatusAvailable)},
+			},
+		},
+	}
+
+	resp, err := nim.client.DescribeNetworkInterfaces(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if networkInterfaces == nil {
+		networkInterfaces = []*ec2.NetworkInterface{}
+	}
+
+	networkInterfaces = append(networkInterfaces, resp.NetworkInterfaces...)
+
+	if resp.NextToken != nil {
+		return nim.describeNetworkInterfaces(resp.NextToken, networkInterfaces)
+	}
+
+	return networkInterfaces, nil
+}
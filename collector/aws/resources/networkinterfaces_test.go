@@ -0,0 +1,225 @@
+package resources
+
+import (
+	"errors"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	collectorTestutils "finala/collector/testutils"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+var defaultNetworkInterfacesMock = ec2.DescribeNetworkInterfacesOutput{
+	NetworkInterfaces: []*ec2.NetworkInterface{
+		{
+			// Detached 60 days ago
+			NetworkInterfaceId: awsClient.String("eni-1"),
+			VpcId:              awsClient.String("vpc-1"),
+			TagSet:             []*ec2.Tag{{Key: awsClient.String("team"), Value: awsClient.String("a")}},
+		},
+		{
+			// Detached yesterday
+			NetworkInterfaceId: awsClient.String("eni-2"),
+		},
+		{
+			// No events in the cloudtrail history
+			NetworkInterfaceId: awsClient.String("eni-3"),
+		},
+		{
+			// Created 5 days ago, without an attachment
+			NetworkInterfaceId: awsClient.String("eni-4"),
+		},
+	},
+}
+
+// defaultNetworkInterfacesEventsMock defines the lookup events pages of each event name
+var defaultNetworkInterfacesEventsMock = map[string][][]*cloudtrail.Event{
+	"DetachNetworkInterface": {
+		{
+			{
+				EventTime:       awsClient.Time(time.Now().AddDate(0, 0, -1)),
+				CloudTrailEvent: awsClient.String(`{"requestParameters": {"attachmentId": "eni-attach-2", "networkInterfaceId": "eni-2"}}`),
+			},
+			{
+				EventTime: awsClient.Time(time.Now().AddDate(0, 0, -60)),
+				Resources: []*cloudtrail.Resource{
+					{ResourceType: awsClient.String("AWS::EC2::NetworkInterface"), ResourceName: awsClient.String("eni-1")},
+					{ResourceName: awsClient.String("eni-attach-1")},
+				},
+			},
+		},
+		{
+			{
+				EventTime: awsClient.Time(time.Now().AddDate(0, 0, -80)),
+				Resources: []*cloudtrail.Resource{{ResourceName: awsClient.String("eni-1")}},
+			},
+		},
+	},
+	"CreateNetworkInterface": {
+		{
+			{
+				EventTime:       awsClient.Time(time.Now().AddDate(0, 0, -5)),
+				CloudTrailEvent: awsClient.String(`{"responseElements": {"networkInterface": {"networkInterfaceId": "eni-4"}}}`),
+			},
+		},
+	},
+}
+
+type MockAWSNetworkInterfaceClient struct {
+	responseDescribeNetworkInterfaces ec2.DescribeNetworkInterfacesOutput
+	err                               error
+	lookupErr                         error
+	lookupCount                       int
+	lookupStartTime                   time.Time
+}
+
+func (r *MockAWSNetworkInterfaceClient) DescribeNetworkInterfaces(*ec2.DescribeNetworkInterfacesInput) (*ec2.DescribeNetworkInterfacesOutput, error) {
+	return &r.responseDescribeNetworkInterfaces, r.err
+}
+
+func (r *MockAWSNetworkInterfaceClient) LookupEvents(input *cloudtrail.LookupEventsInput) (*cloudtrail.LookupEventsOutput, error) {
+	r.lookupCount++
+	r.lookupStartTime = *input.StartTime
+
+	pages := defaultNetworkInterfacesEventsMock[*input.LookupAttributes[0].AttributeValue]
+	page := 0
+	if input.NextToken != nil {
+		page, _ = strconv.Atoi(*input.NextToken)
+	}
+
+	// The events before the lookup start time are not returned
+	output := &cloudtrail.LookupEventsOutput{Events: []*cloudtrail.Event{}}
+	for _, event := range pages[page] {
+		if !event.EventTime.Before(*input.StartTime) {
+			output.Events = append(output.Events, event)
+		}
+	}
+	if page+1 < len(pages) {
+		output.NextToken = awsClient.String(strconv.Itoa(page + 1))
+	}
+	return output, r.lookupErr
+}
+
+func TestDetectNetworkInterfaces(t *testing.T) {
+
+	var defaultMetricConfig = []config.MetricConfig{
+		{
+			Description: "Unattached network interface",
+			Constraint: config.MetricConstraintConfig{
+				Operator: ">=",
+				Value:    30,
+			},
+		},
+	}
+
+	t.Run("detect", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+		client := &MockAWSNetworkInterfaceClient{responseDescribeNetworkInterfaces: defaultNetworkInterfacesMock}
+		networkInterfaces, err := NewNetworkInterfaceManager(detector, client)
+		if err != nil {
+			t.Fatalf("unexpected network interface manager error happened, got %v expected %v", err, nil)
+		}
+
+		networkInterfacesManager, ok := networkInterfaces.(*NetworkInterfaceManager)
+		if !ok {
+			t.Fatalf("unexpected network interface struct, got %s expected %s", reflect.TypeOf(networkInterfaces), "*NetworkInterfaceManager")
+		}
+
+		networkInterfacesManager.lookupInterval = 0
+		response, _ := networkInterfacesManager.Detect(defaultMetricConfig)
+
+		networkInterfacesResponse, ok := response.([]DetectedNetworkInterface)
+		if !ok {
+			t.Fatalf("unexpected network interface struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedNetworkInterface")
+		}
+
+		if len(networkInterfacesResponse) != 2 {
+			t.Fatalf("unexpected network interfaces detected, got %d expected %d", len(networkInterfacesResponse), 2)
+		}
+
+		if len(collector.Events) != 2 {
+			t.Fatalf("unexpected collector network interface resources, got %d expected %d", len(collector.Events), 2)
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource status events count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+
+		expected := []struct {
+			id            string
+			availableDays int
+		}{
+			// Detached before the looked up window of 31 days
+			{"eni-1", 31},
+			{"eni-3", 31},
+		}
+		for i, networkInterface := range networkInterfacesResponse {
+			if networkInterface.ResourceID != expected[i].id || networkInterface.AvailableDays != expected[i].availableDays {
+				t.Fatalf("unexpected network interface, got %s available %d days expected %s available %d days", networkInterface.ResourceID, networkInterface.AvailableDays, expected[i].id, expected[i].availableDays)
+			}
+		}
+
+		if networkInterfacesResponse[0].Tag["team"] != "a" {
+			t.Fatalf("unexpected network interface tags, got %v", networkInterfacesResponse[0].Tag)
+		}
+
+		// The events are looked up once for all the network interfaces, by their event name pages
+		if client.lookupCount != 3 {
+			t.Fatalf("unexpected cloudtrail lookups count, got %d expected %d", client.lookupCount, 3)
+		}
+
+		// The events are looked up in the constraint window and a day more
+		if lookupDays := int(time.Since(client.lookupStartTime).Hours() / 24); lookupDays != 31 {
+			t.Fatalf("unexpected cloudtrail lookup window, got %d days expected %d days", lookupDays, 31)
+		}
+	})
+
+	t.Run("lookup error", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+		networkInterfaces, err := NewNetworkInterfaceManager(detector, &MockAWSNetworkInterfaceClient{responseDescribeNetworkInterfaces: defaultNetworkInterfacesMock, lookupErr: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected network interface manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = networkInterfaces.Detect(defaultMetricConfig)
+		if err == nil {
+			t.Fatalf("unexpected lookup events error, return empty")
+		}
+
+		if len(collector.Events) != 0 {
+			t.Fatalf("unexpected collector network interface resources, got %d expected %d", len(collector.Events), 0)
+		}
+	})
+
+	t.Run("describe error", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+		networkInterfaces, err := NewNetworkInterfaceManager(detector, &MockAWSNetworkInterfaceClient{err: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected network interface manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = networkInterfaces.Detect(defaultMetricConfig)
+		if err == nil {
+			t.Fatalf("unexpected describe network interfaces error, return empty")
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource status events count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+	})
+}
@@ -3,10 +3,10 @@ package resources
 import (
 	"errors"
 	"finala/collector"
+	"finala/collector/aws/cloudwatch"
 	"finala/collector/aws/common"
 	"finala/collector/aws/register"
 	"finala/collector/config"
-	"finala/expression"
 	"fmt"
 	"time"
 
@@ -43,6 +43,7 @@ type DetectedAWSRDS struct {
 	MultiAZ      bool
 	Engine       string
 	collector.PriceDetectedFields
+	collector.MetricDetectedFields
 }
 
 // RDSVolumeType will hold the available volume types for RDS types
@@ -107,7 +108,9 @@ func (r *RDSManager) Detect(metrics []config.MetricConfig) (interface{}, error)
 	}
 
 	now := time.Now()
+	batch := r.awsManager.GetCloudWatchClient().NewBatch()
 	for _, instance := range instances {
+		instance := instance
 
 		log.WithField("name", *instance.DBInstanceIdentifier).Debug("checking RDS")
 
@@ -134,6 +137,7 @@ func (r *RDSManager) Detect(metrics []config.MetricConfig) (interface{}, error)
 			"region":              r.awsManager.GetRegion()}).Debug("Found the following price list")
 
 		for _, metric := range metrics {
+			metric := metric
 			log.WithFields(log.Fields{
 				"name":        *instance.DBInstanceIdentifier,
 				"metric_name": metric.Description,
@@ -154,71 +158,74 @@ func (r *RDSManager) Detect(metrics []config.MetricConfig) (interface{}, error)
 				},
 			}
 
-			formulaValue, _, err := r.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
-
-			if err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"name":        *instance.DBInstanceIdentifier,
-					"metric_name": metric.Description,
-				}).Error("Could not get cloudwatch metric data")
-				continue
-			}
+			batch.Add(&metricInput, metric, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"name":        *instance.DBInstanceIdentifier,
+						"metric_name": metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					return
+				}
 
-			expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
-			if err != nil {
-				continue
-			}
+				expression, err := metric.Constraint.Evaluate(formulaValue, metricsValues)
+				if err != nil {
+					return
+				}
 
-			if expression {
-
-				log.WithFields(log.Fields{
-					"metric_name":         metric.Description,
-					"constraint_operator": metric.Constraint.Operator,
-					"constraint_Value":    metric.Constraint.Value,
-					"formula_value":       formulaValue,
-					"name":                *instance.DBInstanceIdentifier,
-					"instance_type":       *instance.DBInstanceClass,
-					"engine":              *instance.Engine,
-					"region":              r.awsManager.GetRegion(),
-				}).Info("RDS instance detected as unutilized resource")
-
-				tags, err := r.client.ListTagsForResource(&rds.ListTagsForResourceInput{
-					ResourceName: instance.DBInstanceArn,
-				})
-
-				tagsData := map[string]string{}
-				if err == nil {
-					for _, tag := range tags.TagList {
-						tagsData[*tag.Key] = *tag.Value
+				if expression {
+
+					log.WithFields(log.Fields{
+						"metric_name":         metric.Description,
+						"constraint_operator": metric.Constraint.Operator,
+						"constraint_Value":    metric.Constraint.Value,
+						"formula_value":       formulaValue,
+						"name":                *instance.DBInstanceIdentifier,
+						"instance_type":       *instance.DBInstanceClass,
+						"engine":              *instance.Engine,
+						"region":              r.awsManager.GetRegion(),
+					}).Info("RDS instance detected as unutilized resource")
+
+					tags, err := r.client.ListTagsForResource(&rds.ListTagsForResourceInput{
+						ResourceName: instance.DBInstanceArn,
+					})
+
+					tagsData := map[string]string{}
+					if err == nil {
+						for _, tag := range tags.TagList {
+							tagsData[*tag.Key] = *tag.Value
+						}
 					}
-				}
 
-				rds := DetectedAWSRDS{
-					Region:       r.awsManager.GetRegion(),
-					Metric:       metric.Description,
-					InstanceType: *instance.DBInstanceClass,
-					MultiAZ:      *instance.MultiAZ,
-					Engine:       *instance.Engine,
-					PriceDetectedFields: collector.PriceDetectedFields{
-						ResourceID:    *instance.DBInstanceArn,
-						LaunchTime:    *instance.InstanceCreateTime,
-						PricePerHour:  totalHourlyPrice,
-						PricePerMonth: totalHourlyPrice * collector.TotalMonthHours,
-						Tag:           tagsData,
-					},
-				}
+					rds := DetectedAWSRDS{
+						Region:       r.awsManager.GetRegion(),
+						Metric:       metric.Description,
+						InstanceType: *instance.DBInstanceClass,
+						MultiAZ:      *instance.MultiAZ,
+						Engine:       *instance.Engine,
+						PriceDetectedFields: collector.PriceDetectedFields{
+							ResourceID:    *instance.DBInstanceArn,
+							LaunchTime:    *instance.InstanceCreateTime,
+							PricePerHour:  totalHourlyPrice,
+							PricePerMonth: totalHourlyPrice * collector.TotalMonthHours,
+							Tag:           tagsData,
+						},
+						MetricDetectedFields: cloudwatch.NewMetricDetectedFields(metric, &metricInput, formulaValue, metricsValues),
+					}
 
-				r.awsManager.GetCollector().AddResource(collector.EventCollector{
-					ResourceName: r.Name,
-					Data:         rds,
-				})
+					r.awsManager.GetCollector().AddResource(collector.EventCollector{
+						ResourceName: r.Name,
+						Data:         rds,
+					})
 
-				detected = append(detected, rds)
-			}
+					detected = append(detected, rds)
+				}
+			})
 		}
 
 	}
 
+	batch.Execute()
+
 	r.awsManager.GetCollector().CollectFinish(r.Name)
 
 	return detected, nil
@@ -3,10 +3,10 @@ package resources
 import (
 	"errors"
 	"finala/collector"
+	"finala/collector/aws/cloudwatch"
 	"finala/collector/aws/common"
 	"finala/collector/aws/register"
 	"finala/collector/config"
-	"finala/expression"
 	"time"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
@@ -38,6 +38,7 @@ type DetectedRedShift struct {
 	NodeType      string
 	NumberOfNodes int64
 	collector.PriceDetectedFields
+	collector.MetricDetectedFields
 }
 
 func init() {
@@ -84,13 +85,16 @@ func (rdm *RedShiftManager) Detect(metrics []config.MetricConfig) (interface{},
 	}
 
 	now := time.Now()
+	batch := rdm.awsManager.GetCloudWatchClient().NewBatch()
 
 	for _, cluster := range clusters {
+		cluster := cluster
 		log.WithField("cluster_id", *cluster.ClusterIdentifier).Debug("checking redshift")
 
 		price, _ := rdm.awsManager.GetPricingClient().GetPrice(rdm.getPricingFilterInput(cluster), "", rdm.awsManager.GetRegion())
 
 		for _, metric := range metrics {
+			metric := metric
 			log.WithFields(log.Fields{
 				"cluster_id":  *cluster.ClusterIdentifier,
 				"metric_name": metric.Description,
@@ -112,65 +116,69 @@ func (rdm *RedShiftManager) Detect(metrics []config.MetricConfig) (interface{},
 				},
 			}
 
-			formulaValue, _, err := rdm.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
-			if err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"cluster_id":  *cluster.ClusterIdentifier,
-					"metric_name": metric.Description,
-				}).Error("Could not get cloudwatch metric data")
-				continue
-			}
+			batch.Add(&metricInput, metric, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"cluster_id":  *cluster.ClusterIdentifier,
+						"metric_name": metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					return
+				}
 
-			expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
-			if err != nil {
-				log.WithField("error", err).Error("could not parse expression")
-				continue
-			}
+				expression, err := metric.Constraint.Evaluate(formulaValue, metricsValues)
+				if err != nil {
+					log.WithField("error", err).Error("could not parse expression")
+					return
+				}
 
-			if expression {
-				clusterPrice := price * float64(*cluster.NumberOfNodes)
-
-				log.WithFields(log.Fields{
-					"metric_name":         metric.Description,
-					"constraint_operator": metric.Constraint.Operator,
-					"constraint_Value":    metric.Constraint.Value,
-					"formula_value":       formulaValue,
-					"cluster_id":          *cluster.ClusterIdentifier,
-					"node_type":           *cluster.NodeType,
-					"region":              rdm.awsManager.GetRegion(),
-				}).Info("Redshift cluster detected as unutilized resource")
-
-				tagsData := map[string]string{}
-				if err == nil {
-					for _, tag := range cluster.Tags {
-						tagsData[*tag.Key] = *tag.Value
+				if expression {
+					clusterPrice := price * float64(*cluster.NumberOfNodes)
+
+					log.WithFields(log.Fields{
+						"metric_name":         metric.Description,
+						"constraint_operator": metric.Constraint.Operator,
+						"constraint_Value":    metric.Constraint.Value,
+						"formula_value":       formulaValue,
+						"cluster_id":          *cluster.ClusterIdentifier,
+						"node_type":           *cluster.NodeType,
+						"region":              rdm.awsManager.GetRegion(),
+					}).Info("Redshift cluster detected as unutilized resource")
+
+					tagsData := map[string]string{}
+					if err == nil {
+						for _, tag := range cluster.Tags {
+							tagsData[*tag.Key] = *tag.Value
+						}
 					}
-				}
 
-				redshift := DetectedRedShift{
-					Region:        rdm.awsManager.GetRegion(),
-					Metric:        metric.Description,
-					NodeType:      *cluster.NodeType,
-					NumberOfNodes: *cluster.NumberOfNodes,
-					PriceDetectedFields: collector.PriceDetectedFields{
-						LaunchTime:    *cluster.ClusterCreateTime,
-						ResourceID:    *cluster.ClusterIdentifier,
-						PricePerHour:  clusterPrice,
-						PricePerMonth: clusterPrice * collector.TotalMonthHours,
-						Tag:           tagsData,
-					},
-				}
+					redshift := DetectedRedShift{
+						Region:        rdm.awsManager.GetRegion(),
+						Metric:        metric.Description,
+						NodeType:      *cluster.NodeType,
+						NumberOfNodes: *cluster.NumberOfNodes,
+						PriceDetectedFields: collector.PriceDetectedFields{
+							LaunchTime:    *cluster.ClusterCreateTime,
+							ResourceID:    *cluster.ClusterIdentifier,
+							PricePerHour:  clusterPrice,
+							PricePerMonth: clusterPrice * collector.TotalMonthHours,
+							Tag:           tagsData,
+						},
+						MetricDetectedFields: cloudwatch.NewMetricDetectedFields(metric, &metricInput, formulaValue, metricsValues),
+					}
 
-				rdm.awsManager.GetCollector().AddResource(collector.EventCollector{
-					ResourceName: collector.ResourceIdentifier(rdm.Name),
-					Data:         redshift,
-				})
+					rdm.awsManager.GetCollector().AddResource(collector.EventCollector{
+						ResourceName: collector.ResourceIdentifier(rdm.Name),
+						Data:         redshift,
+					})
 
-				detectedredshiftClusters = append(detectedredshiftClusters, redshift)
-			}
+					detectedredshiftClusters = append(detectedredshiftClusters, redshift)
+				}
+			})
 		}
 	}
 
+	batch.Execute()
+
 	rdm.awsManager.GetCollector().CollectFinish(rdm.Name)
 
 	return detectedredshiftClusters, nil
@@ -0,0 +1,568 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/cloudwatch"
+	"finala/collector/aws/common"
+	"finala/collector/aws/pricing"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"fmt"
+	"sort"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	awsPricing "github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/aws/aws-sdk-go/service/s3"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// s3StorageMetricName defines the bucket storage metric, metric configurations that use it are evaluated
+	// on the standard storage of the buckets without a lifecycle transition
+	s3StorageMetricName = "BucketSizeBytes"
+
+	// s3UsagePeriod defines the window of the bucket usage metrics, the storage metrics are reported once a day
+	s3UsagePeriod = 48 * time.Hour
+
+	// bytesInGB defines the amount of bytes in a single GB
+	bytesInGB = 1024 * 1024 * 1024
+)
+
+// s3StorageType describes the pricing volume type and usage type of a storage type
+type s3StorageType struct {
+	volumeType string
+	usageType  string
+}
+
+// s3StorageTypes maps the cloudwatch storage types of the bucket size metric to their pricing volume type and usage
+// type, the usage type is prefixed by the bucket pricing region prefix
+var s3StorageTypes = map[string]s3StorageType{
+	"StandardStorage":             {volumeType: "Standard", usageType: "TimedStorage-ByteHrs"},
+	"StandardIAStorage":           {volumeType: "Standard - Infrequent Access", usageType: "TimedStorage-SIA-ByteHrs"},
+	"OneZoneIAStorage":            {volumeType: "One Zone - Infrequent Access", usageType: "TimedStorage-ZIA-ByteHrs"},
+	"ReducedRedundancyStorage":    {volumeType: "Reduced Redundancy", usageType: "TimedStorage-RRS-ByteHrs"},
+	"IntelligentTieringFAStorage": {volumeType: "Intelligent-Tiering Frequent Access", usageType: "TimedStorage-INT-FA-ByteHrs"},
+	"IntelligentTieringIAStorage": {volumeType: "Intelligent-Tiering Infrequent Access", usageType: "TimedStorage-INT-IA-ByteHrs"},
+	"GlacierStorage":              {volumeType: "Amazon Glacier", usageType: "TimedStorage-GlacierByteHrs"},
+	"DeepArchiveStorage":          {volumeType: "Glacier Deep Archive", usageType: "TimedStorage-GDA-ByteHrs"},
+}
+
+// S3ClientDescreptor is an interface defining the aws s3 client
+type S3ClientDescreptor interface {
+	ListBuckets(*s3.ListBucketsInput) (*s3.ListBucketsOutput, error)
+	GetBucketLocation(*s3.GetBucketLocationInput) (*s3.GetBucketLocationOutput, error)
+	GetBucketLifecycleConfiguration(*s3.GetBucketLifecycleConfigurationInput) (*s3.GetBucketLifecycleConfigurationOutput, error)
+	ListBucketMetricsConfigurations(*s3.ListBucketMetricsConfigurationsInput) (*s3.ListBucketMetricsConfigurationsOutput, error)
+	GetBucketTagging(*s3.GetBucketTaggingInput) (*s3.GetBucketTaggingOutput, error)
+}
+
+// S3Manager describe the s3 buckets manager
+type S3Manager struct {
+	client             S3ClientDescreptor
+	awsManager         common.AWSManager
+	namespace          string
+	servicePricingCode string
+	Name               collector.ResourceIdentifier
+
+	// regionClient returns the s3 client of the given region, the buckets api should be called in the bucket region
+	regionClient func(region string) S3ClientDescreptor
+
+	// regionCloudWatchClient returns the cloudwatch client of the given region, the buckets metrics are reported in the bucket region
+	regionCloudWatchClient func(region string) *cloudwatch.CloudwatchManager
+}
+
+// DetectedS3Bucket define the detected AWS s3 bucket
+type DetectedS3Bucket struct {
+	Region       string
+	Metric       string
+	Name         string
+	SizeGB       float64
+	ObjectsCount int64
+	collector.PriceDetectedFields
+	collector.MetricDetectedFields
+}
+
+// s3Bucket describe the bucket data that is needed for the detection
+type s3Bucket struct {
+	bucket             *s3.Bucket
+	region             string
+	hasTransition      bool
+	requestsMetricsID  *string
+	storageSizes       map[string]float64
+	objectsCount       float64
+	pricePerMonth      float64
+	totalStorageSizeGB float64
+}
+
+func init() {
+	register.Registry("s3", NewS3Manager)
+}
+
+// NewS3Manager implements AWS GO SDK
+func NewS3Manager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	resourceName := awsManager.GetResourceIdentifier("s3")
+
+	// The buckets are listed from a single region of each account, the global marker is per account so every
+	// account that shares the global resources is still scanned
+	var accountID string
+	if identity := awsManager.GetAccountIdentity(); identity != nil {
+		accountID = awsClient.StringValue(identity.Account)
+	}
+	if !awsManager.TrySetGlobal(collector.ResourceIdentifier(fmt.Sprintf("%s:%s", resourceName, accountID))) {
+		log.Info("resource defined ad global resource")
+		return nil, nil
+	}
+
+	sess, sessionConfig := awsManager.GetSession()
+
+	var regionClient func(region string) S3ClientDescreptor
+	if client == nil {
+		client = s3.New(sess, sessionConfig)
+		regionClients := map[string]S3ClientDescreptor{}
+		regionClient = func(region string) S3ClientDescreptor {
+			if _, found := regionClients[region]; !found {
+				regionClients[region] = s3.New(sess, sessionConfig.Copy(&awsClient.Config{Region: awsClient.String(region)}))
+			}
+			return regionClients[region]
+		}
+	}
+
+	s3Client, ok := client.(S3ClientDescreptor)
+	if !ok {
+		return nil, errors.New("invalid s3 client")
+	}
+
+	if regionClient == nil {
+		regionClient = func(region string) S3ClientDescreptor {
+			return s3Client
+		}
+	}
+
+	cloudWatchClients := map[string]*cloudwatch.CloudwatchManager{
+		awsManager.GetRegion(): awsManager.GetCloudWatchClient(),
+	}
+
+	return &S3Manager{
+		client:             s3Client,
+		awsManager:         awsManager,
+		namespace:          "AWS/S3",
+		servicePricingCode: "AmazonS3",
+		Name:               resourceName,
+		regionClient:       regionClient,
+		regionCloudWatchClient: func(region string) *cloudwatch.CloudwatchManager {
+			if _, found := cloudWatchClients[region]; !found {
+				cloudWatchClients[region] = cloudwatch.NewCloudWatchManager(awsCloudwatch.New(sess, sessionConfig.Copy(&awsClient.Config{Region: awsClient.String(region)})))
+			}
+			return cloudWatchClients[region]
+		},
+	}, nil
+}
+
+// Detect will go over on all the account buckets and check if some of the metric configuration happened.
+// The requests metrics are checked only for buckets with request metrics of the entire bucket, and the storage metric
+// is checked only for buckets without a lifecycle transition
+func (sm *S3Manager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	log.WithFields(log.Fields{
+		"resource": "s3",
+	}).Info("starting to analyze resource")
+
+	sm.awsManager.GetCollector().CollectStart(sm.Name)
+
+	detected := []DetectedS3Bucket{}
+
+	resp, err := sm.client.ListBuckets(&s3.ListBucketsInput{})
+	if err != nil {
+		log.WithError(err).Error("could not list s3 buckets")
+		sm.awsManager.GetCollector().CollectError(sm.Name, err)
+		return detected, err
+	}
+
+	regionsBuckets := map[string][]*s3Bucket{}
+	for _, bucket := range resp.Buckets {
+		bucketData, err := sm.describeBucket(bucket)
+		if err != nil {
+			log.WithError(err).WithField("bucket", *bucket.Name).Error("could not describe s3 bucket")
+			continue
+		}
+		regionsBuckets[bucketData.region] = append(regionsBuckets[bucketData.region], bucketData)
+	}
+
+	regions := []string{}
+	for region := range regionsBuckets {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	now := time.Now()
+	for _, region := range regions {
+
+		buckets := regionsBuckets[region]
+		cloudWatchClient := sm.regionCloudWatchClient(region)
+
+		sm.fetchBucketsUsage(cloudWatchClient, buckets, now)
+
+		batch := cloudWatchClient.NewBatch()
+		for _, bucket := range buckets {
+			bucket := bucket
+
+			log.WithField("bucket", *bucket.bucket.Name).Debug("checking s3 bucket")
+
+			for _, metric := range metrics {
+				metric := metric
+
+				metricInput, ok := sm.getMetricInput(bucket, metric, now)
+				if !ok {
+					continue
+				}
+
+				batch.Add(metricInput, metric, func(formulaValue float64, metricsResponseValues map[string]interface{}, err error) {
+					if err != nil {
+						log.WithError(err).WithFields(log.Fields{
+							"bucket":      *bucket.bucket.Name,
+							"metric_name": metric.Description,
+						}).Error("Could not get cloudwatch metric data")
+						return
+					}
+
+					expression, err := metric.Constraint.Evaluate(formulaValue, metricsResponseValues)
+					if err != nil {
+						log.WithFields(log.Fields{
+							"bucket":                     *bucket.bucket.Name,
+							"formula_value":              formulaValue,
+							"metric_constraint_value":    metric.Constraint.Value,
+							"metric_constraint_operator": metric.Constraint.Operator,
+						}).Error("bool expression error")
+						return
+					}
+
+					if !expression {
+						return
+					}
+
+					log.WithFields(log.Fields{
+						"metric_name":         metric.Description,
+						"constraint_operator": metric.Constraint.Operator,
+						"constraint_Value":    metric.Constraint.Value,
+						"formula_value":       formulaValue,
+						"name":                *bucket.bucket.Name,
+						"region":              bucket.region,
+					}).Info("S3 bucket detected as unutilized resource")
+
+					bucketData := DetectedS3Bucket{
+						Region:       bucket.region,
+						Metric:       metric.Description,
+						Name:         *bucket.bucket.Name,
+						SizeGB:       bucket.totalStorageSizeGB,
+						ObjectsCount: int64(bucket.objectsCount),
+						PriceDetectedFields: collector.PriceDetectedFields{
+							ResourceID:    fmt.Sprintf("arn:aws:s3:::%s", *bucket.bucket.Name),
+							LaunchTime:    awsClient.TimeValue(bucket.bucket.CreationDate),
+							PricePerHour:  bucket.pricePerMonth / collector.TotalMonthHours,
+							PricePerMonth: bucket.pricePerMonth,
+							Tag:           sm.getBucketTags(bucket),
+						},
+						MetricDetectedFields: cloudwatch.NewMetricDetectedFields(metric, metricInput, formulaValue, metricsResponseValues),
+					}
+
+					sm.awsManager.GetCollector().AddResource(collector.EventCollector{
+						ResourceName: sm.Name,
+						Data:         bucketData,
+					})
+
+					detected = append(detected, bucketData)
+				})
+			}
+		}
+
+		batch.Execute()
+	}
+
+	sm.awsManager.GetCollector().CollectFinish(sm.Name)
+
+	return detected, nil
+}
+
+// describeBucket returns the bucket region, lifecycle transition and the request metrics configuration
+func (sm *S3Manager) describeBucket(bucket *s3.Bucket) (*s3Bucket, error) {
+
+	location, err := sm.client.GetBucketLocation(&s3.GetBucketLocationInput{
+		Bucket: bucket.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bucketData := &s3Bucket{
+		bucket:       bucket,
+		region:       s3.NormalizeBucketLocation(awsClient.StringValue(location.LocationConstraint)),
+		storageSizes: map[string]float64{},
+	}
+	client := sm.regionClient(bucketData.region)
+
+	lifecycle, err := client.GetBucketLifecycleConfiguration(&s3.GetBucketLifecycleConfigurationInput{
+		Bucket: bucket.Name,
+	})
+	if err != nil && !isAWSErrorCode(err, "NoSuchLifecycleConfiguration") {
+		return nil, err
+	}
+	if err == nil {
+		for _, rule := range lifecycle.Rules {
+			if awsClient.StringValue(rule.Status) == s3.ExpirationStatusEnabled && (len(rule.Transitions) > 0 || len(rule.NoncurrentVersionTransitions) > 0) {
+				bucketData.hasTransition = true
+				break
+			}
+		}
+	}
+
+	var token *string
+	for {
+		metricsConfigurations, err := client.ListBucketMetricsConfigurations(&s3.ListBucketMetricsConfigurationsInput{
+			Bucket:            bucket.Name,
+			ContinuationToken: token,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// The request metrics are available only when there is a configuration that covers the entire bucket
+		for _, metricsConfiguration := range metricsConfigurations.MetricsConfigurationList {
+			if metricsConfiguration.Filter == nil {
+				bucketData.requestsMetricsID = metricsConfiguration.Id
+				break
+			}
+		}
+
+		if bucketData.requestsMetricsID != nil || metricsConfigurations.NextContinuationToken == nil {
+			break
+		}
+		token = metricsConfigurations.NextContinuationToken
+	}
+
+	return bucketData, nil
+}
+
+// fetchBucketsUsage sets the buckets storage size by storage types, objects count and the storage price per month
+func (sm *S3Manager) fetchBucketsUsage(cloudWatchClient *cloudwatch.CloudwatchManager, buckets []*s3Bucket, now time.Time) {
+
+	period := int64(s3UsagePeriod.Seconds())
+	startTime := now.Add(-s3UsagePeriod)
+
+	batch := cloudWatchClient.NewBatch()
+	for _, bucket := range buckets {
+		bucket := bucket
+
+		dimensions := map[string]string{
+			"NumberOfObjects": "AllStorageTypes",
+		}
+		for storageType := range s3StorageTypes {
+			dimensions[storageType] = storageType
+		}
+
+		for key, storageType := range dimensions {
+			key := key
+			metricName := s3StorageMetricName
+			if key == "NumberOfObjects" {
+				metricName = key
+			}
+
+			metricInput := awsCloudwatch.GetMetricStatisticsInput{
+				Namespace: &sm.namespace,
+				Period:    &period,
+				StartTime: &startTime,
+				EndTime:   &now,
+				Dimensions: []*awsCloudwatch.Dimension{
+					{
+						Name:  awsClient.String("BucketName"),
+						Value: bucket.bucket.Name,
+					},
+					{
+						Name:  awsClient.String("StorageType"),
+						Value: awsClient.String(storageType),
+					},
+				},
+			}
+			metric := config.MetricConfig{
+				Description: metricName,
+				Data: []config.MetricDataConfiguration{
+					{
+						Name:      metricName,
+						Statistic: "Maximum",
+					},
+				},
+			}
+
+			batch.Add(&metricInput, metric, func(formulaValue float64, metricsResponseValues map[string]interface{}, err error) {
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"bucket":      *bucket.bucket.Name,
+						"metric_name": key,
+					}).Error("Could not get s3 bucket usage metric data")
+					return
+				}
+
+				if key == "NumberOfObjects" {
+					bucket.objectsCount = formulaValue
+				} else {
+					bucket.storageSizes[key] = formulaValue / bytesInGB
+				}
+			})
+		}
+	}
+
+	batch.Execute()
+
+	// The price of each storage type by the bucket region
+	storagePrices := map[string]map[string]float64{}
+	for _, bucket := range buckets {
+		if _, found := storagePrices[bucket.region]; !found {
+			storagePrices[bucket.region] = map[string]float64{}
+		}
+
+		for storageType, sizeGB := range bucket.storageSizes {
+			if sizeGB == 0 {
+				continue
+			}
+
+			price, found := storagePrices[bucket.region][storageType]
+			if !found {
+				price = sm.getStoragePrice(storageType, bucket.region)
+				storagePrices[bucket.region][storageType] = price
+			}
+
+			bucket.totalStorageSizeGB += sizeGB
+			bucket.pricePerMonth += price * sizeGB
+		}
+	}
+}
+
+// getMetricInput returns the cloudwatch metric input of the bucket metric, or false when the metric is not relevant for the bucket
+func (sm *S3Manager) getMetricInput(bucket *s3Bucket, metric config.MetricConfig, now time.Time) (*awsCloudwatch.GetMetricStatisticsInput, bool) {
+
+	dimensions := []*awsCloudwatch.Dimension{
+		{
+			Name:  awsClient.String("BucketName"),
+			Value: bucket.bucket.Name,
+		},
+	}
+
+	if isS3StorageMetric(metric) {
+		if bucket.hasTransition {
+			return nil, false
+		}
+		dimensions = append(dimensions, &awsCloudwatch.Dimension{
+			Name:  awsClient.String("StorageType"),
+			Value: awsClient.String("StandardStorage"),
+		})
+	} else {
+		if bucket.requestsMetricsID == nil {
+			return nil, false
+		}
+		dimensions = append(dimensions, &awsCloudwatch.Dimension{
+			Name:  awsClient.String("FilterId"),
+			Value: bucket.requestsMetricsID,
+		})
+	}
+
+	period := int64(metric.Period.Seconds())
+	metricEndTime := now.Add(time.Duration(-metric.StartTime))
+
+	return &awsCloudwatch.GetMetricStatisticsInput{
+		Namespace:  &sm.namespace,
+		Period:     &period,
+		StartTime:  &metricEndTime,
+		EndTime:    &now,
+		Dimensions: dimensions,
+	}, true
+}
+
+// getBucketTags returns the bucket tags, a bucket without tags returns an empty map
+func (sm *S3Manager) getBucketTags(bucket *s3Bucket) map[string]string {
+
+	tagsData := map[string]string{}
+	tags, err := sm.regionClient(bucket.region).GetBucketTagging(&s3.GetBucketTaggingInput{
+		Bucket: bucket.bucket.Name,
+	})
+	if err != nil {
+		if !isAWSErrorCode(err, "NoSuchTagSet") {
+			log.WithError(err).WithField("bucket", *bucket.bucket.Name).Error("could not get s3 bucket tags")
+		}
+		return tagsData
+	}
+
+	for _, tag := range tags.TagSet {
+		tagsData[*tag.Key] = *tag.Value
+	}
+
+	return tagsData
+}
+
+// getStoragePrice returns the price per GB-month of the first usage tier of the storage type in the given region. The
+// standard and intelligent tiering storage are priced by usage tiers, and the first tier is the price of most buckets
+func (sm *S3Manager) getStoragePrice(storageType string, region string) float64 {
+
+	pricingRegionPrefix, err := sm.awsManager.GetPricingClient().GetRegionPrefix(region)
+	if err != nil {
+		log.WithError(err).WithField("region", region).Error("could not get s3 pricing region prefix")
+		return 0
+	}
+
+	pricingFilters := sm.getPricingFilterInput(s3StorageTypes[storageType], pricingRegionPrefix)
+	price, err := sm.awsManager.GetPricingClient().GetPrice(pricingFilters, pricing.FirstTierRateCode, region)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"storage_type":  storageType,
+			"region":        region,
+			"price_filters": pricingFilters,
+		}).Error("could not get s3 storage price")
+	}
+
+	return price
+}
+
+// getPricingFilterInput returns the s3 storage price filter per GB-month of the given storage type, the usage type
+// filter matches a single product of the storage type
+func (sm *S3Manager) getPricingFilterInput(storageType s3StorageType, pricingRegionPrefix string) awsPricing.GetProductsInput {
+
+	return awsPricing.GetProductsInput{
+		ServiceCode: &sm.servicePricingCode,
+		Filters: []*awsPricing.Filter{
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("productFamily"),
+				Value: awsClient.String("Storage"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("volumeType"),
+				Value: awsClient.String(storageType.volumeType),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("usagetype"),
+				Value: awsClient.String(pricingRegionPrefix + storageType.usageType),
+			},
+		},
+	}
+}
+
+// isS3StorageMetric returns true when the metric configuration is based on the bucket storage size
+func isS3StorageMetric(metric config.MetricConfig) bool {
+	for _, data := range metric.Data {
+		if data.Name == s3StorageMetricName {
+			return true
+		}
+	}
+	return false
+}
+
+// isAWSErrorCode returns true when the given error is an aws error with the given code
+func isAWSErrorCode(err error, code string) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == code
+}
@@ -0,0 +1,333 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector/aws/pricing"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	"finala/collector/testutils"
+	collectorTestutils "finala/collector/testutils"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	awsPricing "github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	// bucketSizeMock defines the size of all the mocked buckets storage types, 200GB
+	bucketSizeMock = 200 * bytesInGB
+)
+
+var defaultS3BucketsMock = s3.ListBucketsOutput{
+	Buckets: []*s3.Bucket{
+		// No requests, with a lifecycle transition
+		{Name: awsClient.String("bucket-idle"), CreationDate: testutils.TimePointer(time.Now())},
+		// Large, without a lifecycle configuration
+		{Name: awsClient.String("bucket-large"), CreationDate: testutils.TimePointer(time.Now())},
+		// Large, with a lifecycle transition and without request metrics of the entire bucket
+		{Name: awsClient.String("bucket-lifecycle"), CreationDate: testutils.TimePointer(time.Now())},
+	},
+}
+
+var defaultS3LifecycleMock = s3.GetBucketLifecycleConfigurationOutput{
+	Rules: []*s3.LifecycleRule{
+		{
+			Status: awsClient.String("Disabled"),
+			Transitions: []*s3.Transition{
+				{Days: awsClient.Int64(1), StorageClass: awsClient.String("GLACIER")},
+			},
+		},
+		{
+			Status: awsClient.String("Enabled"),
+			Transitions: []*s3.Transition{
+				{Days: awsClient.Int64(30), StorageClass: awsClient.String("STANDARD_IA")},
+			},
+		},
+	},
+}
+
+var defaultS3MetricsMock = map[string]cloudwatch.GetMetricStatisticsOutput{
+	"BucketSizeBytes": {
+		Datapoints: []*cloudwatch.Datapoint{
+			{Average: testutils.Float64Pointer(bucketSizeMock), Maximum: testutils.Float64Pointer(bucketSizeMock)},
+		},
+	},
+	"NumberOfObjects": {
+		Datapoints: []*cloudwatch.Datapoint{
+			{Maximum: testutils.Float64Pointer(10)},
+		},
+	},
+	"GetRequests": {
+		Datapoints: []*cloudwatch.Datapoint{
+			{Sum: testutils.Float64Pointer(0)},
+		},
+	},
+	"PutRequests": {
+		Datapoints: []*cloudwatch.Datapoint{
+			{Sum: testutils.Float64Pointer(0)},
+		},
+	},
+}
+
+type MockAWSS3Client struct {
+	responseListBuckets s3.ListBucketsOutput
+	err                 error
+}
+
+func (r *MockAWSS3Client) ListBuckets(*s3.ListBucketsInput) (*s3.ListBucketsOutput, error) {
+	return &r.responseListBuckets, r.err
+}
+
+func (r *MockAWSS3Client) GetBucketLocation(*s3.GetBucketLocationInput) (*s3.GetBucketLocationOutput, error) {
+	return &s3.GetBucketLocationOutput{}, r.err
+}
+
+func (r *MockAWSS3Client) GetBucketLifecycleConfiguration(input *s3.GetBucketLifecycleConfigurationInput) (*s3.GetBucketLifecycleConfigurationOutput, error) {
+	if *input.Bucket == "bucket-large" {
+		return nil, awserr.New("NoSuchLifecycleConfiguration", "The lifecycle configuration does not exist", nil)
+	}
+	return &defaultS3LifecycleMock, r.err
+}
+
+func (r *MockAWSS3Client) ListBucketMetricsConfigurations(input *s3.ListBucketMetricsConfigurationsInput) (*s3.ListBucketMetricsConfigurationsOutput, error) {
+
+	switch *input.Bucket {
+	case "bucket-idle":
+		return &s3.ListBucketMetricsConfigurationsOutput{
+			MetricsConfigurationList: []*s3.MetricsConfiguration{{Id: awsClient.String("EntireBucket")}},
+		}, r.err
+	case "bucket-lifecycle":
+		return &s3.ListBucketMetricsConfigurationsOutput{
+			MetricsConfigurationList: []*s3.MetricsConfiguration{
+				{Id: awsClient.String("Logs"), Filter: &s3.MetricsFilter{Prefix: awsClient.String("logs/")}},
+			},
+		}, r.err
+	}
+	return &s3.ListBucketMetricsConfigurationsOutput{}, r.err
+}
+
+func (r *MockAWSS3Client) GetBucketTagging(input *s3.GetBucketTaggingInput) (*s3.GetBucketTaggingOutput, error) {
+	if *input.Bucket != "bucket-large" {
+		return nil, awserr.New("NoSuchTagSet", "The TagSet does not exist", nil)
+	}
+	return &s3.GetBucketTaggingOutput{
+		TagSet: []*s3.Tag{{Key: awsClient.String("team"), Value: awsClient.String("a")}},
+	}, r.err
+}
+
+func TestDetectS3Buckets(t *testing.T) {
+
+	var defaultMetricConfig = []config.MetricConfig{
+		{
+			Description: "Bucket requests",
+			Data: []config.MetricDataConfiguration{
+				{Name: "GetRequests", Statistic: "Sum"},
+				{Name: "PutRequests", Statistic: "Sum"},
+			},
+			Period:    24 * time.Hour,
+			StartTime: 720 * time.Hour,
+			Constraint: config.MetricConstraintConfig{
+				Formula:  "GetRequests + PutRequests",
+				Operator: "==",
+				Value:    0,
+			},
+		},
+		{
+			Description: "Standard storage without lifecycle transition",
+			Data: []config.MetricDataConfiguration{
+				{Name: "BucketSizeBytes", Statistic: "Average"},
+			},
+			Period:    24 * time.Hour,
+			StartTime: 48 * time.Hour,
+			Constraint: config.MetricConstraintConfig{
+				Operator: ">=",
+				Value:    100 * bytesInGB,
+			},
+		},
+	}
+
+	t.Run("detect", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(&defaultS3MetricsMock)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		s3Interface, err := NewS3Manager(detector, &MockAWSS3Client{responseListBuckets: defaultS3BucketsMock})
+		if err != nil {
+			t.Fatalf("unexpected s3 manager error happened, got %v expected %v", err, nil)
+		}
+
+		s3Manager, ok := s3Interface.(*S3Manager)
+		if !ok {
+			t.Fatalf("unexpected s3 struct, got %s expected %s", reflect.TypeOf(s3Interface), "*S3Manager")
+		}
+
+		response, _ := s3Manager.Detect(defaultMetricConfig)
+
+		bucketsResponse, ok := response.([]DetectedS3Bucket)
+		if !ok {
+			t.Fatalf("unexpected s3 struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedS3Bucket")
+		}
+
+		if len(bucketsResponse) != 2 {
+			t.Fatalf("unexpected s3 buckets detected, got %d expected %d", len(bucketsResponse), 2)
+		}
+
+		if len(collector.Events) != 2 {
+			t.Fatalf("unexpected collector s3 resources, got %d expected %d", len(collector.Events), 2)
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource status events count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+
+		expected := []struct {
+			name   string
+			metric string
+		}{
+			{"bucket-idle", "Bucket requests"},
+			{"bucket-large", "Standard storage without lifecycle transition"},
+		}
+		for i, bucket := range bucketsResponse {
+			if bucket.Name != expected[i].name || bucket.Metric != expected[i].metric {
+				t.Fatalf("unexpected detected bucket, got %s (%s) expected %s (%s)", bucket.Name, bucket.Metric, expected[i].name, expected[i].metric)
+			}
+
+			// The mocked size is returned for all the storage types and priced with the same mocked price
+			expectedPrice := float64(200 * len(s3StorageTypes))
+			if bucket.PricePerMonth != expectedPrice {
+				t.Fatalf("unexpected bucket price per month, got %v expected %v", bucket.PricePerMonth, expectedPrice)
+			}
+
+			if bucket.ObjectsCount != 10 {
+				t.Fatalf("unexpected bucket objects count, got %d expected %d", bucket.ObjectsCount, 10)
+			}
+		}
+
+		if bucketsResponse[1].ResourceID != "arn:aws:s3:::bucket-large" {
+			t.Fatalf("unexpected bucket resource id, got %s expected %s", bucketsResponse[1].ResourceID, "arn:aws:s3:::bucket-large")
+		}
+
+		if bucketsResponse[1].Tag["team"] != "a" {
+			t.Fatalf("unexpected bucket tags, got %v", bucketsResponse[1].Tag)
+		}
+	})
+
+	t.Run("list error", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+		s3Interface, err := NewS3Manager(detector, &MockAWSS3Client{err: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected s3 manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = s3Interface.Detect(defaultMetricConfig)
+		if err == nil {
+			t.Fatalf("unexpected list buckets error, return empty")
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource status events count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+	})
+}
+
+func TestS3GlobalResource(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+	s3Interface, err := NewS3Manager(detector, &MockAWSS3Client{})
+	if err != nil || s3Interface == nil {
+		t.Fatalf("unexpected s3 manager, got %v error %v", s3Interface, err)
+	}
+
+	s3Interface, err = NewS3Manager(detector, &MockAWSS3Client{})
+	if err != nil || s3Interface != nil {
+		t.Fatalf("unexpected second s3 manager of the global resource, got %v error %v", s3Interface, err)
+	}
+
+	// Another account that shares the global resources has its own buckets
+	s3Interface, err = NewS3Manager(detector.ForAccount("5678"), &MockAWSS3Client{})
+	if err != nil || s3Interface == nil {
+		t.Fatalf("unexpected s3 manager of another account, got %v error %v", s3Interface, err)
+	}
+}
+
+// MockAWSS3PricingClient records the products filters of the pricing requests
+type MockAWSS3PricingClient struct {
+	awsTestutils.MockAWSPricingClient
+	inputs []*awsPricing.GetProductsInput
+}
+
+func (r *MockAWSS3PricingClient) GetProducts(input *awsPricing.GetProductsInput) (*awsPricing.GetProductsOutput, error) {
+	r.inputs = append(r.inputs, input)
+	return r.MockAWSPricingClient.GetProducts(input)
+}
+
+func TestS3StoragePrice(t *testing.T) {
+
+	testCases := []struct {
+		region            string
+		storageType       string
+		expectedUsageType string
+	}{
+		{"us-east-1", "StandardStorage", "TimedStorage-ByteHrs"},
+		{"eu-west-1", "StandardStorage", "EUW1-TimedStorage-ByteHrs"},
+		{"eu-west-1", "IntelligentTieringFAStorage", "EUW1-TimedStorage-INT-FA-ByteHrs"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.region+" "+test.storageType, func(t *testing.T) {
+
+			mockPricingClient := &MockAWSS3PricingClient{
+				MockAWSPricingClient: awsTestutils.MockAWSPricingClient{
+					Response: awsClient.JSONValue{
+						"product": pricing.PricingProduct{SKU: "R6PXMNYCEDGZ2EYN"},
+						"Terms": pricing.PricingTerms{
+							OnDemand: map[string]*pricing.PricingOfferTerm{
+								"R6PXMNYCEDGZ2EYN.JRTCKXETXF": {
+									PriceDimensions: map[string]*pricing.PriceRateCode{
+										"R6PXMNYCEDGZ2EYN.JRTCKXETXF.PXJDJ3YRG3": {BeginRange: "512000", PricePerUnit: pricing.PriceCurrencyCode{USD: "0.021"}},
+										"R6PXMNYCEDGZ2EYN.JRTCKXETXF.PGHJ3S3EYE": {BeginRange: "0", PricePerUnit: pricing.PriceCurrencyCode{USD: "0.023"}},
+										"R6PXMNYCEDGZ2EYN.JRTCKXETXF.D42MF2PVJS": {BeginRange: "51200", PricePerUnit: pricing.PriceCurrencyCode{USD: "0.022"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+			detector := awsTestutils.AWSManager(collectorTestutils.NewMockCollector(), nil, pricing.NewPricingManager(mockPricingClient, "us-east-1"), "us-east-1")
+			s3Manager := &S3Manager{awsManager: detector, servicePricingCode: "AmazonS3"}
+
+			// The first tier price of the storage type
+			price := s3Manager.getStoragePrice(test.storageType, test.region)
+			if price != 0.023 {
+				t.Fatalf("unexpected s3 storage price, got %v expected %v", price, 0.023)
+			}
+
+			if len(mockPricingClient.inputs) != 1 {
+				t.Fatalf("unexpected pricing requests count, got %d expected %d", len(mockPricingClient.inputs), 1)
+			}
+
+			var usageType string
+			for _, filter := range mockPricingClient.inputs[0].Filters {
+				if *filter.Field == "usagetype" {
+					usageType = *filter.Value
+				}
+			}
+			if usageType != test.expectedUsageType {
+				t.Fatalf("unexpected s3 storage usage type filter, got %q expected %q", usageType, test.expectedUsageType)
+			}
+		})
+	}
+}
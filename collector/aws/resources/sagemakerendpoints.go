@@ -0,0 +1,368 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/cloudwatch"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"fmt"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/aws/aws-sdk-go/service/sagemaker"
+	log "github.com/sirupsen/logrus"
+)
+
+// sageMakerRegionPrefixes defines the SageMaker usage types prefixes of the regions without a pricing region prefix,
+// the SageMaker usage types are prefixed in every region
+var sageMakerRegionPrefixes = map[string]string{
+	"us-east-1":  "USE1-",
+	"ap-south-1": "APS3-",
+}
+
+// SageMakerEndpointsClientDescreptor is an interface defining the aws sagemaker endpoints client
+type SageMakerEndpointsClientDescreptor interface {
+	ListEndpoints(*sagemaker.ListEndpointsInput) (*sagemaker.ListEndpointsOutput, error)
+	DescribeEndpoint(*sagemaker.DescribeEndpointInput) (*sagemaker.DescribeEndpointOutput, error)
+	DescribeEndpointConfig(*sagemaker.DescribeEndpointConfigInput) (*sagemaker.DescribeEndpointConfigOutput, error)
+	ListTags(*sagemaker.ListTagsInput) (*sagemaker.ListTagsOutput, error)
+}
+
+// sageMakerTagsClient is an interface defining the aws sagemaker client that lists the resources tags
+type sageMakerTagsClient interface {
+	ListTags(*sagemaker.ListTagsInput) (*sagemaker.ListTagsOutput, error)
+}
+
+// SageMakerEndpointsManager describes the SageMaker endpoints struct
+type SageMakerEndpointsManager struct {
+	client             SageMakerEndpointsClientDescreptor
+	awsManager         common.AWSManager
+	namespace          string
+	servicePricingCode string
+	Name               collector.ResourceIdentifier
+}
+
+// DetectedSageMakerEndpoint defines the detected AWS SageMaker endpoint variants
+type DetectedSageMakerEndpoint struct {
+	Region        string
+	Metric        string
+	EndpointName  string
+	VariantName   string
+	InstanceType  string
+	InstanceCount int64
+	collector.PriceDetectedFields
+	collector.MetricDetectedFields
+}
+
+// sageMakerEndpointVariant describes a production variant of an endpoint and the instances serving it
+type sageMakerEndpointVariant struct {
+	name          string
+	instanceType  string
+	instanceCount int64
+}
+
+func init() {
+	register.Registry("sagemaker_endpoints", NewSageMakerEndpointsManager)
+}
+
+// NewSageMakerEndpointsManager implements AWS GO SDK
+func NewSageMakerEndpointsManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = sagemaker.New(awsManager.GetSession())
+	}
+
+	sageMakerClient, ok := client.(SageMakerEndpointsClientDescreptor)
+	if !ok {
+		return nil, errors.New("invalid sagemaker endpoints client")
+	}
+
+	return &SageMakerEndpointsManager{
+		client:             sageMakerClient,
+		awsManager:         awsManager,
+		namespace:          "AWS/SageMaker",
+		servicePricingCode: "AmazonSageMaker",
+		Name:               awsManager.GetResourceIdentifier("sagemaker_endpoints"),
+	}, nil
+}
+
+// Detect check which SageMaker endpoint variants are under utilized. The variant cost is the hosting price of its instances.
+// Each endpoint variant is reported once, by the first metric that detects it
+func (sem *SageMakerEndpointsManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	log.WithFields(log.Fields{
+		"region":   sem.awsManager.GetRegion(),
+		"resource": "sagemaker_endpoints",
+	}).Info("starting to analyze resource")
+
+	sem.awsManager.GetCollector().CollectStart(sem.Name)
+
+	detected := []DetectedSageMakerEndpoint{}
+
+	pricingRegionPrefix, err := getSageMakerRegionPrefix(sem.awsManager)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region": sem.awsManager.GetRegion(),
+		}).Error("Could not get pricing region prefix")
+		sem.awsManager.GetCollector().CollectError(sem.Name, err)
+		return detected, err
+	}
+
+	endpoints, err := sem.listEndpoints(nil, nil)
+	if err != nil {
+		log.WithError(err).Error("could not list sagemaker endpoints")
+		sem.awsManager.GetCollector().CollectError(sem.Name, err)
+		return detected, err
+	}
+
+	// The hourly price of each instance type, most of the endpoints share the same few instance types
+	instanceTypePrices := map[string]float64{}
+
+	now := time.Now()
+	batch := sem.awsManager.GetCloudWatchClient().NewBatch()
+	reported := map[string]struct{}{}
+
+	for _, endpoint := range endpoints {
+		endpoint := endpoint
+		log.WithField("endpoint_name", *endpoint.EndpointName).Debug("checking sagemaker endpoint")
+
+		variants, err := sem.describeEndpointVariants(endpoint.EndpointName)
+		if err != nil {
+			log.WithError(err).WithField("endpoint_name", *endpoint.EndpointName).Error("could not describe sagemaker endpoint")
+			continue
+		}
+
+		tagsData, err := getSageMakerTags(sem.client, endpoint.EndpointArn)
+		if err != nil {
+			log.WithError(err).WithField("endpoint_name", *endpoint.EndpointName).Error("could not list sagemaker endpoint tags")
+		}
+
+		for _, variant := range variants {
+			variant := variant
+
+			price, ok := instanceTypePrices[variant.instanceType]
+			if !ok {
+				pricingFilters := getSageMakerPricingFilterInput(sem.servicePricingCode, fmt.Sprintf("%sHost:%s", pricingRegionPrefix, variant.instanceType))
+				price, err = sem.awsManager.GetPricingClient().GetPrice(pricingFilters, "", sem.awsManager.GetRegion())
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"instance_type": variant.instanceType,
+						"price_filters": pricingFilters,
+					}).Error("could not get sagemaker endpoint instance price")
+				}
+				instanceTypePrices[variant.instanceType] = price
+			}
+			pricePerHour := price * float64(variant.instanceCount)
+			variantID := fmt.Sprintf("%s/%s", *endpoint.EndpointArn, variant.name)
+
+			for _, metric := range metrics {
+				metric := metric
+				log.WithFields(log.Fields{
+					"endpoint_name": *endpoint.EndpointName,
+					"variant_name":  variant.name,
+					"metric_name":   metric.Description,
+				}).Debug("checking metric")
+
+				period := int64(metric.Period.Seconds())
+				metricEndTime := now.Add(time.Duration(-metric.StartTime))
+				metricInput := awsCloudwatch.GetMetricStatisticsInput{
+					Namespace:  &sem.namespace,
+					MetricName: &metric.Description,
+					Period:     &period,
+					StartTime:  &metricEndTime,
+					EndTime:    &now,
+					Dimensions: []*awsCloudwatch.Dimension{
+						{
+							Name:  awsClient.String("EndpointName"),
+							Value: endpoint.EndpointName,
+						},
+						{
+							Name:  awsClient.String("VariantName"),
+							Value: &variant.name,
+						},
+					},
+				}
+
+				batch.Add(&metricInput, metric, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
+					if err != nil {
+						log.WithError(err).WithFields(log.Fields{
+							"endpoint_name": *endpoint.EndpointName,
+							"metric_name":   metric.Description,
+						}).Error("Could not get cloudwatch metric data")
+						return
+					}
+
+					if _, found := reported[variantID]; found {
+						return
+					}
+
+					expression, err := metric.Constraint.Evaluate(formulaValue, metricsValues)
+					if err != nil || !expression {
+						return
+					}
+					reported[variantID] = struct{}{}
+
+					log.WithFields(log.Fields{
+						"metric_name":         metric.Description,
+						"constraint_operator": metric.Constraint.Operator,
+						"constraint_Value":    metric.Constraint.Value,
+						"formula_value":       formulaValue,
+						"endpoint_name":       *endpoint.EndpointName,
+						"variant_name":        variant.name,
+						"region":              sem.awsManager.GetRegion(),
+					}).Info("SageMaker endpoint detected as unutilized resource")
+
+					endpointData := DetectedSageMakerEndpoint{
+						Region:        sem.awsManager.GetRegion(),
+						Metric:        metric.Description,
+						EndpointName:  *endpoint.EndpointName,
+						VariantName:   variant.name,
+						InstanceType:  variant.instanceType,
+						InstanceCount: variant.instanceCount,
+						PriceDetectedFields: collector.PriceDetectedFields{
+							ResourceID:    *endpoint.EndpointArn,
+							LaunchTime:    awsClient.TimeValue(endpoint.CreationTime),
+							PricePerHour:  pricePerHour,
+							PricePerMonth: pricePerHour * collector.TotalMonthHours,
+							Tag:           tagsData,
+						},
+						MetricDetectedFields: cloudwatch.NewMetricDetectedFields(metric, &metricInput, formulaValue, metricsValues),
+					}
+
+					sem.awsManager.GetCollector().AddResource(collector.EventCollector{
+						ResourceName: sem.Name,
+						Data:         endpointData,
+					})
+
+					detected = append(detected, endpointData)
+				})
+			}
+		}
+	}
+
+	batch.Execute()
+
+	sem.awsManager.GetCollector().CollectFinish(sem.Name)
+
+	return detected, nil
+}
+
+// describeEndpointVariants returns the production variants of the endpoint. The endpoint describes the current instances
+// count of each variant, and the endpoint configuration describes their instance type
+func (sem *SageMakerEndpointsManager) describeEndpointVariants(endpointName *string) ([]sageMakerEndpointVariant, error) {
+
+	endpoint, err := sem.client.DescribeEndpoint(&sagemaker.DescribeEndpointInput{
+		EndpointName: endpointName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	endpointConfig, err := sem.client.DescribeEndpointConfig(&sagemaker.DescribeEndpointConfigInput{
+		EndpointConfigName: endpoint.EndpointConfigName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	instanceTypes := map[string]string{}
+	for _, variant := range endpointConfig.ProductionVariants {
+		instanceTypes[*variant.VariantName] = awsClient.StringValue(variant.InstanceType)
+	}
+
+	variants := []sageMakerEndpointVariant{}
+	for _, variant := range endpoint.ProductionVariants {
+		variants = append(variants, sageMakerEndpointVariant{
+			name:          *variant.VariantName,
+			instanceType:  instanceTypes[*variant.VariantName],
+			instanceCount: awsClient.Int64Value(variant.CurrentInstanceCount),
+		})
+	}
+
+	return variants, nil
+}
+
+// listEndpoints returns the in service SageMaker endpoints
+func (sem *SageMakerEndpointsManager) listEndpoints(nextToken *string, endpoints []*sagemaker.EndpointSummary) ([]*sagemaker.EndpointSummary, error) {
+
+	input := &sagemaker.ListEndpointsInput{
+		NextToken:    nextToken,
+		StatusEquals: awsClient.String(sagemaker.EndpointStatusInService),
+	}
+
+	resp, err := sem.client.ListEndpoints(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if endpoints == nil {
+		endpoints = []*sagemaker.EndpointSummary{}
+	}
+
+	endpoints = append(endpoints, resp.Endpoints...)
+
+	if resp.NextToken != nil {
+		return sem.listEndpoints(resp.NextToken, endpoints)
+	}
+
+	return endpoints, nil
+}
+
+// getSageMakerRegionPrefix returns the SageMaker usage types prefix of the manager region
+func getSageMakerRegionPrefix(awsManager common.AWSManager) (string, error) {
+
+	if prefix, found := sageMakerRegionPrefixes[awsManager.GetRegion()]; found {
+		return prefix, nil
+	}
+
+	return awsManager.GetPricingClient().GetRegionPrefix(awsManager.GetRegion())
+}
+
+// getSageMakerPricingFilterInput prepares the hourly price filter of a SageMaker instance by its usage type,
+// for example "USE1-Host:ml.m5.large" for a hosting instance
+func getSageMakerPricingFilterInput(servicePricingCode string, usageType string) pricing.GetProductsInput {
+	return pricing.GetProductsInput{
+		ServiceCode: &servicePricingCode,
+		Filters: []*pricing.Filter{
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("termType"),
+				Value: awsClient.String("OnDemand"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("usagetype"),
+				Value: &usageType,
+			},
+		},
+	}
+}
+
+// getSageMakerTags returns the tags of a SageMaker resource
+func getSageMakerTags(client sageMakerTagsClient, resourceArn *string) (map[string]string, error) {
+
+	tagsData := map[string]string{}
+	input := &sagemaker.ListTagsInput{
+		ResourceArn: resourceArn,
+	}
+
+	for {
+		resp, err := client.ListTags(input)
+		if err != nil {
+			return tagsData, err
+		}
+
+		for _, tag := range resp.Tags {
+			tagsData[*tag.Key] = *tag.Value
+		}
+
+		if resp.NextToken == nil {
+			return tagsData, nil
+		}
+		input.NextToken = resp.NextToken
+	}
+}
@@ -0,0 +1,177 @@
+package resources
+
+import (
+	"errors"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	collectorTestutils "finala/collector/testutils"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sagemaker"
+)
+
+var defaultSageMakerEndpointsMock = sagemaker.ListEndpointsOutput{
+	Endpoints: []*sagemaker.EndpointSummary{
+		{
+			EndpointName: awsClient.String("endpoint-1"),
+			EndpointArn:  awsClient.String("arn:aws:sagemaker:us-east-1:1234:endpoint/endpoint-1"),
+			CreationTime: collectorTestutils.TimePointer(time.Now()),
+		},
+	},
+}
+
+var defaultSageMakerEndpointMock = sagemaker.DescribeEndpointOutput{
+	EndpointName:       awsClient.String("endpoint-1"),
+	EndpointConfigName: awsClient.String("endpoint-config-1"),
+	ProductionVariants: []*sagemaker.ProductionVariantSummary{
+		{VariantName: awsClient.String("variant-1"), CurrentInstanceCount: awsClient.Int64(2)},
+		{VariantName: awsClient.String("variant-2"), CurrentInstanceCount: awsClient.Int64(1)},
+	},
+}
+
+var defaultSageMakerEndpointConfigMock = sagemaker.DescribeEndpointConfigOutput{
+	EndpointConfigName: awsClient.String("endpoint-config-1"),
+	ProductionVariants: []*sagemaker.ProductionVariant{
+		{VariantName: awsClient.String("variant-1"), InstanceType: awsClient.String("ml.m5.large")},
+		{VariantName: awsClient.String("variant-2"), InstanceType: awsClient.String("ml.c5.xlarge")},
+	},
+}
+
+type MockAWSSageMakerEndpointsClient struct {
+	err error
+}
+
+func (r *MockAWSSageMakerEndpointsClient) ListEndpoints(*sagemaker.ListEndpointsInput) (*sagemaker.ListEndpointsOutput, error) {
+	return &defaultSageMakerEndpointsMock, r.err
+}
+
+func (r *MockAWSSageMakerEndpointsClient) DescribeEndpoint(*sagemaker.DescribeEndpointInput) (*sagemaker.DescribeEndpointOutput, error) {
+	return &defaultSageMakerEndpointMock, r.err
+}
+
+func (r *MockAWSSageMakerEndpointsClient) DescribeEndpointConfig(*sagemaker.DescribeEndpointConfigInput) (*sagemaker.DescribeEndpointConfigOutput, error) {
+	return &defaultSageMakerEndpointConfigMock, r.err
+}
+
+func (r *MockAWSSageMakerEndpointsClient) ListTags(*sagemaker.ListTagsInput) (*sagemaker.ListTagsOutput, error) {
+	return &sagemaker.ListTagsOutput{
+		Tags: []*sagemaker.Tag{{Key: awsClient.String("team"), Value: awsClient.String("a")}},
+	}, r.err
+}
+
+func TestDetectSageMakerEndpoints(t *testing.T) {
+
+	t.Run("detect", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		endpointsInterface, err := NewSageMakerEndpointsManager(detector, &MockAWSSageMakerEndpointsClient{})
+		if err != nil {
+			t.Fatalf("unexpected sagemaker endpoints manager error happened, got %v expected %v", err, nil)
+		}
+
+		endpointsManager, ok := endpointsInterface.(*SageMakerEndpointsManager)
+		if !ok {
+			t.Fatalf("unexpected sagemaker endpoints struct, got %s expected %s", reflect.TypeOf(endpointsInterface), "*SageMakerEndpointsManager")
+		}
+
+		// The endpoint variants match both metrics, and each variant is reported once
+		metrics := append(append([]config.MetricConfig{}, awsTestutils.DefaultMetricConfig...), awsTestutils.DefaultMetricConfig...)
+		response, err := endpointsManager.Detect(metrics)
+		if err != nil {
+			t.Fatalf("unexpected sagemaker endpoints error happened, got %v expected %v", err, nil)
+		}
+
+		endpointsResponse, ok := response.([]DetectedSageMakerEndpoint)
+		if !ok {
+			t.Fatalf("unexpected sagemaker endpoints struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedSageMakerEndpoint")
+		}
+
+		if len(endpointsResponse) != 2 {
+			t.Fatalf("unexpected sagemaker endpoint variants detected, got %d expected %d", len(endpointsResponse), 2)
+		}
+
+		if len(collector.Events) != 2 {
+			t.Fatalf("unexpected collector sagemaker endpoints events, got %d expected %d", len(collector.Events), 2)
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource event collection status count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+
+		expected := []struct {
+			variantName  string
+			instanceType string
+			pricePerHour float64
+		}{
+			{"variant-1", "ml.m5.large", 2},
+			{"variant-2", "ml.c5.xlarge", 1},
+		}
+		for i, endpoint := range endpointsResponse {
+			if endpoint.VariantName != expected[i].variantName || endpoint.InstanceType != expected[i].instanceType || endpoint.PricePerHour != expected[i].pricePerHour {
+				t.Fatalf("unexpected sagemaker endpoint variant, got %s (%s) priced %v expected %s (%s) priced %v", endpoint.VariantName, endpoint.InstanceType, endpoint.PricePerHour, expected[i].variantName, expected[i].instanceType, expected[i].pricePerHour)
+			}
+
+			if endpoint.Tag["team"] != "a" {
+				t.Fatalf("unexpected sagemaker endpoint tags, got %v", endpoint.Tag)
+			}
+		}
+	})
+
+	t.Run("detection error", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		endpointsManager, err := NewSageMakerEndpointsManager(detector, &MockAWSSageMakerEndpointsClient{err: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected sagemaker endpoints manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = endpointsManager.Detect(awsTestutils.DefaultMetricConfig)
+		if err == nil {
+			t.Fatalf("unexpected list sagemaker endpoints error, return empty")
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource event collection status count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+	})
+}
+
+func TestGetSageMakerPricingFilterInput(t *testing.T) {
+
+	testCases := []struct {
+		region            string
+		expectedUsageType string
+	}{
+		{"us-east-1", "USE1-Host:ml.m5.large"},
+		{"ap-south-1", "APS3-Host:ml.m5.large"},
+		{"eu-west-1", "EUW1-Host:ml.m5.large"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.region, func(t *testing.T) {
+			detector := awsTestutils.AWSManager(collectorTestutils.NewMockCollector(), nil, awsTestutils.NewMockPricing(nil), test.region)
+
+			pricingRegionPrefix, err := getSageMakerRegionPrefix(detector)
+			if err != nil {
+				t.Fatalf("unexpected sagemaker region prefix error happened, got %v expected %v", err, nil)
+			}
+
+			pricingFilters := getSageMakerPricingFilterInput("AmazonSageMaker", fmt.Sprintf("%sHost:%s", pricingRegionPrefix, "ml.m5.large"))
+			for _, filter := range pricingFilters.Filters {
+				if *filter.Field == "usagetype" && *filter.Value != test.expectedUsageType {
+					t.Fatalf("unexpected sagemaker usage type filter, got %s expected %s", *filter.Value, test.expectedUsageType)
+				}
+			}
+		})
+	}
+}
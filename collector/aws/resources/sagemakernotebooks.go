@@ -0,0 +1,254 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"fmt"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	"github.com/aws/aws-sdk-go/service/sagemaker"
+	log "github.com/sirupsen/logrus"
+)
+
+// sageMakerNotebookStartEventNames defines the cloudtrail events that start a notebook instance
+var sageMakerNotebookStartEventNames = []string{"CreateNotebookInstance", "StartNotebookInstance"}
+
+// sageMakerNotebookOpenEventName defines the cloudtrail event of opening a notebook instance
+const sageMakerNotebookOpenEventName = "CreatePresignedNotebookInstanceUrl"
+
+// SageMakerNotebooksClientDescreptor is an interface defining the aws sagemaker notebook instances and their events client
+type SageMakerNotebooksClientDescreptor interface {
+	ListNotebookInstances(*sagemaker.ListNotebookInstancesInput) (*sagemaker.ListNotebookInstancesOutput, error)
+	ListTags(*sagemaker.ListTagsInput) (*sagemaker.ListTagsOutput, error)
+	LookupEvents(*cloudtrail.LookupEventsInput) (*cloudtrail.LookupEventsOutput, error)
+}
+
+// sageMakerNotebooksClient combines the sagemaker and the cloudtrail (notebook instance events) clients. Both clients
+// implement ListTags, so the cloudtrail client is not embedded
+type sageMakerNotebooksClient struct {
+	*sagemaker.SageMaker
+	cloudTrail *cloudtrail.CloudTrail
+}
+
+// LookupEvents looks up the notebook instances events with the cloudtrail client
+func (c *sageMakerNotebooksClient) LookupEvents(input *cloudtrail.LookupEventsInput) (*cloudtrail.LookupEventsOutput, error) {
+	return c.cloudTrail.LookupEvents(input)
+}
+
+// SageMakerNotebooksManager describes the SageMaker notebook instances struct
+type SageMakerNotebooksManager struct {
+	client             SageMakerNotebooksClientDescreptor
+	awsManager         common.AWSManager
+	servicePricingCode string
+	lookupInterval     time.Duration
+	Name               collector.ResourceIdentifier
+}
+
+// DetectedSageMakerNotebook defines the detected AWS SageMaker notebook instances
+type DetectedSageMakerNotebook struct {
+	Region       string
+	Metric       string
+	Name         string
+	InstanceType string
+	RunningDays  float64
+	IdleDays     float64
+	collector.PriceDetectedFields
+}
+
+func init() {
+	register.Registry("sagemaker_notebooks", NewSageMakerNotebooksManager)
+}
+
+// NewSageMakerNotebooksManager implements AWS GO SDK
+func NewSageMakerNotebooksManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = &sageMakerNotebooksClient{
+			SageMaker:  sagemaker.New(awsManager.GetSession()),
+			cloudTrail: cloudtrail.New(awsManager.GetSession()),
+		}
+	}
+
+	sageMakerClient, ok := client.(SageMakerNotebooksClientDescreptor)
+	if !ok {
+		return nil, errors.New("invalid sagemaker notebooks client")
+	}
+
+	return &SageMakerNotebooksManager{
+		client:             sageMakerClient,
+		awsManager:         awsManager,
+		servicePricingCode: "AmazonSageMaker",
+		lookupInterval:     cloudTrailLookupInterval,
+		Name:               awsManager.GetResourceIdentifier("sagemaker_notebooks"),
+	}, nil
+}
+
+// Detect SageMaker notebook instances that are idle for a long time. The last start and the last open of a notebook
+// instance are taken from the cloudtrail events in the window of the constraint days, and a notebook instance without
+// events in the window is considered running and idle since the window start
+func (snm *SageMakerNotebooksManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	// This resource support only one metric, the constraint value is the idle duration in days
+	metric := metrics[0]
+
+	log.WithFields(log.Fields{
+		"region":   snm.awsManager.GetRegion(),
+		"resource": "sagemaker_notebooks",
+	}).Info("starting to analyze resource")
+
+	snm.awsManager.GetCollector().CollectStart(snm.Name)
+
+	detected := []DetectedSageMakerNotebook{}
+
+	pricingRegionPrefix, err := getSageMakerRegionPrefix(snm.awsManager)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region": snm.awsManager.GetRegion(),
+		}).Error("Could not get pricing region prefix")
+		snm.awsManager.GetCollector().CollectError(snm.Name, err)
+		return detected, err
+	}
+
+	notebooks, err := snm.listNotebookInstances(nil, nil)
+	if err != nil {
+		log.WithError(err).Error("could not list sagemaker notebook instances")
+		snm.awsManager.GetCollector().CollectError(snm.Name, err)
+		return detected, err
+	}
+
+	if len(notebooks) == 0 {
+		snm.awsManager.GetCollector().CollectFinish(snm.Name)
+		return detected, nil
+	}
+
+	now := time.Now()
+	lookupStartTime := cloudTrailLookupStartTime(metric.Constraint, now)
+	lastStartTimes, lastOpenTimes, err := snm.getLastEventTimes(lookupStartTime, now)
+	if err != nil {
+		log.WithError(err).Error("could not lookup sagemaker notebook instances events")
+		snm.awsManager.GetCollector().CollectError(snm.Name, err)
+		return detected, err
+	}
+
+	for _, notebook := range notebooks {
+
+		log.WithField("notebook_name", *notebook.NotebookInstanceName).Debug("checking sagemaker notebook instance")
+
+		startTime, found := lastStartTimes[*notebook.NotebookInstanceName]
+		if !found {
+			startTime = lookupStartTime
+		}
+
+		lastActivityTime := startTime
+		if openTime, found := lastOpenTimes[*notebook.NotebookInstanceName]; found && openTime.After(lastActivityTime) {
+			lastActivityTime = openTime
+		}
+
+		runningDays := now.Sub(startTime).Hours() / 24
+		idleDays := now.Sub(lastActivityTime).Hours() / 24
+		expression, err := metric.Constraint.Evaluate(idleDays, nil)
+		if err != nil || !expression {
+			continue
+		}
+
+		instanceType := awsClient.StringValue(notebook.InstanceType)
+		pricingFilters := getSageMakerPricingFilterInput(snm.servicePricingCode, fmt.Sprintf("%sNotebk:%s", pricingRegionPrefix, instanceType))
+		price, err := snm.awsManager.GetPricingClient().GetPrice(pricingFilters, "", snm.awsManager.GetRegion())
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"notebook_name": *notebook.NotebookInstanceName,
+				"price_filters": pricingFilters,
+			}).Error("could not get sagemaker notebook instance price")
+		}
+
+		tagsData, err := getSageMakerTags(snm.client, notebook.NotebookInstanceArn)
+		if err != nil {
+			log.WithError(err).WithField("notebook_name", *notebook.NotebookInstanceName).Error("could not list sagemaker notebook instance tags")
+		}
+
+		notebookData := DetectedSageMakerNotebook{
+			Region:       snm.awsManager.GetRegion(),
+			Metric:       metric.Description,
+			Name:         *notebook.NotebookInstanceName,
+			InstanceType: instanceType,
+			RunningDays:  runningDays,
+			IdleDays:     idleDays,
+			PriceDetectedFields: collector.PriceDetectedFields{
+				ResourceID:    *notebook.NotebookInstanceArn,
+				LaunchTime:    awsClient.TimeValue(notebook.CreationTime),
+				PricePerHour:  price,
+				PricePerMonth: price * collector.TotalMonthHours,
+				Tag:           tagsData,
+			},
+		}
+
+		snm.awsManager.GetCollector().AddResource(collector.EventCollector{
+			ResourceName: snm.Name,
+			Data:         notebookData,
+		})
+
+		detected = append(detected, notebookData)
+	}
+
+	snm.awsManager.GetCollector().CollectFinish(snm.Name)
+
+	return detected, nil
+}
+
+// getLastEventTimes returns the time of the last start and the time of the last open of each notebook instance in the
+// given time range
+func (snm *SageMakerNotebooksManager) getLastEventTimes(startTime, endTime time.Time) (map[string]time.Time, map[string]time.Time, error) {
+
+	eventNames := append([]string{sageMakerNotebookOpenEventName}, sageMakerNotebookStartEventNames...)
+	eventTimes, err := lookupLastEventTimes(snm.client, eventNames, startTime, endTime, snm.lookupInterval, eventNotebookInstanceNames)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lastStartTimes := map[string]time.Time{}
+	for _, eventName := range sageMakerNotebookStartEventNames {
+		for notebookName, eventTime := range eventTimes[eventName] {
+			if lastStartTime, found := lastStartTimes[notebookName]; !found || eventTime.After(lastStartTime) {
+				lastStartTimes[notebookName] = eventTime
+			}
+		}
+	}
+
+	return lastStartTimes, eventTimes[sageMakerNotebookOpenEventName], nil
+}
+
+// eventNotebookInstanceNames returns the notebook instances names of the cloudtrail event request
+func eventNotebookInstanceNames(event *cloudtrail.Event) []string {
+	return cloudTrailEventFieldValues(event, "notebookInstanceName")
+}
+
+// listNotebookInstances returns the in service SageMaker notebook instances
+func (snm *SageMakerNotebooksManager) listNotebookInstances(nextToken *string, notebooks []*sagemaker.NotebookInstanceSummary) ([]*sagemaker.NotebookInstanceSummary, error) {
+
+	input := &sagemaker.ListNotebookInstancesInput{
+		NextToken:    nextToken,
+		StatusEquals: awsClient.String(sagemaker.NotebookInstanceStatusInService),
+	}
+
+	resp, err := snm.client.ListNotebookInstances(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if notebooks == nil {
+		notebooks = []*sagemaker.NotebookInstanceSummary{}
+	}
+
+	notebooks = append(notebooks, resp.NotebookInstances...)
+
+	if resp.NextToken != nil {
+		return snm.listNotebookInstances(resp.NextToken, notebooks)
+	}
+
+	return notebooks, nil
+}
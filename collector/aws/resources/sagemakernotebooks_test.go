@@ -0,0 +1,218 @@
+package resources
+
+import (
+	"errors"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	collectorTestutils "finala/collector/testutils"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	"github.com/aws/aws-sdk-go/service/sagemaker"
+)
+
+var defaultSageMakerNotebooksMock = sagemaker.ListNotebookInstancesOutput{
+	NotebookInstances: []*sagemaker.NotebookInstanceSummary{
+		{
+			// Started 30 and a half days ago, opened before the start
+			NotebookInstanceName: awsClient.String("notebook-1"),
+			NotebookInstanceArn:  awsClient.String("arn:aws:sagemaker:us-east-1:1234:notebook-instance/notebook-1"),
+			InstanceType:         awsClient.String("ml.t2.medium"),
+			CreationTime:         collectorTestutils.TimePointer(time.Now().AddDate(0, 0, -60)),
+			LastModifiedTime:     collectorTestutils.TimePointer(time.Now().Add(-time.Hour)),
+		},
+		{
+			// Started 30 and a half days ago and opened a day ago
+			NotebookInstanceName: awsClient.String("notebook-2"),
+			NotebookInstanceArn:  awsClient.String("arn:aws:sagemaker:us-east-1:1234:notebook-instance/notebook-2"),
+			InstanceType:         awsClient.String("ml.t2.medium"),
+			CreationTime:         collectorTestutils.TimePointer(time.Now().AddDate(0, 0, -60)),
+			LastModifiedTime:     collectorTestutils.TimePointer(time.Now().AddDate(0, 0, -40)),
+		},
+		{
+			// Without events in the cloudtrail history
+			NotebookInstanceName: awsClient.String("notebook-3"),
+			NotebookInstanceArn:  awsClient.String("arn:aws:sagemaker:us-east-1:1234:notebook-instance/notebook-3"),
+			InstanceType:         awsClient.String("ml.t2.medium"),
+			CreationTime:         collectorTestutils.TimePointer(time.Now().AddDate(0, 0, -200)),
+			LastModifiedTime:     collectorTestutils.TimePointer(time.Now().AddDate(0, 0, -150)),
+		},
+	},
+}
+
+// defaultSageMakerNotebooksEventsMock defines the notebook instances events by the event name
+var defaultSageMakerNotebooksEventsMock = map[string][]*cloudtrail.Event{
+	"CreateNotebookInstance": {
+		newSageMakerNotebookEventMock("notebook-1", time.Now().AddDate(0, 0, -60)),
+		newSageMakerNotebookEventMock("notebook-2", time.Now().AddDate(0, 0, -60)),
+	},
+	"StartNotebookInstance": {
+		newSageMakerNotebookEventMock("notebook-1", time.Now().Add(-30*24*time.Hour-12*time.Hour)),
+		newSageMakerNotebookEventMock("notebook-2", time.Now().Add(-30*24*time.Hour-12*time.Hour)),
+	},
+	"CreatePresignedNotebookInstanceUrl": {
+		newSageMakerNotebookEventMock("notebook-1", time.Now().AddDate(0, 0, -35)),
+		newSageMakerNotebookEventMock("notebook-2", time.Now().AddDate(0, 0, -30)),
+		newSageMakerNotebookEventMock("notebook-2", time.Now().AddDate(0, 0, -1)),
+	},
+}
+
+func newSageMakerNotebookEventMock(notebookName string, eventTime time.Time) *cloudtrail.Event {
+	return &cloudtrail.Event{
+		EventTime:       &eventTime,
+		CloudTrailEvent: awsClient.String(fmt.Sprintf(`{"requestParameters":{"notebookInstanceName":"%s"}}`, notebookName)),
+	}
+}
+
+type MockAWSSageMakerNotebooksClient struct {
+	err             error
+	lookupErr       error
+	lookupStartTime time.Time
+}
+
+func (r *MockAWSSageMakerNotebooksClient) ListNotebookInstances(*sagemaker.ListNotebookInstancesInput) (*sagemaker.ListNotebookInstancesOutput, error) {
+	return &defaultSageMakerNotebooksMock, r.err
+}
+
+func (r *MockAWSSageMakerNotebooksClient) ListTags(*sagemaker.ListTagsInput) (*sagemaker.ListTagsOutput, error) {
+	return &sagemaker.ListTagsOutput{
+		Tags: []*sagemaker.Tag{{Key: awsClient.String("team"), Value: awsClient.String("a")}},
+	}, r.err
+}
+
+func (r *MockAWSSageMakerNotebooksClient) LookupEvents(input *cloudtrail.LookupEventsInput) (*cloudtrail.LookupEventsOutput, error) {
+	r.lookupStartTime = *input.StartTime
+
+	// The events before the lookup start time are not returned
+	eventName := awsClient.StringValue(input.LookupAttributes[0].AttributeValue)
+	output := &cloudtrail.LookupEventsOutput{Events: []*cloudtrail.Event{}}
+	for _, event := range defaultSageMakerNotebooksEventsMock[eventName] {
+		if !event.EventTime.Before(*input.StartTime) {
+			output.Events = append(output.Events, event)
+		}
+	}
+	return output, r.lookupErr
+}
+
+func TestDetectSageMakerNotebooks(t *testing.T) {
+
+	var defaultMetricConfig = []config.MetricConfig{
+		{
+			Description: "Idle notebook instance",
+			Constraint: config.MetricConstraintConfig{
+				Operator: ">=",
+				Value:    30,
+			},
+		},
+	}
+
+	t.Run("detect", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, nil, mockPrice, "us-east-1")
+
+		client := &MockAWSSageMakerNotebooksClient{}
+		notebooksInterface, err := NewSageMakerNotebooksManager(detector, client)
+		if err != nil {
+			t.Fatalf("unexpected sagemaker notebooks manager error happened, got %v expected %v", err, nil)
+		}
+
+		notebooksManager, ok := notebooksInterface.(*SageMakerNotebooksManager)
+		if !ok {
+			t.Fatalf("unexpected sagemaker notebooks struct, got %s expected %s", reflect.TypeOf(notebooksInterface), "*SageMakerNotebooksManager")
+		}
+		notebooksManager.lookupInterval = 0
+
+		response, err := notebooksManager.Detect(defaultMetricConfig)
+		if err != nil {
+			t.Fatalf("unexpected sagemaker notebooks error happened, got %v expected %v", err, nil)
+		}
+
+		notebooksResponse, ok := response.([]DetectedSageMakerNotebook)
+		if !ok {
+			t.Fatalf("unexpected sagemaker notebooks struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedSageMakerNotebook")
+		}
+
+		if len(notebooksResponse) != 2 {
+			t.Fatalf("unexpected sagemaker notebooks detected, got %d expected %d", len(notebooksResponse), 2)
+		}
+
+		if len(collector.Events) != 2 {
+			t.Fatalf("unexpected collector sagemaker notebooks events, got %d expected %d", len(collector.Events), 2)
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource event collection status count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+
+		notebook := notebooksResponse[0]
+		if notebook.Name != "notebook-1" || notebook.PricePerHour != 1 || int(notebook.RunningDays) != 30 || int(notebook.IdleDays) != 30 {
+			t.Fatalf("unexpected sagemaker notebook, got %s priced %v running %v days idle %v days expected %s priced %v running %d days idle %d days", notebook.Name, notebook.PricePerHour, notebook.RunningDays, notebook.IdleDays, "notebook-1", 1, 30, 30)
+		}
+
+		notebook = notebooksResponse[1]
+		// Without events in the looked up window of 31 days
+		if notebook.Name != "notebook-3" || int(notebook.RunningDays) != 31 || int(notebook.IdleDays) != 31 {
+			t.Fatalf("unexpected sagemaker notebook, got %s running %v days idle %v days expected %s running %d days idle %d days", notebook.Name, notebook.RunningDays, notebook.IdleDays, "notebook-3", 31, 31)
+		}
+
+		// The events are looked up in the constraint window and a day more
+		if lookupDays := int(time.Since(client.lookupStartTime).Hours() / 24); lookupDays != 31 {
+			t.Fatalf("unexpected cloudtrail lookup window, got %d days expected %d days", lookupDays, 31)
+		}
+
+		if notebook.Tag["team"] != "a" {
+			t.Fatalf("unexpected sagemaker notebook tags, got %v", notebook.Tag)
+		}
+	})
+
+	t.Run("detection error", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, nil, mockPrice, "us-east-1")
+
+		notebooksManager, err := NewSageMakerNotebooksManager(detector, &MockAWSSageMakerNotebooksClient{err: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected sagemaker notebooks manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = notebooksManager.Detect(defaultMetricConfig)
+		if err == nil {
+			t.Fatalf("unexpected list sagemaker notebooks error, return empty")
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource event collection status count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+	})
+
+	t.Run("lookup error", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, nil, mockPrice, "us-east-1")
+
+		notebooksInterface, err := NewSageMakerNotebooksManager(detector, &MockAWSSageMakerNotebooksClient{lookupErr: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected sagemaker notebooks manager error happened, got %v expected %v", err, nil)
+		}
+		notebooksManager := notebooksInterface.(*SageMakerNotebooksManager)
+		notebooksManager.lookupInterval = 0
+
+		response, err := notebooksManager.Detect(defaultMetricConfig)
+		if err == nil {
+			t.Fatalf("unexpected lookup sagemaker notebooks events error, return empty")
+		}
+
+		if notebooksResponse := response.([]DetectedSageMakerNotebook); len(notebooksResponse) != 0 {
+			t.Fatalf("unexpected sagemaker notebooks detected, got %d expected %d", len(notebooksResponse), 0)
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource event collection status count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+	})
+}
@@ -0,0 +1,276 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/cloudwatch"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"fmt"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	log "github.com/sirupsen/logrus"
+)
+
+// VPCEndpointClientDescriptor is an interface defining the aws VPC endpoints client
+type VPCEndpointClientDescriptor interface {
+	DescribeVpcEndpoints(*ec2.DescribeVpcEndpointsInput) (*ec2.DescribeVpcEndpointsOutput, error)
+}
+
+// VPCEndpointManager describes the VPC endpoints struct
+type VPCEndpointManager struct {
+	client             VPCEndpointClientDescriptor
+	awsManager         common.AWSManager
+	namespace          string
+	servicePricingCode string
+	Name               collector.ResourceIdentifier
+}
+
+// DetectedVPCEndpoint defines the detected AWS interface VPC endpoints
+type DetectedVPCEndpoint struct {
+	Region            string
+	Metric            string
+	VPCID             string
+	ServiceName       string
+	AvailabilityZones int
+	collector.PriceDetectedFields
+	collector.MetricDetectedFields
+}
+
+func init() {
+	register.Registry("vpc_endpoints", NewVPCEndpointManager)
+}
+
+// NewVPCEndpointManager implements AWS GO SDK for ec2 VPC endpoints
+func NewVPCEndpointManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = ec2.New(awsManager.GetSession())
+	}
+
+	vpcEndpointClient, ok := client.(VPCEndpointClientDescriptor)
+	if !ok {
+		return nil, errors.New("invalid VPC endpoint client")
+	}
+
+	return &VPCEndpointManager{
+		client:             vpcEndpointClient,
+		awsManager:         awsManager,
+		namespace:          "AWS/PrivateLinkEndpoints",
+		servicePricingCode: "AmazonVPC",
+		Name:               awsManager.GetResourceIdentifier("vpc_endpoints"),
+	}, nil
+}
+
+// Detect check which interface VPC endpoints are under utilized. The endpoint is charged per hour for each of its availability zones
+func (vem *VPCEndpointManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	log.WithFields(log.Fields{
+		"region":   vem.awsManager.GetRegion(),
+		"resource": "vpc_endpoints",
+	}).Info("analyzing resource")
+
+	vem.awsManager.GetCollector().CollectStart(vem.Name)
+
+	detectedVPCEndpoints := []DetectedVPCEndpoint{}
+
+	pricingRegionPrefix, err := vem.awsManager.GetPricingClient().GetRegionPrefix(vem.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region": vem.awsManager.GetRegion(),
+		}).Error("Could not get pricing region prefix")
+		vem.awsManager.GetCollector().CollectError(vem.Name, err)
+		return detectedVPCEndpoints, err
+	}
+
+	pricingFilters := vem.getPricingFilterInput(pricingRegionPrefix)
+	price, err := vem.awsManager.GetPricingClient().GetPrice(pricingFilters, "", vem.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region":        vem.awsManager.GetRegion(),
+			"price_filters": pricingFilters,
+		}).Error("could not get VPC endpoint price")
+		vem.awsManager.GetCollector().CollectError(vem.Name, err)
+		return detectedVPCEndpoints, err
+	}
+
+	vpcEndpoints, err := vem.describeVPCEndpoints(nil, nil)
+	if err != nil {
+		vem.awsManager.GetCollector().CollectError(vem.Name, err)
+		return detectedVPCEndpoints, err
+	}
+
+	now := time.Now()
+	batch := vem.awsManager.GetCloudWatchClient().NewBatch()
+
+	for _, vpcEndpoint := range vpcEndpoints {
+		vpcEndpoint := vpcEndpoint
+		log.WithField("endpoint_id", *vpcEndpoint.VpcEndpointId).Debug("checking VPC endpoint")
+
+		for _, metric := range metrics {
+			metric := metric
+			log.WithFields(log.Fields{
+				"endpoint_id": *vpcEndpoint.VpcEndpointId,
+				"metric_name": metric.Description,
+			}).Debug("checking metric")
+
+			period := int64(metric.Period.Seconds())
+			metricEndTime := now.Add(time.Duration(-metric.StartTime))
+			metricInput := awsCloudwatch.GetMetricStatisticsInput{
+				Namespace:  &vem.namespace,
+				MetricName: &metric.Description,
+				Period:     &period,
+				StartTime:  &metricEndTime,
+				EndTime:    &now,
+				Dimensions: []*awsCloudwatch.Dimension{
+					{
+						Name:  awsClient.String("Endpoint Type"),
+						Value: vpcEndpoint.VpcEndpointType,
+					},
+					{
+						Name:  awsClient.String("Service Name"),
+						Value: vpcEndpoint.ServiceName,
+					},
+					{
+						Name:  awsClient.String("VPC Endpoint Id"),
+						Value: vpcEndpoint.VpcEndpointId,
+					},
+					{
+						Name:  awsClient.String("VPC Id"),
+						Value: vpcEndpoint.VpcId,
+					},
+				},
+			}
+
+			batch.Add(&metricInput, metric, func(formulaValue float64, metricsValues map[string]interface{}, err error) {
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"endpoint_id": *vpcEndpoint.VpcEndpointId,
+						"metric_name": metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					return
+				}
+
+				expression, err := metric.Constraint.Evaluate(formulaValue, metricsValues)
+				if err != nil {
+					log.WithField("error", err).Error("could not parse expression")
+					return
+				}
+
+				if expression {
+					log.WithFields(log.Fields{
+						"metric_name":         metric.Description,
+						"constraint_operator": metric.Constraint.Operator,
+						"constraint_Value":    metric.Constraint.Value,
+						"formula_value":       formulaValue,
+						"endpoint_id":         *vpcEndpoint.VpcEndpointId,
+						"vpc":                 *vpcEndpoint.VpcId,
+						"region":              vem.awsManager.GetRegion(),
+					}).Info("VPC endpoint detected as unutilized resource")
+
+					tagsData := map[string]string{}
+					for _, tag := range vpcEndpoint.Tags {
+						tagsData[*tag.Key] = *tag.Value
+					}
+
+					// An interface endpoint has a network interface in each of its subnets, a single subnet per availability zone
+					availabilityZones := len(vpcEndpoint.SubnetIds)
+					pricePerHour := price * float64(availabilityZones)
+
+					detectedVPCEndpoint := DetectedVPCEndpoint{
+						Region:            vem.awsManager.GetRegion(),
+						Metric:            metric.Description,
+						VPCID:             *vpcEndpoint.VpcId,
+						ServiceName:       *vpcEndpoint.ServiceName,
+						AvailabilityZones: availabilityZones,
+						PriceDetectedFields: collector.PriceDetectedFields{
+							LaunchTime:    *vpcEndpoint.CreationTimestamp,
+							ResourceID:    *vpcEndpoint.VpcEndpointId,
+							PricePerHour:  pricePerHour,
+							PricePerMonth: pricePerHour * collector.TotalMonthHours,
+							Tag:           tagsData,
+						},
+						MetricDetectedFields: cloudwatch.NewMetricDetectedFields(metric, &metricInput, formulaValue, metricsValues),
+					}
+
+					vem.awsManager.GetCollector().AddResource(collector.EventCollector{
+						ResourceName: vem.Name,
+						Data:         detectedVPCEndpoint,
+					})
+
+					detectedVPCEndpoints = append(detectedVPCEndpoints, detectedVPCEndpoint)
+				}
+			})
+		}
+	}
+
+	batch.Execute()
+
+	vem.awsManager.GetCollector().CollectFinish(vem.Name)
+
+	return detectedVPCEndpoints, nil
+}
+
+// getPricingFilterInput prepares the hourly price filter of a VPC endpoint in a single availability zone
+func (vem *VPCEndpointManager) getPricingFilterInput(pricingRegionPrefix string) pricing.GetProductsInput {
+	return pricing.GetProductsInput{
+		ServiceCode: &vem.servicePricingCode,
+		Filters: []*pricing.Filter{
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("productFamily"),
+				Value: awsClient.String("VpcEndpoint"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("termType"),
+				Value: awsClient.String("OnDemand"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("usagetype"),
+				Value: awsClient.String(fmt.Sprintf("%sVpcEndpoint-Hours", pricingRegionPrefix)),
+			},
+		},
+	}
+}
+
+// describeVPCEndpoints returns a list of the available interface VPC endpoints
+func (vem *VPCEndpointManager) describeVPCEndpoints(nextToken *string, vpcEndpoints []*ec2.VpcEndpoint) ([]*ec2.VpcEndpoint, error) {
+	input := &ec2.DescribeVpcEndpointsInput{
+		NextToken: nextToken,
+		Filters: []*ec2.Filter{
+			{
+				Name:   awsClient.String("vpc-endpoint-type"),
+				Values: []*string{awsClient.String(ec2.VpcEndpointTypeInterface)},
+			},
+			{
+				Name:   awsClient.String("vpc-endpoint-state"),
+				Values: []*string{awsClient.String("available")},
+			},
+		},
+	}
+
+	resp, err := vem.client.DescribeVpcEndpoints(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not describe VPC endpoints")
+		return nil, err
+	}
+
+	if vpcEndpoints == nil {
+		vpcEndpoints = []*ec2.VpcEndpoint{}
+	}
+
+	vpcEndpoints = append(vpcEndpoints, resp.VpcEndpoints...)
+
+	if resp.NextToken != nil {
+		return vem.describeVPCEndpoints(resp.NextToken, vpcEndpoints)
+	}
+
+	return vpcEndpoints, nil
+}
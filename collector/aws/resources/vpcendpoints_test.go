@@ -0,0 +1,108 @@
+package resources
+
+import (
+	"errors"
+	awsTestutils "finala/collector/aws/testutils"
+	collectorTestutils "finala/collector/testutils"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+var defaultVPCEndpointsMock = ec2.DescribeVpcEndpointsOutput{
+	VpcEndpoints: []*ec2.VpcEndpoint{
+		{
+			VpcEndpointId:     awsClient.String("vpce-1"),
+			VpcEndpointType:   awsClient.String("Interface"),
+			VpcId:             awsClient.String("vpc-1"),
+			ServiceName:       awsClient.String("com.amazonaws.us-east-1.ssm"),
+			CreationTimestamp: collectorTestutils.TimePointer(time.Now()),
+			SubnetIds:         []*string{awsClient.String("subnet-1"), awsClient.String("subnet-2"), awsClient.String("subnet-3")},
+			Tags: []*ec2.Tag{
+				{
+					Key:   awsClient.String("team"),
+					Value: awsClient.String("testeam-1"),
+				},
+			},
+		},
+	},
+}
+
+type MockAWSVPCEndpointClient struct {
+	responseDescribeVpcEndpoints ec2.DescribeVpcEndpointsOutput
+	err                          error
+}
+
+func (r *MockAWSVPCEndpointClient) DescribeVpcEndpoints(*ec2.DescribeVpcEndpointsInput) (*ec2.DescribeVpcEndpointsOutput, error) {
+	return &r.responseDescribeVpcEndpoints, r.err
+}
+
+func TestDetectVPCEndpoints(t *testing.T) {
+
+	t.Run("detect VPC endpoints", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		vpcEndpointManager, err := NewVPCEndpointManager(detector, &MockAWSVPCEndpointClient{responseDescribeVpcEndpoints: defaultVPCEndpointsMock})
+		if err != nil {
+			t.Fatalf("unexpected VPC endpoint manager error happened, got %v expected %v", err, nil)
+		}
+
+		response, err := vpcEndpointManager.Detect(awsTestutils.DefaultMetricConfig)
+		if err != nil {
+			t.Fatalf("unexpected VPC endpoint error happened, got %v expected %v", err, nil)
+		}
+
+		vpcEndpointsResponse, ok := response.([]DetectedVPCEndpoint)
+		if !ok {
+			t.Fatalf("unexpected VPC endpoint struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedVPCEndpoint")
+		}
+
+		if len(vpcEndpointsResponse) != 1 {
+			t.Fatalf("unexpected VPC endpoints detected, got %d expected %d", len(vpcEndpointsResponse), 1)
+		}
+
+		if len(collector.Events) != 1 {
+			t.Fatalf("unexpected collector VPC endpoint events, got %d expected %d", len(collector.Events), 1)
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource event collection status count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+
+		vpcEndpoint := vpcEndpointsResponse[0]
+		if vpcEndpoint.AvailabilityZones != 3 || vpcEndpoint.PricePerHour != 3 {
+			t.Fatalf("unexpected VPC endpoint price, got %v per hour for %d availability zones expected %v for %d", vpcEndpoint.PricePerHour, vpcEndpoint.AvailabilityZones, 3, 3)
+		}
+
+		if vpcEndpoint.Tag["team"] != "testeam-1" {
+			t.Fatalf("unexpected VPC endpoint tags, got %v", vpcEndpoint.Tag)
+		}
+	})
+
+	t.Run("detection error", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		vpcEndpointManager, err := NewVPCEndpointManager(detector, &MockAWSVPCEndpointClient{err: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected VPC endpoint manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = vpcEndpointManager.Detect(awsTestutils.DefaultMetricConfig)
+		if err == nil {
+			t.Fatalf("unexpected describe VPC endpoints error, return empty")
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource event collection status count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+	})
+}
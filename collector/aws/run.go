@@ -2,10 +2,14 @@ package aws
 
 import (
 	"finala/collector"
+	"finala/collector/aws/pricing"
 	"finala/collector/aws/register"
 	_ "finala/collector/aws/resources"
 	"finala/collector/config"
+	"sync"
 
+	"github.com/aws/aws-sdk-go/service/costexplorer"
+	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/sts"
 	log "github.com/sirupsen/logrus"
 )
@@ -13,58 +17,194 @@ import (
 const (
 	//ResourcePrefix descrive the resource prefix name
 	ResourcePrefix = "aws"
+
+	// defaultWorkers defines the default number of regions that can be scanned at the same time
+	defaultWorkers = 1
 )
 
 //Analyze represents the aws analyze
 type Analyze struct {
-	cl            collector.CollectorDescriber
-	metricManager collector.MetricDescriptor
-	awsAccounts   []config.AWSAccount
-	global        map[string]struct{}
+	cl             collector.CollectorDescriber
+	metricManager  collector.MetricDescriptor
+	awsAccounts    []config.AWSAccount
+	filter         config.FilterConfig
+	priceClient    pricing.PricingClientDescreptor
+	priceCache     *pricing.PriceCache
+	coverage       config.CoverageConfig
+	global         *GlobalResources
+	workers        int
+	accountWorkers int
 }
 
-// NewAnalyzeManager will charge to execute aws resources
-func NewAnalyzeManager(cl collector.CollectorDescriber, metricsManager collector.MetricDescriptor, awsAccounts []config.AWSAccount) *Analyze {
+// NewAnalyzeManager will charge to execute aws resources.
+// When priceClient is nil, the prices are fetched from the aws pricing api of every account.
+// The price cache is shared by all the scanned accounts and regions
+func NewAnalyzeManager(cl collector.CollectorDescriber, metricsManager collector.MetricDescriptor, awsAccounts []config.AWSAccount, concurrency config.ConcurrencyConfig, filter config.FilterConfig, priceClient pricing.PricingClientDescreptor, priceCache *pricing.PriceCache, coverage config.CoverageConfig) *Analyze {
+
+	workers := concurrency.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+
+	// By default a single account is allowed to use all the available workers
+	accountWorkers := concurrency.AccountWorkers
+	if accountWorkers <= 0 || accountWorkers > workers {
+		accountWorkers = workers
+	}
+
 	return &Analyze{
-		cl:            cl,
-		metricManager: metricsManager,
-		awsAccounts:   awsAccounts,
-		global:        make(map[string]struct{}),
+		cl:             cl,
+		metricManager:  metricsManager,
+		awsAccounts:    awsAccounts,
+		filter:         filter,
+		priceClient:    priceClient,
+		priceCache:     priceCache,
+		coverage:       coverage,
+		global:         NewGlobalResources(),
+		workers:        workers,
+		accountWorkers: accountWorkers,
 	}
 }
 
-// All will loop on all the aws provider settings, and check from the configuration of the metric should be reported
+// All will loop on all the aws provider settings, and check from the configuration of the metric should be reported.
+// Each account region is scanned in a separate worker, bounded by the global and the per account workers limit
 func (app *Analyze) All() {
 
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, app.workers)
+
+	log.WithFields(log.Fields{
+		"workers":         app.workers,
+		"account_workers": app.accountWorkers,
+	}).Info("starting to scan aws accounts")
+
+	app.validateFilter()
+
 	for _, account := range app.awsAccounts {
 
+		if !app.filter.IsAccountIncluded(account.Name) {
+			log.WithField("account", account.Name).Info("account was excluded by the filter")
+			continue
+		}
+
 		awsAuth := NewAuth(account)
 		globalsession, globalConfig := awsAuth.Login("")
 		stsManager := NewSTSManager(sts.New(globalsession, globalConfig))
+		accountWorkers := make(chan struct{}, app.accountWorkers)
+
+		// The account regions are described only when the `all` regions keyword is used
+		var regionsClient RegionsClientDescriptor
+		if hasAllRegions(account) {
+			regionsSession, regionsConfig := awsAuth.Login(defaultRegionPrice)
+			regionsClient = ec2.New(regionsSession, regionsConfig)
+		}
+
+		regions, err := ResolveRegions(regionsClient, account)
+		if err != nil {
+			log.WithError(err).WithField("account", account.Name).Error("could not resolve account regions")
+			continue
+		}
+
+		var coverage *CoverageManager
+		if app.coverage.Enable {
+			coverage = app.loadCoverage(awsAuth, stsManager, account)
+		}
+
+		for _, region := range regions {
+			if !app.filter.IsRegionIncluded(region) {
+				log.WithFields(log.Fields{
+					"account": account.Name,
+					"region":  region,
+				}).Debug("region was excluded by the filter")
+				continue
+			}
 
-		for _, region := range account.Regions {
-			resourcesDetection := NewDetectorManager(awsAuth, app.cl, account, stsManager, app.global, region)
-			for resourceType, resourceDetector := range register.GetResources() {
-
-				resource, err := resourceDetector(resourcesDetection, nil)
-				if err != nil {
-					log.Error(err)
-					continue
-				}
-				if resource == nil {
-					continue
-				}
-
-				metrics, err := app.metricManager.IsResourceMetricsEnable(resourceType)
-				if err != nil {
-					continue
-				}
-
-				_, err = resource.Detect(metrics)
-				if err != nil {
-					log.Error("could not detect unused data")
-				}
+			// The detector manager (and the aws sessions) are created before starting the worker,
+			// the aws sdk session creation is not safe for concurrent use
+			// The run collector keeps the state of this account region run (for example the suppressed resources count)
+			runCollector := app.cl
+			if cl, ok := app.cl.(collector.RunCollectorDescriber); ok {
+				runCollector = cl.NewRun()
 			}
+			resourcesDetection := NewDetectorManager(awsAuth, NewCoverageCollector(runCollector, coverage, region), account, stsManager, app.global, app.priceClient, app.priceCache, region)
+
+			wg.Add(1)
+			go func(resourcesDetection *DetectorManager) {
+				defer wg.Done()
+
+				// Acquire the account slot first, so a waiting account will not hold a global worker
+				accountWorkers <- struct{}{}
+				defer func() { <-accountWorkers }()
+				workers <- struct{}{}
+				defer func() { <-workers }()
+
+				app.detect(resourcesDetection)
+			}(resourcesDetection)
+		}
+	}
+
+	wg.Wait()
+}
+
+// detect runs all the registered resources detectors on the given detector manager
+func (app *Analyze) detect(resourcesDetection *DetectorManager) {
+
+	for resourceType, resourceDetector := range register.GetResources() {
+
+		if !app.filter.IsResourceIncluded(resourceType) {
+			log.WithField("resource_type", resourceType).Debug("resource type was excluded by the filter")
+			continue
+		}
+
+		resource, err := resourceDetector(resourcesDetection, nil)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		if resource == nil {
+			continue
+		}
+
+		metrics, err := app.metricManager.IsResourceMetricsEnable(resourceType)
+		if err != nil {
+			continue
+		}
+
+		_, err = resource.Detect(metrics)
+		if err != nil {
+			log.Error("could not detect unused data")
+		}
+	}
+}
+
+// loadCoverage returns the reserved instances and savings plans coverage of the account.
+// When the coverage could not be pulled, nil is returned and the resources effective prices are the on demand prices
+func (app *Analyze) loadCoverage(awsAuth AuthDescriptor, stsManager *STSManager, account config.AWSAccount) *CoverageManager {
+
+	callerIdentityOutput, err := stsManager.client.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		log.WithError(err).WithField("account", account.Name).Error("could not get account identity for the coverage")
+		return nil
+	}
+
+	costExplorerSession, costExplorerConfig := awsAuth.Login(defaultRegionPrice)
+	coverage := NewCoverageManager(costexplorer.New(costExplorerSession, costExplorerConfig), app.coverage.Lookback)
+	err = coverage.Load(*callerIdentityOutput.Account)
+	if err != nil {
+		log.WithError(err).WithField("account", account.Name).Warn("could not load account coverage, the on demand prices will be used")
+		return nil
+	}
+
+	return coverage
+}
+
+// validateFilter warns about filtered resource types that are not registered, a typo would silently scan nothing
+func (app *Analyze) validateFilter() {
+
+	resources := register.GetResources()
+	for _, resourceType := range append(app.filter.Only, app.filter.Skip...) {
+		if _, found := resources[resourceType]; !found {
+			log.WithField("resource_type", resourceType).Warn("filtered resource type is not supported")
 		}
 	}
 }
@@ -0,0 +1,38 @@
+package aws
+
+import (
+	"finala/collector/config"
+	collectorTestutils "finala/collector/testutils"
+	"testing"
+)
+
+func TestNewAnalyzeManager(t *testing.T) {
+
+	testCases := []struct {
+		name                   string
+		concurrency            config.ConcurrencyConfig
+		expectedWorkers        int
+		expectedAccountWorkers int
+	}{
+		{"default", config.ConcurrencyConfig{}, 1, 1},
+		{"workers", config.ConcurrencyConfig{Workers: 4}, 4, 4},
+		{"account_workers", config.ConcurrencyConfig{Workers: 4, AccountWorkers: 2}, 4, 2},
+		{"account_workers_above_workers", config.ConcurrencyConfig{Workers: 2, AccountWorkers: 5}, 2, 2},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			collector := collectorTestutils.NewMockCollector()
+			analyze := NewAnalyzeManager(collector, nil, []config.AWSAccount{}, test.concurrency, config.FilterConfig{}, nil, nil, config.CoverageConfig{})
+
+			if analyze.workers != test.expectedWorkers {
+				t.Fatalf("unexpected workers count, got %d expected %d", analyze.workers, test.expectedWorkers)
+			}
+
+			if analyze.accountWorkers != test.expectedAccountWorkers {
+				t.Fatalf("unexpected account workers count, got %d expected %d", analyze.accountWorkers, test.expectedAccountWorkers)
+			}
+		})
+	}
+
+}
@@ -27,10 +27,13 @@ var defaultResponseMetricStatistics = cloudwatch.GetMetricStatisticsOutput{
 
 type MockAWSCloudwatchClient struct {
 	responseMetricStatistics map[string]cloudwatch.GetMetricStatisticsOutput
+	GetMetricStatisticsCount int
+	GetMetricDataCount       int
 }
 
 func (r *MockAWSCloudwatchClient) GetMetricStatistics(input *cloudwatch.GetMetricStatisticsInput) (*cloudwatch.GetMetricStatisticsOutput, error) {
 
+	r.GetMetricStatisticsCount++
 	metricResponse, found := r.responseMetricStatistics[*input.MetricName]
 	if !found {
 		return nil, errors.New("metric not found")
@@ -38,7 +41,49 @@ func (r *MockAWSCloudwatchClient) GetMetricStatistics(input *cloudwatch.GetMetri
 	return &metricResponse, nil
 }
 
-func NewMockCloudwatch(mockClientResponse *map[string]cloudwatch.GetMetricStatisticsOutput) *cloudwatchmanager.CloudwatchManager {
+func (r *MockAWSCloudwatchClient) GetMetricData(input *cloudwatch.GetMetricDataInput) (*cloudwatch.GetMetricDataOutput, error) {
+
+	r.GetMetricDataCount++
+	output := &cloudwatch.GetMetricDataOutput{
+		MetricDataResults: []*cloudwatch.MetricDataResult{},
+	}
+
+	for _, query := range input.MetricDataQueries {
+		metricResponse, found := r.responseMetricStatistics[*query.MetricStat.Metric.MetricName]
+		if !found {
+			return nil, errors.New("metric not found")
+		}
+
+		values := []*float64{}
+		for _, datapoint := range metricResponse.Datapoints {
+			switch *query.MetricStat.Stat {
+			case "Average":
+				values = append(values, datapoint.Average)
+			case "Maximum":
+				values = append(values, datapoint.Maximum)
+			case "Minimum":
+				values = append(values, datapoint.Minimum)
+			case "Sum":
+				values = append(values, datapoint.Sum)
+			case "SampleCount":
+				values = append(values, datapoint.SampleCount)
+			default:
+				if value, found := datapoint.ExtendedStatistics[*query.MetricStat.Stat]; found {
+					values = append(values, value)
+				}
+			}
+		}
+
+		output.MetricDataResults = append(output.MetricDataResults, &cloudwatch.MetricDataResult{
+			Id:     query.Id,
+			Values: values,
+		})
+	}
+
+	return output, nil
+}
+
+func NewMockCloudwatchClient(mockClientResponse *map[string]cloudwatch.GetMetricStatisticsOutput) *MockAWSCloudwatchClient {
 
 	mockMetricStatistics := map[string]cloudwatch.GetMetricStatisticsOutput{
 		"TestMetric": defaultResponseMetricStatistics,
@@ -48,9 +93,14 @@ func NewMockCloudwatch(mockClientResponse *map[string]cloudwatch.GetMetricStatis
 		mockMetricStatistics = *mockClientResponse
 	}
 
-	mockClient := MockAWSCloudwatchClient{
+	return &MockAWSCloudwatchClient{
 		responseMetricStatistics: mockMetricStatistics,
 	}
-	cloutwatchManager := cloudwatchmanager.NewCloudWatchManager(&mockClient)
+}
+
+func NewMockCloudwatch(mockClientResponse *map[string]cloudwatch.GetMetricStatisticsOutput) *cloudwatchmanager.CloudwatchManager {
+
+	mockClient := NewMockCloudwatchClient(mockClientResponse)
+	cloutwatchManager := cloudwatchmanager.NewCloudWatchManager(mockClient)
 	return cloutwatchManager
 }
@@ -77,3 +77,21 @@ func (dm *MockAWSManager) IsGlobalSet(resourceName collector.ResourceIdentifier)
 	return isExists
 
 }
+
+// TrySetGlobal marked resource as global, returns false if the resource was already marked
+func (dm *MockAWSManager) TrySetGlobal(resourceName collector.ResourceIdentifier) bool {
+	if dm.IsGlobalSet(resourceName) {
+		return false
+	}
+	dm.SetGlobal(resourceName)
+	return true
+}
+
+// ForAccount returns a manager of another account that shares the global resources with this manager
+func (dm *MockAWSManager) ForAccount(accountID string) *MockAWSManager {
+	accountManager := *dm
+	accountManager.accountIdentity = &sts.GetCallerIdentityOutput{
+		Account: &accountID,
+	}
+	return &accountManager
+}
@@ -34,7 +34,8 @@ func NewMockPricing(mockPricing *MockAWSPricingClient) *pricing.PricingManager {
 						"R6PXMNYCEDGZ2EYN.JRTCKXETXF": {
 							PriceDimensions: map[string]*pricing.PriceRateCode{
 								"R6PXMNYCEDGZ2EYN.JRTCKXETXF.6YS6EN2CT7": {
-									Unit: "USD",
+									Unit:       "USD",
+									BeginRange: "0",
 									PricePerUnit: pricing.PriceCurrencyCode{
 										USD: "1",
 									},
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"finala/collector/spool"
 	"finala/request"
 	"finala/visibility"
 	"fmt"
@@ -27,6 +28,7 @@ type CollectorDescriber interface {
 	AddResource(data EventCollector)
 	CollectStart(resourceName ResourceIdentifier)
 	CollectFinish(resourceName ResourceIdentifier)
+	CollectFinishSuppressed(resourceName ResourceIdentifier, suppressedCount int)
 	CollectError(resourceName ResourceIdentifier, err error)
 	GetCollectorEvent() []EventCollector
 }
@@ -36,14 +38,19 @@ type CollectorManager struct {
 	collectChan    chan EventCollector
 	collectorMutex *sync.RWMutex
 	request        *request.HTTPClient
+	spool          *spool.Spool
+	output         EventsWriter
 	sendData       []EventCollector
+	sendingData    []EventCollector
 	sendInterval   time.Duration
 	executionID    string
 	apiEndpoint    string
 }
 
-// NewCollectorManager create new collector instance
-func NewCollectorManager(ctx context.Context, wg *sync.WaitGroup, req *request.HTTPClient, sendInterval time.Duration, name, apiEndpoint string) *CollectorManager {
+// NewCollectorManager create new collector instance.
+// When eventsSpool is given, events that could not be sent are persisted to the disk and replayed later.
+// When output is given, the events are written to it instead of being sent to the api server
+func NewCollectorManager(ctx context.Context, wg *sync.WaitGroup, req *request.HTTPClient, eventsSpool *spool.Spool, output EventsWriter, sendInterval time.Duration, name, apiEndpoint string) *CollectorManager {
 
 	wg.Add(2)
 	executionID := fmt.Sprintf("%s_%v", name, time.Now().Unix())
@@ -52,6 +59,8 @@ func NewCollectorManager(ctx context.Context, wg *sync.WaitGroup, req *request.H
 		collectChan:    make(chan EventCollector),
 		collectorMutex: &sync.RWMutex{},
 		request:        req,
+		spool:          eventsSpool,
+		output:         output,
 		sendData:       []EventCollector{},
 		sendInterval:   sendInterval,
 		executionID:    executionID,
@@ -109,10 +118,16 @@ func (cm *CollectorManager) CollectStart(resourceName ResourceIdentifier) {
 
 // CollectFinish add `finish` event to collector by given resource name
 func (cm *CollectorManager) CollectFinish(resourceName ResourceIdentifier) {
+	cm.CollectFinishSuppressed(resourceName, 0)
+}
+
+// CollectFinishSuppressed add `finish` event to collector by given resource name and the count of the suppressed resources
+func (cm *CollectorManager) CollectFinishSuppressed(resourceName ResourceIdentifier, suppressedCount int) {
 	cm.updateServiceStatus(EventCollector{
 		ResourceName: resourceName,
 		Data: EventStatusData{
-			Status: EventFinish,
+			Status:          EventFinish,
+			SuppressedCount: suppressedCount,
 		},
 	})
 }
@@ -128,9 +143,15 @@ func (cm *CollectorManager) CollectError(resourceName ResourceIdentifier, err er
 	})
 }
 
-// GetCollectorEvent returns current events list
+// GetCollectorEvent returns current events list, including the events that are being sent
 func (cm *CollectorManager) GetCollectorEvent() []EventCollector {
-	return cm.sendData
+	cm.collectorMutex.RLock()
+	defer cm.collectorMutex.RUnlock()
+
+	if len(cm.sendingData) == 0 {
+		return cm.sendData
+	}
+	return append(append([]EventCollector{}, cm.sendingData...), cm.sendData...)
 }
 
 // updateServiceStatus add status on resource collector
@@ -143,63 +164,236 @@ func (cm *CollectorManager) updateServiceStatus(data EventCollector) {
 // collect append all the given event to the one array of events
 func (cm *CollectorManager) saveEvent(data EventCollector) {
 
-	cm.collectorMutex.RLock()
-	defer cm.collectorMutex.RUnlock()
+	cm.collectorMutex.Lock()
+	defer cm.collectorMutex.Unlock()
 	cm.sendData = append(cm.sendData, data)
 }
 
 // sendBulk will send all event data to to api server.
+// Spooled events are sent first, and new events are spooled when the api server is unavailable.
+// The pending events are taken from the events list before they are sent, so new events are saved while the api server is slow
 func (cm *CollectorManager) sendBulk() bool {
 
-	cm.collectorMutex.RLock()
-	defer cm.collectorMutex.RUnlock()
+	events := cm.takeEvents()
+	defer cm.clearSendingEvents()
+
+	if cm.output != nil {
+		return cm.writeOutput(events)
+	}
+
+	// Keep the events order, new events are sent only after all the spooled events were sent
+	if !cm.replaySpool() {
+		cm.spoolEvents(events)
+		return false
+	}
+
+	if len(events) == 0 {
+		log.Debug("skip send events")
+		return false
+	}
+
+	buf, err := json.Marshal(events)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	status := cm.send(cm.sendData)
-	if status {
-		cm.sendData = []EventCollector{}
+	status := cm.send(cm.executionID, buf) == nil
+	if !status {
+		cm.spoolEvents(events)
 	}
 
 	return status
 
 }
 
-// gracefulShutdown will send the last events
-func (cm *CollectorManager) gracefulShutdown() {
+// takeEvents returns the pending events and replaces them with an empty events list,
+// the taken events are kept as the sending events until they are sent or restored
+func (cm *CollectorManager) takeEvents() []EventCollector {
 
-	time.Sleep(cm.sendInterval)
-	if len(cm.sendData) > 0 {
-		log.WithField("event_count", len(cm.sendData)).Info("Found more event to send")
-		cm.sendBulk()
-		cm.gracefulShutdown()
+	cm.collectorMutex.Lock()
+	defer cm.collectorMutex.Unlock()
+
+	events := cm.sendData
+	cm.sendData = []EventCollector{}
+	cm.sendingData = events
+	return events
+}
+
+// clearSendingEvents clears the sending events, after they were sent or restored
+func (cm *CollectorManager) clearSendingEvents() {
+
+	cm.collectorMutex.Lock()
+	defer cm.collectorMutex.Unlock()
+
+	cm.sendingData = nil
+}
+
+// restoreEvents returns the given events, that could not be sent, to the head of the pending events list
+func (cm *CollectorManager) restoreEvents(events []EventCollector) {
+
+	if len(events) == 0 {
+		return
 	}
 
+	cm.collectorMutex.Lock()
+	defer cm.collectorMutex.Unlock()
+
+	cm.sendData = append(events, cm.sendData...)
+	cm.sendingData = nil
 }
 
-// send will get all the events and send them to the api server
-func (cm *CollectorManager) send(events []EventCollector) bool {
+// writeOutput writes the given events to the collector output instead of the api server
+func (cm *CollectorManager) writeOutput(events []EventCollector) bool {
 
 	if len(events) == 0 {
-		log.Debug("skip send events")
+		log.Debug("skip write events")
+		return false
+	}
+
+	err := cm.output.Write(cm.executionID, events)
+	if err != nil {
+		log.WithError(err).WithField("event_count", len(events)).Error("could not write events to the collector output")
+		cm.restoreEvents(events)
+		return false
+	}
+
+	return true
+}
+
+// replaySpool sends all the spooled segments by their order. returns false if one of the segments could not be sent.
+// A segment that the api server rejected is quarantined, so it does not block the next segments and events
+func (cm *CollectorManager) replaySpool() bool {
+
+	if cm.spool == nil {
+		return true
+	}
+
+	segments, err := cm.spool.Segments()
+	if err != nil {
+		log.WithError(err).Error("could not read spooled events")
 		return false
 	}
 
+	for _, segment := range segments {
+		err = cm.send(segment.ExecutionID, segment.Events)
+		if isEventsRejected(err) {
+			log.WithError(err).WithFields(log.Fields{
+				"segment":      segment.Name,
+				"execution_id": segment.ExecutionID,
+			}).Error("api server rejected the spooled events, the segment is quarantined")
+
+			err = cm.spool.Quarantine(segment)
+			if err != nil {
+				log.WithError(err).WithField("segment", segment.Name).Error("could not quarantine spooled events")
+				return false
+			}
+			continue
+		}
+
+		if err != nil {
+			log.WithField("segments_count", len(segments)).Warn("api server is unavailable, spooled events will be sent later")
+			return false
+		}
+
+		err = cm.spool.Remove(segment)
+		if err != nil {
+			log.WithError(err).WithField("segment", segment.Name).Error("could not remove spooled events")
+			return false
+		}
+		log.WithFields(log.Fields{
+			"segment":      segment.Name,
+			"execution_id": segment.ExecutionID,
+		}).Info("spooled events were sent")
+	}
+
+	return true
+}
+
+// spoolEvents moves the given events to the spool. when there is no spool, or the events could not be spooled,
+// the events are returned to the pending events list
+func (cm *CollectorManager) spoolEvents(events []EventCollector) {
+
+	if len(events) == 0 {
+		return
+	}
+
+	if cm.spool == nil {
+		cm.restoreEvents(events)
+		return
+	}
+
 	buf, err := json.Marshal(events)
 	if err != nil {
 		log.Fatal(err)
 	}
-	req, err := cm.request.Request("POST", fmt.Sprintf("%s/api/v1/detect-events/%s", cm.apiEndpoint, cm.executionID), nil, bytes.NewBuffer(buf))
+
+	err = cm.spool.Push(cm.executionID, buf)
+	if err != nil {
+		log.WithError(err).WithField("event_count", len(events)).Error("could not spool events")
+		cm.restoreEvents(events)
+	}
+}
+
+// gracefulShutdown will send the last events once. Events that could not be sent are spooled (or written to the
+// output), and the events that are still pending after this final flush are logged as lost
+func (cm *CollectorManager) gracefulShutdown() {
+
+	time.Sleep(cm.sendInterval)
+	if len(cm.GetCollectorEvent()) == 0 {
+		return
+	}
+
+	log.WithField("event_count", len(cm.GetCollectorEvent())).Info("Found more event to send")
+	cm.sendBulk()
+
+	lostEvents := len(cm.GetCollectorEvent())
+	if lostEvents > 0 {
+		log.WithField("event_count", lostEvents).Error("could not send, spool or write the last events, the events are lost")
+	}
+}
+
+// send will send the given encoded events to the api server under the given execution id
+func (cm *CollectorManager) send(executionID string, buf []byte) error {
+	return postEvents(cm.request, cm.apiEndpoint, executionID, buf)
+}
+
+// postEvents sends the given encoded events list to the api server, a response other than accepted is returned as
+// a request.HttpError
+func postEvents(client *request.HTTPClient, apiEndpoint, executionID string, buf []byte) error {
+
+	req, err := client.Request("POST", fmt.Sprintf("%s/api/v1/detect-events/%s", apiEndpoint, executionID), nil, bytes.NewBuffer(buf))
 	if err != nil {
 		log.WithError(err).Error("could not create HTTP client request")
-		return false
+		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	defer visibility.Elapsed("api webserver request")()
-	res, err := cm.request.DO(req)
+	res, err := client.DO(req)
 
 	if err != nil {
-		log.WithError(err).Error("could not send HTTP client request")
+		log.WithError(err).WithField("execution_id", executionID).Error("could not send HTTP client request")
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusAccepted {
+		err := &request.HttpError{Status: res.Status, StatusCode: res.StatusCode}
+		log.WithError(err).WithField("execution_id", executionID).Error("api server did not accept the events")
+		return err
+	}
+
+	return nil
+}
+
+// isEventsRejected returns true when the api server permanently rejected the events. A client error response, other
+// than a timeout or a rate limit, is returned again for the same events, so the events should not be sent again
+func isEventsRejected(err error) bool {
+
+	httpErr, ok := err.(*request.HttpError)
+	if !ok {
 		return false
 	}
 
-	return res.StatusCode == http.StatusAccepted
+	return httpErr.StatusCode >= http.StatusBadRequest && httpErr.StatusCode < http.StatusInternalServerError &&
+		httpErr.StatusCode != http.StatusRequestTimeout && httpErr.StatusCode != http.StatusTooManyRequests
 }
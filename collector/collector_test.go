@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"finala/collector"
+	"finala/collector/spool"
 	"finala/request"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -24,9 +27,25 @@ type DetectEvents struct {
 type ReceivedData struct {
 	receivedCount    int
 	returnStatusCode int
+	mutex            sync.Mutex
+}
+
+func (rd *ReceivedData) setStatusCode(statusCode int) {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+	rd.returnStatusCode = statusCode
+}
+
+func (rd *ReceivedData) count() int {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+	return rd.receivedCount
 }
 
 func (rd *ReceivedData) HandleRequestHandler(resp http.ResponseWriter, req *http.Request) {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+
 	resp.WriteHeader(rd.returnStatusCode)
 	buf, bodyErr := ioutil.ReadAll(req.Body)
 	if bodyErr != nil {
@@ -41,7 +60,10 @@ func (rd *ReceivedData) HandleRequestHandler(resp http.ResponseWriter, req *http
 		return
 	}
 
-	rd.receivedCount = len(e)
+	// The events may be sent in a few requests, only the accepted events are counted
+	if rd.returnStatusCode == http.StatusAccepted {
+		rd.receivedCount += len(e)
+	}
 
 }
 
@@ -49,7 +71,7 @@ func newCollector(wg *sync.WaitGroup, ctx context.Context, port int) *collector.
 
 	req := request.NewHTTPClient()
 	duration := time.Duration(time.Second * 1)
-	coll := collector.NewCollectorManager(ctx, wg, req, duration, "collector_name", fmt.Sprintf("http://127.0.0.1:%d", port))
+	coll := collector.NewCollectorManager(ctx, wg, req, nil, nil, duration, "collector_name", fmt.Sprintf("http://127.0.0.1:%d", port))
 	return coll
 }
 func TestAddEvent(t *testing.T) {
@@ -86,8 +108,8 @@ func TestAddEvent(t *testing.T) {
 
 	time.Sleep(time.Second * 3)
 
-	if receivedData.receivedCount != 2 {
-		t.Fatalf("unexpected collector send data, got %d, expected %d", receivedData.receivedCount, 2)
+	if count := receivedData.count(); count != 2 {
+		t.Fatalf("unexpected collector send data, got %d, expected %d", count, 2)
 	}
 
 	if len(coll.GetCollectorEvent()) != 0 {
@@ -135,3 +157,163 @@ func TestAddEventServerUnavailable(t *testing.T) {
 	}
 
 }
+
+func TestAddEventSpool(t *testing.T) {
+
+	var wg sync.WaitGroup
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	receivedData := ReceivedData{
+		returnStatusCode: http.StatusInternalServerError,
+	}
+
+	directory, _ := ioutil.TempDir("", "spool")
+	defer os.RemoveAll(directory)
+	eventsSpool, err := spool.NewSpool(directory, 0)
+	if err != nil {
+		t.Fatalf("unexpected spool error, got %v expected %v", err, nil)
+	}
+
+	coll := collector.NewCollectorManager(ctx, &wg, request.NewHTTPClient(), eventsSpool, nil, time.Second, "collector_name", "http://127.0.0.1:5003")
+
+	r := mux.NewRouter()
+	r.HandleFunc("/api/v1/detect-events/{executionID}", receivedData.HandleRequestHandler)
+
+	srv := &http.Server{
+		Addr:    ":5003",
+		Handler: r,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil {
+			log.Println(err)
+		}
+	}()
+
+	time.Sleep(time.Second)
+
+	coll.CollectStart(collector.ResourceIdentifier("test"))
+	coll.AddResource(collector.EventCollector{
+		ResourceName: "test1",
+		Data:         "test data",
+	})
+	time.Sleep(time.Second * 2)
+
+	if len(coll.GetCollectorEvent()) != 0 {
+		t.Fatalf("unexpected collector events in memory, got %d, expected %d", len(coll.GetCollectorEvent()), 0)
+	}
+
+	segments, _ := eventsSpool.Segments()
+	if len(segments) != 1 {
+		t.Fatalf("unexpected spooled segments, got %d, expected %d", len(segments), 1)
+	}
+
+	receivedData.setStatusCode(http.StatusAccepted)
+	time.Sleep(time.Second * 2)
+
+	if count := receivedData.count(); count != 2 {
+		t.Fatalf("unexpected collector send data, got %d, expected %d", count, 2)
+	}
+
+	segments, _ = eventsSpool.Segments()
+	if len(segments) != 0 {
+		t.Fatalf("unexpected spooled segments, got %d, expected %d", len(segments), 0)
+	}
+
+}
+
+func TestAddEventSpoolRejected(t *testing.T) {
+
+	var wg sync.WaitGroup
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	receivedData := ReceivedData{
+		returnStatusCode: http.StatusInternalServerError,
+	}
+
+	directory, _ := ioutil.TempDir("", "spool")
+	defer os.RemoveAll(directory)
+	eventsSpool, err := spool.NewSpool(directory, 0)
+	if err != nil {
+		t.Fatalf("unexpected spool error, got %v expected %v", err, nil)
+	}
+
+	coll := collector.NewCollectorManager(ctx, &wg, request.NewHTTPClient(), eventsSpool, nil, time.Second, "collector_name", "http://127.0.0.1:5005")
+
+	r := mux.NewRouter()
+	r.HandleFunc("/api/v1/detect-events/{executionID}", receivedData.HandleRequestHandler)
+
+	srv := &http.Server{
+		Addr:    ":5005",
+		Handler: r,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil {
+			log.Println(err)
+		}
+	}()
+
+	time.Sleep(time.Second)
+
+	coll.CollectStart(collector.ResourceIdentifier("test"))
+	time.Sleep(time.Second * 2)
+
+	segments, _ := eventsSpool.Segments()
+	if len(segments) != 1 {
+		t.Fatalf("unexpected spooled segments, got %d, expected %d", len(segments), 1)
+	}
+
+	// The rejected segment is quarantined instead of blocking the next events
+	receivedData.setStatusCode(http.StatusBadRequest)
+	time.Sleep(time.Second * 2)
+
+	segments, _ = eventsSpool.Segments()
+	if len(segments) != 0 {
+		t.Fatalf("unexpected spooled segments, got %d, expected %d", len(segments), 0)
+	}
+
+	quarantined, _ := filepath.Glob(filepath.Join(directory, "*.bad"))
+	if len(quarantined) != 1 {
+		t.Fatalf("unexpected quarantined segments, got %d, expected %d", len(quarantined), 1)
+	}
+
+	receivedData.setStatusCode(http.StatusAccepted)
+	coll.AddResource(collector.EventCollector{
+		ResourceName: "test1",
+		Data:         "test data",
+	})
+	time.Sleep(time.Second * 2)
+
+	if count := receivedData.count(); count != 1 {
+		t.Fatalf("unexpected collector send data, got %d, expected %d", count, 1)
+	}
+
+}
+
+func TestGracefulShutdownServerUnavailable(t *testing.T) {
+
+	var wg sync.WaitGroup
+	ctx, cancelFn := context.WithCancel(context.Background())
+
+	// Nothing listens on the api server port and there is no spool, so the last events can not be sent
+	req := request.NewHTTPClientWithRetry(request.RetryConfig{MaxAttempts: 1})
+	coll := collector.NewCollectorManager(ctx, &wg, req, nil, nil, time.Second, "collector_name", "http://127.0.0.1:5004")
+
+	coll.CollectStart(collector.ResourceIdentifier("test"))
+	cancelFn()
+
+	shutdown := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(shutdown)
+	}()
+
+	select {
+	case <-shutdown:
+	case <-time.After(time.Second * 10):
+		t.Fatalf("unexpected collector shutdown, the collector did not shut down after the final flush")
+	}
+
+	if len(coll.GetCollectorEvent()) != 1 {
+		t.Fatalf("unexpected lost collector events, got %d, expected %d", len(coll.GetCollectorEvent()), 1)
+	}
+}
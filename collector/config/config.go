@@ -1,8 +1,14 @@
 package config
 
 import (
+	"errors"
+	"finala/expression"
+	"finala/request"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v2"
@@ -10,22 +16,161 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-// AWSAccount describe AWS account
+// ErrInvalidConstraintFormula returned when a constraint formula result is not a number
+var ErrInvalidConstraintFormula = errors.New("constraint formula result is not a number")
+
+// AWSAccount describe AWS account.
+// The `all` region scans all the regions that are enabled in the account,
+// the exclude regions are glob patterns (for example: ap-*) of regions that are not scanned.
+// The role is assumed with the account credentials, after the source role when it is set (role chaining)
 type AWSAccount struct {
-	Name         string   `yaml:"name"`
-	AccessKey    string   `yaml:"access_key"`
-	SecretKey    string   `yaml:"secret_key"`
-	Role         string   `yaml:"role"`
-	Profile      string   `yaml:"profile"`
-	SessionToken string   `yaml:"session_token"`
-	Regions      []string `yaml:"regions"`
+	Name           string   `yaml:"name"`
+	AccessKey      string   `yaml:"access_key"`
+	SecretKey      string   `yaml:"secret_key"`
+	SourceRole     string   `yaml:"source_role"`
+	Role           string   `yaml:"role"`
+	Profile        string   `yaml:"profile"`
+	SessionToken   string   `yaml:"session_token"`
+	Regions        []string `yaml:"regions"`
+	ExcludeRegions []string `yaml:"exclude_regions"`
+}
+
+// OrganizationConfig describe an aws organization whose active accounts are scanned.
+// The credentials (access key, profile or role) are used to list the organization accounts, the role template
+// (for example: arn:aws:iam::{{account_id}}:role/finala) is the role assumed in every account,
+// and the regions are scanned in every account. An account is scanned when one of its parent OUs is included
+// (or the include list is empty) and none of them is excluded
+type OrganizationConfig struct {
+	AWSAccount   `yaml:",inline"`
+	RoleTemplate string   `yaml:"role_template"`
+	IncludeOUs   []string `yaml:"include_ous"`
+	ExcludeOUs   []string `yaml:"exclude_ous"`
 }
 
-// MetricConstraintConfig describe the metric calculator
+// MetricConstraintConfig describe the metric calculator.
+// A constraint is a rule (formula, operator and value), a rule tree of all/any/not sub constraints, or both
 type MetricConstraintConfig struct {
-	Formula  string  `yaml:"formula"`
-	Operator string  `yaml:"operator"`
-	Value    float64 `yaml:"value"`
+	Formula  string                   `yaml:"formula"`
+	Operator string                   `yaml:"operator"`
+	Value    float64                  `yaml:"value"`
+	All      []MetricConstraintConfig `yaml:"all"`
+	Any      []MetricConstraintConfig `yaml:"any"`
+	Not      *MetricConstraintConfig  `yaml:"not"`
+}
+
+// IsCompound returns true if the constraint has sub constraints
+func (mc MetricConstraintConfig) IsCompound() bool {
+	return len(mc.All) > 0 || len(mc.Any) > 0 || mc.Not != nil
+}
+
+// Evaluate returns true when the constraint rule and all its sub constraints are matched.
+// The constraint rule is checked against the given formula value (the GetMetric result),
+// sub constraints with a formula evaluate it with the given metrics values
+func (mc MetricConstraintConfig) Evaluate(formulaValue float64, metricsValues map[string]interface{}) (bool, error) {
+	return mc.evaluate(formulaValue, metricsValues, formulaValue)
+}
+
+// evaluate checks the constraint rule with the given rule value, and the sub constraints by their formulas
+func (mc MetricConstraintConfig) evaluate(formulaValue float64, metricsValues map[string]interface{}, ruleValue float64) (bool, error) {
+
+	if mc.Operator != "" || !mc.IsCompound() {
+		match, err := expression.BoolExpression(ruleValue, mc.Value, mc.Operator)
+		if err != nil || !match {
+			return false, err
+		}
+	}
+
+	for _, constraint := range mc.All {
+		match, err := constraint.evaluateSubConstraint(formulaValue, metricsValues)
+		if err != nil || !match {
+			return false, err
+		}
+	}
+
+	if len(mc.Any) > 0 {
+		anyMatch := false
+		for _, constraint := range mc.Any {
+			match, err := constraint.evaluateSubConstraint(formulaValue, metricsValues)
+			if err != nil {
+				return false, err
+			}
+			if match {
+				anyMatch = true
+				break
+			}
+		}
+		if !anyMatch {
+			return false, nil
+		}
+	}
+
+	if mc.Not != nil {
+		match, err := mc.Not.evaluateSubConstraint(formulaValue, metricsValues)
+		if err != nil || match {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// evaluateSubConstraint evaluates a sub constraint, the rule value is the sub constraint formula result
+// or the formula value when the sub constraint has no formula
+func (mc MetricConstraintConfig) evaluateSubConstraint(formulaValue float64, metricsValues map[string]interface{}) (bool, error) {
+
+	ruleValue := formulaValue
+	if mc.Formula != "" {
+		result, err := expression.ExpressionWithParams(mc.Formula, metricsValues)
+		if err != nil {
+			return false, err
+		}
+
+		value, ok := result.(float64)
+		if !ok {
+			return false, ErrInvalidConstraintFormula
+		}
+		ruleValue = value
+	}
+
+	return mc.evaluate(formulaValue, metricsValues, ruleValue)
+}
+
+// String returns the constraint rule and all its sub constraints,
+// for example: "value < 5 and any(CPUUtilization > 5, not(NetworkIn > 4096))"
+func (mc MetricConstraintConfig) String() string {
+
+	rules := []string{}
+	if mc.Operator != "" || !mc.IsCompound() {
+		subject := "value"
+		if mc.Formula != "" {
+			subject = mc.Formula
+		}
+		rules = append(rules, fmt.Sprintf("%s %s %s", subject, mc.Operator, strconv.FormatFloat(mc.Value, 'f', -1, 64)))
+	}
+
+	if len(mc.All) > 0 {
+		rules = append(rules, fmt.Sprintf("all(%s)", joinConstraints(mc.All)))
+	}
+
+	if len(mc.Any) > 0 {
+		rules = append(rules, fmt.Sprintf("any(%s)", joinConstraints(mc.Any)))
+	}
+
+	if mc.Not != nil {
+		rules = append(rules, fmt.Sprintf("not(%s)", mc.Not.String()))
+	}
+
+	return strings.Join(rules, " and ")
+}
+
+// joinConstraints returns the given constraints separated by commas
+func joinConstraints(constraints []MetricConstraintConfig) string {
+
+	rules := make([]string, len(constraints))
+	for i, constraint := range constraints {
+		rules[i] = constraint.String()
+	}
+	return strings.Join(rules, ", ")
 }
 
 // MetricDataConfiguration descrive the metric details (name of the metric and the metric statistics)
@@ -44,24 +189,104 @@ type MetricConfig struct {
 	Constraint  MetricConstraintConfig    `yaml:"constraint"`
 }
 
+// CoverageConfig describe the reserved instances and savings plans coverage of the accounts.
+// When enabled, the coverage of the lookback period is pulled from cost explorer and the effective prices
+// of the detected resources exclude the covered share of the on demand price
+type CoverageConfig struct {
+	Enable   bool          `yaml:"enable"`
+	Lookback time.Duration `yaml:"lookback"`
+}
+
+// PricingConfig describe the source of the resources prices.
+// When the offline directory is set, the prices are read from the AWS bulk offer files (JSON or CSV) of the directory
+// instead of the pricing api, the region offer files can be kept in a region sub directory (for example us-east-1/) to load
+// them only when the region is collected. When the cache file is set, the prices are kept in the file for the next runs, until the cache ttl expires
+type PricingConfig struct {
+	OfflineDirectory string         `yaml:"offline_directory"`
+	CacheFile        string         `yaml:"cache_file"`
+	CacheTTL         time.Duration  `yaml:"cache_ttl"`
+	Coverage         CoverageConfig `yaml:"coverage"`
+}
+
 // ProviderConfig describe the available providers
+// Resources with one of the ignore tags (an empty value matches any value), or with a snooze tag
+// date (2006-01-02 or RFC3339) in the future, are not reported
 type ProviderConfig struct {
-	Accounts []AWSAccount              `yaml:"accounts"`
-	Metrics  map[string][]MetricConfig `yaml:"metrics"`
+	Accounts     []AWSAccount              `yaml:"accounts"`
+	Organization *OrganizationConfig       `yaml:"organization"`
+	Metrics      map[string][]MetricConfig `yaml:"metrics"`
+	IgnoreTags   map[string]string         `yaml:"ignore_tags"`
+	SnoozeTags   []string                  `yaml:"snooze_tags"`
+	Pricing      PricingConfig             `yaml:"pricing"`
+}
+
+// SpoolConfig describe the on disk spool of the events that could not be sent to the api
+type SpoolConfig struct {
+	Directory string `yaml:"directory"`
+	MaxSize   string `yaml:"max_size"`
 }
 
 // APIServerConfig descrive the api configuration
 type APIServerConfig struct {
-	BulkInterval time.Duration `yaml:"bulk_interval"`
-	Addr         string        `yaml:"address"`
+	BulkInterval time.Duration       `yaml:"bulk_interval"`
+	Addr         string              `yaml:"address"`
+	Spool        SpoolConfig         `yaml:"spool"`
+	Retry        request.RetryConfig `yaml:"retry"`
+}
+
+// ConcurrencyConfig describe how many account regions can be scanned at the same time
+type ConcurrencyConfig struct {
+	Workers        int `yaml:"workers"`
+	AccountWorkers int `yaml:"account_workers"`
+}
+
+// FilterConfig describe which resource types, accounts and regions are scanned, an empty list does not filter
+type FilterConfig struct {
+	Only     []string `yaml:"only"`
+	Skip     []string `yaml:"skip"`
+	Accounts []string `yaml:"accounts"`
+	Regions  []string `yaml:"regions"`
+}
+
+// IsResourceIncluded returns true if the given resource type should be scanned
+func (fc FilterConfig) IsResourceIncluded(resourceType string) bool {
+	return (len(fc.Only) == 0 || contains(fc.Only, resourceType)) && !contains(fc.Skip, resourceType)
+}
+
+// IsAccountIncluded returns true if the given account name should be scanned
+func (fc FilterConfig) IsAccountIncluded(accountName string) bool {
+	return len(fc.Accounts) == 0 || contains(fc.Accounts, accountName)
+}
+
+// IsRegionIncluded returns true if the given region should be scanned
+func (fc FilterConfig) IsRegionIncluded(region string) bool {
+	return len(fc.Regions) == 0 || contains(fc.Regions, region)
+}
+
+// contains returns true if the given value is in the list
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// OutputConfig describe where the collector events are written instead of the api server
+type OutputConfig struct {
+	File string `yaml:"file"`
 }
 
 // CollectorConfig present the application config
 type CollectorConfig struct {
-	Name      string                    `yaml:"name"`
-	LogLevel  string                    `yaml:"log_level"`
-	APIServer APIServerConfig           `yaml:"api_server"`
-	Providers map[string]ProviderConfig `yaml:"providers"`
+	Name        string                    `yaml:"name"`
+	LogLevel    string                    `yaml:"log_level"`
+	APIServer   APIServerConfig           `yaml:"api_server"`
+	Output      OutputConfig              `yaml:"output"`
+	Concurrency ConcurrencyConfig         `yaml:"concurrency"`
+	Filter      FilterConfig              `yaml:"filter"`
+	Providers   map[string]ProviderConfig `yaml:"providers"`
 }
 
 // Load will load yaml file go struct
@@ -38,3 +38,131 @@ func TestConfig(t *testing.T) {
 	})
 
 }
+
+func TestConstraintEvaluate(t *testing.T) {
+
+	metricsValues := map[string]interface{}{
+		"CPUUtilization": float64(3),
+		"NetworkIn":      float64(2048),
+	}
+
+	testCases := []struct {
+		name       string
+		constraint config.MetricConstraintConfig
+		expected   bool
+	}{
+		{"rule", config.MetricConstraintConfig{Operator: "<", Value: 5}, true},
+		{"rule_not_matched", config.MetricConstraintConfig{Operator: ">", Value: 5}, false},
+		{"all", config.MetricConstraintConfig{All: []config.MetricConstraintConfig{
+			{Formula: "CPUUtilization", Operator: "<", Value: 5},
+			{Formula: "NetworkIn", Operator: "<", Value: 4096},
+		}}, true},
+		{"all_not_matched", config.MetricConstraintConfig{All: []config.MetricConstraintConfig{
+			{Formula: "CPUUtilization", Operator: "<", Value: 5},
+			{Formula: "NetworkIn", Operator: "<", Value: 1024},
+		}}, false},
+		{"any", config.MetricConstraintConfig{Any: []config.MetricConstraintConfig{
+			{Formula: "CPUUtilization", Operator: ">", Value: 5},
+			{Formula: "NetworkIn / 1024", Operator: "==", Value: 2},
+		}}, true},
+		{"any_not_matched", config.MetricConstraintConfig{Any: []config.MetricConstraintConfig{
+			{Formula: "CPUUtilization", Operator: ">", Value: 5},
+			{Formula: "NetworkIn", Operator: ">", Value: 4096},
+		}}, false},
+		{"not", config.MetricConstraintConfig{Not: &config.MetricConstraintConfig{Formula: "NetworkIn", Operator: ">", Value: 4096}}, true},
+		{"not_not_matched", config.MetricConstraintConfig{Not: &config.MetricConstraintConfig{Formula: "NetworkIn", Operator: "<", Value: 4096}}, false},
+		{"rule_and_tree", config.MetricConstraintConfig{Operator: "<", Value: 5, Not: &config.MetricConstraintConfig{Operator: "<", Value: 1}}, true},
+		{"nested", config.MetricConstraintConfig{Any: []config.MetricConstraintConfig{
+			{Formula: "CPUUtilization", Operator: ">", Value: 5},
+			{All: []config.MetricConstraintConfig{
+				{Formula: "CPUUtilization", Operator: "<", Value: 5},
+				{Not: &config.MetricConstraintConfig{Formula: "NetworkIn", Operator: ">", Value: 4096}},
+			}},
+		}}, true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := test.constraint.Evaluate(3, metricsValues)
+			if err != nil {
+				t.Fatalf("unexpected error, got %v expected %v", err, nil)
+			}
+			if result != test.expected {
+				t.Fatalf("unexpected constraint result, got %t expected %t", result, test.expected)
+			}
+		})
+	}
+
+	t.Run("invalid_formula", func(t *testing.T) {
+		constraint := config.MetricConstraintConfig{All: []config.MetricConstraintConfig{
+			{Formula: "CPUUtilization > 1", Operator: "<", Value: 5},
+		}}
+		_, err := constraint.Evaluate(3, metricsValues)
+		if err != config.ErrInvalidConstraintFormula {
+			t.Fatalf("unexpected error, got %v expected %v", err, config.ErrInvalidConstraintFormula)
+		}
+	})
+
+}
+
+func TestConstraintString(t *testing.T) {
+
+	testCases := []struct {
+		name       string
+		constraint config.MetricConstraintConfig
+		expected   string
+	}{
+		{"rule", config.MetricConstraintConfig{Operator: "<", Value: 0.5}, "value < 0.5"},
+		{"compound", config.MetricConstraintConfig{All: []config.MetricConstraintConfig{
+			{Formula: "CPUUtilization", Operator: "<", Value: 5},
+			{Formula: "NetworkIn", Operator: "<", Value: 4096},
+		}}, "all(CPUUtilization < 5, NetworkIn < 4096)"},
+		{"rule_and_tree", config.MetricConstraintConfig{Operator: "<", Value: 5, Not: &config.MetricConstraintConfig{Operator: "<", Value: 1}}, "value < 5 and not(value < 1)"},
+		{"nested", config.MetricConstraintConfig{Any: []config.MetricConstraintConfig{
+			{Formula: "CPUUtilization", Operator: ">", Value: 5},
+			{All: []config.MetricConstraintConfig{
+				{Formula: "CPUUtilization", Operator: "<", Value: 5},
+				{Not: &config.MetricConstraintConfig{Formula: "NetworkIn", Operator: ">", Value: 4096}},
+			}},
+		}}, "any(CPUUtilization > 5, all(CPUUtilization < 5, not(NetworkIn > 4096)))"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			if result := test.constraint.String(); result != test.expected {
+				t.Fatalf("unexpected constraint string, got %q expected %q", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+
+	filter := config.FilterConfig{
+		Only:     []string{"rds", "ec2"},
+		Skip:     []string{"ec2"},
+		Accounts: []string{"production"},
+	}
+
+	if !filter.IsResourceIncluded("rds") {
+		t.Fatalf("unexpected resource filter result, got %t expected %t", false, true)
+	}
+
+	if filter.IsResourceIncluded("ec2") || filter.IsResourceIncluded("lambda") {
+		t.Fatalf("unexpected resource filter result, got %t expected %t", true, false)
+	}
+
+	if !filter.IsAccountIncluded("production") || filter.IsAccountIncluded("staging") {
+		t.Fatalf("unexpected account filter result")
+	}
+
+	if !filter.IsRegionIncluded("eu-west-1") {
+		t.Fatalf("unexpected region filter result, empty list should include all regions")
+	}
+
+	emptyFilter := config.FilterConfig{}
+	if !emptyFilter.IsResourceIncluded("lambda") || !emptyFilter.IsAccountIncluded("staging") {
+		t.Fatalf("unexpected empty filter result, empty lists should include everything")
+	}
+
+}
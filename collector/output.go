@@ -0,0 +1,83 @@
+package collector
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// EventsWriter describe a destination that replaces the api server for the collector events
+type EventsWriter interface {
+	Write(executionID string, events []EventCollector) error
+}
+
+// OutputHeader describe the line that opens the events of an execution in the output file
+type OutputHeader struct {
+	ExecutionID string `json:"execution_id"`
+}
+
+// FileOutput writes the collector events to a file as newline delimited JSON.
+// Every execution starts with a header line, followed by a line for each one of the execution events
+type FileOutput struct {
+	file              *os.File
+	encoder           *json.Encoder
+	mutex             *sync.Mutex
+	lastExecutionID   string
+	writtenEventCount int
+}
+
+// NewFileOutput opens (or creates) the given file for appending collector events
+func NewFileOutput(path string) (*FileOutput, error) {
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	log.WithField("file", path).Info("collector events will be written to a file")
+	return &FileOutput{
+		file:    file,
+		encoder: json.NewEncoder(file),
+		mutex:   &sync.Mutex{},
+	}, nil
+}
+
+// Write appends the given events to the output file
+func (fo *FileOutput) Write(executionID string, events []EventCollector) error {
+
+	fo.mutex.Lock()
+	defer fo.mutex.Unlock()
+
+	if fo.lastExecutionID != executionID {
+		err := fo.encoder.Encode(OutputHeader{ExecutionID: executionID})
+		if err != nil {
+			return err
+		}
+		fo.lastExecutionID = executionID
+	}
+
+	for _, event := range events {
+		err := fo.encoder.Encode(event)
+		if err != nil {
+			return err
+		}
+	}
+	fo.writtenEventCount += len(events)
+
+	return fo.file.Sync()
+}
+
+// Close closes the output file
+func (fo *FileOutput) Close() error {
+
+	fo.mutex.Lock()
+	defer fo.mutex.Unlock()
+
+	log.WithFields(log.Fields{
+		"file":        fo.file.Name(),
+		"event_count": fo.writtenEventCount,
+	}).Info("collector output file was closed")
+	return fo.file.Close()
+}
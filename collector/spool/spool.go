@@ -0,0 +1,267 @@
+package spool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// segmentExtension defines the spool segment file extension
+	segmentExtension = ".segment"
+
+	// tempExtension defines the extension of a segment which was not fully written yet
+	tempExtension = ".tmp"
+
+	// quarantineExtension defines the extension of a segment which could not be read or was rejected by the api
+	// server, the quarantined segments are kept for inspection and are not returned as spooled segments
+	quarantineExtension = ".bad"
+)
+
+// ErrSegmentTooLarge returned when a single segment is bigger than the spool max size
+var ErrSegmentTooLarge = errors.New("segment size is bigger than the spool max size")
+
+// Segment describe a single batch of events that was spooled to the disk
+type Segment struct {
+	Name        string          `json:"-"`
+	ExecutionID string          `json:"execution_id"`
+	Events      json.RawMessage `json:"events"`
+}
+
+// Spool stores unsent events batches as segment files in a directory
+type Spool struct {
+	directory string
+	maxSize   uint64
+	sequence  uint64
+	mutex     *sync.Mutex
+}
+
+// NewSpool creates a new spool in the given directory. maxSize defines the maximum
+// total size (in bytes) of all the segments, 0 means unlimited
+func NewSpool(directory string, maxSize uint64) (*Spool, error) {
+
+	err := os.MkdirAll(directory, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	spool := &Spool{
+		directory: directory,
+		maxSize:   maxSize,
+		mutex:     &sync.Mutex{},
+	}
+
+	files, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	// Continue the segments sequence from the last run and clean partially written segments
+	for _, file := range files {
+		switch filepath.Ext(file.Name()) {
+		case tempExtension:
+			log.WithField("segment", file.Name()).Warn("removing partially written spool segment")
+			_ = os.Remove(filepath.Join(directory, file.Name()))
+		case segmentExtension:
+			sequence, err := strconv.ParseUint(strings.TrimSuffix(file.Name(), segmentExtension), 10, 64)
+			if err != nil {
+				continue
+			}
+			if sequence > spool.sequence {
+				spool.sequence = sequence
+			}
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"directory": directory,
+		"max_size":  maxSize,
+	}).Info("events spool is ready")
+
+	return spool, nil
+}
+
+// Push writes the given events batch as a new segment.
+// When the spool size limit is reached, the oldest segments are removed
+func (s *Spool) Push(executionID string, events []byte) error {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	buf, err := json.Marshal(Segment{
+		ExecutionID: executionID,
+		Events:      events,
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.maxSize > 0 {
+		if uint64(len(buf)) > s.maxSize {
+			return ErrSegmentTooLarge
+		}
+		err = s.truncate(s.maxSize - uint64(len(buf)))
+		if err != nil {
+			return err
+		}
+	}
+
+	s.sequence++
+	name := fmt.Sprintf("%020d%s", s.sequence, segmentExtension)
+	tempPath := filepath.Join(s.directory, name+tempExtension)
+
+	// Write to a temporary file first, so a killed collector will never leave a partial segment
+	err = ioutil.WriteFile(tempPath, buf, 0644)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tempPath, filepath.Join(s.directory, name))
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"segment":      name,
+		"execution_id": executionID,
+	}).Info("events were written to the spool")
+
+	return nil
+}
+
+// Segments returns all the readable spooled segments, ordered from the oldest to the newest
+func (s *Spool) Segments() ([]Segment, error) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	segments := []Segment{}
+	names, err := s.segmentNames()
+	if err != nil {
+		return segments, err
+	}
+
+	// An unreadable segment is quarantined, so it does not block the next segments
+	for _, name := range names {
+		buf, err := ioutil.ReadFile(filepath.Join(s.directory, name))
+		if err != nil {
+			log.WithError(err).WithField("segment", name).Error("could not read spool segment")
+			s.quarantine(name)
+			continue
+		}
+
+		segment := Segment{}
+		err = json.Unmarshal(buf, &segment)
+		if err != nil {
+			log.WithError(err).WithField("segment", name).Error("could not parse spool segment")
+			s.quarantine(name)
+			continue
+		}
+		segment.Name = name
+		segments = append(segments, segment)
+	}
+
+	return segments, nil
+}
+
+// Remove deletes the given segment from the spool
+func (s *Spool) Remove(segment Segment) error {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return os.Remove(filepath.Join(s.directory, segment.Name))
+}
+
+// Quarantine moves the given segment out of the spooled segments, the segment file is kept with the quarantine extension
+func (s *Spool) Quarantine(segment Segment) error {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return os.Rename(filepath.Join(s.directory, segment.Name), filepath.Join(s.directory, segment.Name+quarantineExtension))
+}
+
+// quarantine moves the given segment file out of the spooled segments, a segment that could not be quarantined is
+// removed
+func (s *Spool) quarantine(name string) {
+
+	path := filepath.Join(s.directory, name)
+	err := os.Rename(path, path+quarantineExtension)
+	if err == nil {
+		log.WithField("segment", name+quarantineExtension).Warn("spool segment was quarantined")
+		return
+	}
+
+	log.WithError(err).WithField("segment", name).Error("could not quarantine spool segment, removing it")
+	_ = os.Remove(path)
+}
+
+// segmentNames returns the segments file names ordered by the segment sequence
+func (s *Spool) segmentNames() ([]string, error) {
+
+	files, err := ioutil.ReadDir(s.directory)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, file := range files {
+		if filepath.Ext(file.Name()) == segmentExtension {
+			names = append(names, file.Name())
+		}
+	}
+
+	// The file names are zero padded, so the lexical order is the sequence order
+	sort.Strings(names)
+	return names, nil
+}
+
+// truncate removes the oldest segments until the spool size is less or equal to the given size
+func (s *Spool) truncate(size uint64) error {
+
+	files, err := ioutil.ReadDir(s.directory)
+	if err != nil {
+		return err
+	}
+
+	var total uint64
+	segments := []os.FileInfo{}
+	for _, file := range files {
+		if filepath.Ext(file.Name()) == segmentExtension {
+			total += uint64(file.Size())
+			segments = append(segments, file)
+		}
+	}
+
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].Name() < segments[j].Name()
+	})
+
+	for _, segment := range segments {
+		if total <= size {
+			break
+		}
+		log.WithFields(log.Fields{
+			"segment": segment.Name(),
+			"size":    segment.Size(),
+		}).Warn("spool max size reached, dropping the oldest segment")
+
+		err = os.Remove(filepath.Join(s.directory, segment.Name()))
+		if err != nil {
+			return err
+		}
+		total -= uint64(segment.Size())
+	}
+
+	return nil
+}
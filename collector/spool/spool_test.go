@@ -0,0 +1,143 @@
+package spool_test
+
+import (
+	"finala/collector/spool"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func newSpool(t *testing.T, directory string, maxSize uint64) *spool.Spool {
+
+	s, err := spool.NewSpool(directory, maxSize)
+	if err != nil {
+		t.Fatalf("unexpected spool error, got %v expected %v", err, nil)
+	}
+	return s
+}
+
+func TestSpool(t *testing.T) {
+
+	t.Run("push_and_remove", func(t *testing.T) {
+		directory, _ := ioutil.TempDir("", "spool")
+		defer os.RemoveAll(directory)
+
+		s := newSpool(t, directory, 0)
+		for _, events := range []string{`[1]`, `[2]`, `[3]`} {
+			err := s.Push("foo", []byte(events))
+			if err != nil {
+				t.Fatalf("unexpected push error, got %v expected %v", err, nil)
+			}
+		}
+
+		segments, err := s.Segments()
+		if err != nil {
+			t.Fatalf("unexpected segments error, got %v expected %v", err, nil)
+		}
+
+		if len(segments) != 3 {
+			t.Fatalf("unexpected segments count, got %d expected %d", len(segments), 3)
+		}
+
+		if string(segments[0].Events) != `[1]` || segments[0].ExecutionID != "foo" {
+			t.Fatalf("unexpected first segment, got %s expected %s", string(segments[0].Events), `[1]`)
+		}
+
+		err = s.Remove(segments[0])
+		if err != nil {
+			t.Fatalf("unexpected remove error, got %v expected %v", err, nil)
+		}
+
+		segments, _ = s.Segments()
+		if len(segments) != 2 {
+			t.Fatalf("unexpected segments count, got %d expected %d", len(segments), 2)
+		}
+	})
+
+	t.Run("order_across_runs", func(t *testing.T) {
+		directory, _ := ioutil.TempDir("", "spool")
+		defer os.RemoveAll(directory)
+
+		_ = newSpool(t, directory, 0).Push("first_run", []byte(`[1]`))
+		_ = ioutil.WriteFile(directory+"/00000000000000000009.segment.tmp", []byte(`[`), 0644)
+		_ = newSpool(t, directory, 0).Push("second_run", []byte(`[2]`))
+
+		segments, err := newSpool(t, directory, 0).Segments()
+		if err != nil {
+			t.Fatalf("unexpected segments error, got %v expected %v", err, nil)
+		}
+
+		if len(segments) != 2 {
+			t.Fatalf("unexpected segments count, got %d expected %d", len(segments), 2)
+		}
+
+		if segments[0].ExecutionID != "first_run" || segments[1].ExecutionID != "second_run" {
+			t.Fatalf("unexpected segments order, got %s, %s", segments[0].ExecutionID, segments[1].ExecutionID)
+		}
+	})
+
+	t.Run("quarantine", func(t *testing.T) {
+		directory, _ := ioutil.TempDir("", "spool")
+		defer os.RemoveAll(directory)
+
+		s := newSpool(t, directory, 0)
+		_ = s.Push("foo", []byte(`[1]`))
+		_ = ioutil.WriteFile(directory+"/00000000000000000002.segment", []byte(`{"events": [`), 0644)
+		s = newSpool(t, directory, 0)
+		_ = s.Push("foo", []byte(`[3]`))
+
+		// The unparseable segment is quarantined and does not fail the next segments
+		segments, err := s.Segments()
+		if err != nil {
+			t.Fatalf("unexpected segments error, got %v expected %v", err, nil)
+		}
+
+		if len(segments) != 2 || string(segments[1].Events) != `[3]` {
+			t.Fatalf("unexpected segments, got %v", segments)
+		}
+
+		err = s.Quarantine(segments[0])
+		if err != nil {
+			t.Fatalf("unexpected quarantine error, got %v expected %v", err, nil)
+		}
+
+		segments, _ = s.Segments()
+		if len(segments) != 1 {
+			t.Fatalf("unexpected segments count, got %d expected %d", len(segments), 1)
+		}
+
+		for _, name := range []string{"00000000000000000001.segment.bad", "00000000000000000002.segment.bad"} {
+			if _, err := os.Stat(directory + "/" + name); err != nil {
+				t.Fatalf("unexpected quarantined segment %s, got error %v", name, err)
+			}
+		}
+	})
+
+	t.Run("max_size", func(t *testing.T) {
+		directory, _ := ioutil.TempDir("", "spool")
+		defer os.RemoveAll(directory)
+
+		// Each segment is 36 bytes, so only two segments can be kept
+		s := newSpool(t, directory, 100)
+		for _, events := range []string{`[1]`, `[2]`, `[3]`} {
+			_ = s.Push("foo", []byte(events))
+		}
+
+		segments, _ := s.Segments()
+		if len(segments) != 2 {
+			t.Fatalf("unexpected segments count, got %d expected %d", len(segments), 2)
+		}
+
+		if string(segments[0].Events) != `[2]` {
+			t.Fatalf("unexpected oldest segment, got %s expected %s", string(segments[0].Events), `[2]`)
+		}
+
+		err := s.Push("foo", []byte(fmt.Sprintf(`["%s"]`, strings.Repeat("a", 200))))
+		if err != spool.ErrSegmentTooLarge {
+			t.Fatalf("unexpected push error, got %v expected %v", err, spool.ErrSegmentTooLarge)
+		}
+	})
+
+}
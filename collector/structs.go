@@ -23,17 +23,35 @@ const (
 
 // EventStatusData descrive the struct of the resource statuses
 type EventStatusData struct {
-	Status       EventStatus
-	ErrorMessage string
+	Status          EventStatus
+	ErrorMessage    string
+	SuppressedCount int
 }
 
-// PriceDetectedFields describe the pricing field
+// PriceDetectedFields describe the pricing field.
+// PricePerHour and PricePerMonth are the on demand prices, the effective prices exclude the share of the usage
+// that is covered by reserved instances and savings plans
 type PriceDetectedFields struct {
-	ResourceID    string
-	LaunchTime    time.Time
-	PricePerHour  float64
-	PricePerMonth float64
-	Tag           map[string]string
+	ResourceID             string
+	LaunchTime             time.Time
+	PricePerHour           float64
+	PricePerMonth          float64
+	EffectivePricePerHour  float64
+	EffectivePricePerMonth float64
+	CoveragePercentage     float64
+	Tag                    map[string]string
+}
+
+// MetricDetectedFields describe the metric evaluation that detected the resource.
+// The constraint operator and value are the root rule, and the constraint is the whole rule with its sub constraints
+type MetricDetectedFields struct {
+	MetricValues       map[string]interface{}
+	FormulaValue       float64
+	ConstraintOperator string
+	ConstraintValue    float64
+	Constraint         string
+	MetricStartTime    time.Time
+	MetricEndTime      time.Time
 }
 
 // EventCollector collector event data structure
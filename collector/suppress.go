@@ -0,0 +1,148 @@
+package collector
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// snoozeDateLayouts defines the accepted formats of a snooze tag value
+var snoozeDateLayouts = []string{"2006-01-02", time.RFC3339}
+
+// RunCollectorDescriber describe a collector that keeps a separate state for every detection run (an account region)
+type RunCollectorDescriber interface {
+	NewRun() CollectorDescriber
+}
+
+// SuppressCollector wraps a collector and drops the detected resources that are marked as intentionally unused by their tags.
+// A resource is suppressed when it has one of the ignore tags (an empty tag value matches any value),
+// or when it has a snooze tag with a date in the future. The suppressed resources are counted by the resource name,
+// so every detection run should use its own suppress collector (see NewRun)
+type SuppressCollector struct {
+	CollectorDescriber
+	ignoreTags map[string]string
+	snoozeTags []string
+	suppressed map[ResourceIdentifier]int
+	mutex      *sync.Mutex
+	now        func() time.Time
+}
+
+// NewSuppressCollector creates new suppress collector instance
+func NewSuppressCollector(cl CollectorDescriber, ignoreTags map[string]string, snoozeTags []string) *SuppressCollector {
+	return &SuppressCollector{
+		CollectorDescriber: cl,
+		ignoreTags:         ignoreTags,
+		snoozeTags:         snoozeTags,
+		suppressed:         make(map[ResourceIdentifier]int),
+		mutex:              &sync.Mutex{},
+		now:                time.Now,
+	}
+}
+
+// NewRun returns a suppress collector with the same tags and its own suppressed counts, for a single detection run
+func (sc *SuppressCollector) NewRun() CollectorDescriber {
+
+	run := NewSuppressCollector(sc.CollectorDescriber, sc.ignoreTags, sc.snoozeTags)
+	run.now = sc.now
+	return run
+}
+
+// AddResource add the resource data, unless the resource tags suppress it
+func (sc *SuppressCollector) AddResource(data EventCollector) {
+
+	if sc.isSuppressed(resourceTags(data.Data)) {
+		log.WithField("resource_name", data.ResourceName).Debug("resource was suppressed by its tags")
+
+		sc.mutex.Lock()
+		sc.suppressed[data.ResourceName]++
+		sc.mutex.Unlock()
+		return
+	}
+
+	sc.CollectorDescriber.AddResource(data)
+}
+
+// CollectFinish add `finish` event with the count of the suppressed resources since the last finish event
+func (sc *SuppressCollector) CollectFinish(resourceName ResourceIdentifier) {
+
+	sc.mutex.Lock()
+	suppressedCount := sc.suppressed[resourceName]
+	delete(sc.suppressed, resourceName)
+	sc.mutex.Unlock()
+
+	if suppressedCount > 0 {
+		log.WithFields(log.Fields{
+			"resource_name":    resourceName,
+			"suppressed_count": suppressedCount,
+		}).Info("detected resources were suppressed by their tags")
+	}
+
+	sc.CollectorDescriber.CollectFinishSuppressed(resourceName, suppressedCount)
+}
+
+// isSuppressed returns true if the given resource tags match the ignore tags or an active snooze tag
+func (sc *SuppressCollector) isSuppressed(tags map[string]string) bool {
+
+	for key, value := range sc.ignoreTags {
+		tagValue, found := tags[key]
+		if found && (value == "" || tagValue == value) {
+			return true
+		}
+	}
+
+	for _, key := range sc.snoozeTags {
+		tagValue, found := tags[key]
+		if !found {
+			continue
+		}
+
+		until, err := parseSnoozeDate(tagValue)
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"tag":   key,
+				"value": tagValue,
+			}).Warn("could not parse snooze tag date")
+			continue
+		}
+
+		if sc.now().Before(until) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseSnoozeDate parses the snooze tag value by the accepted date layouts
+func parseSnoozeDate(value string) (time.Time, error) {
+
+	var until time.Time
+	var err error
+	for _, layout := range snoozeDateLayouts {
+		until, err = time.Parse(layout, value)
+		if err == nil {
+			return until, nil
+		}
+	}
+
+	return until, err
+}
+
+// resourceTags returns the `Tag` field of the detected resource data, or nil if the resource has no tags
+func resourceTags(data interface{}) map[string]string {
+
+	value := reflect.Indirect(reflect.ValueOf(data))
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	field := value.FieldByName("Tag")
+	if !field.IsValid() {
+		return nil
+	}
+
+	tags, _ := field.Interface().(map[string]string)
+	return tags
+}
@@ -0,0 +1,109 @@
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+type mockDetectedResource struct {
+	Region string
+	PriceDetectedFields
+}
+
+type mockStatusCollector struct {
+	CollectorDescriber
+	events          []EventCollector
+	suppressedCount int
+}
+
+func (mc *mockStatusCollector) AddResource(data EventCollector) {
+	mc.events = append(mc.events, data)
+}
+
+func (mc *mockStatusCollector) CollectFinishSuppressed(resourceName ResourceIdentifier, suppressedCount int) {
+	mc.suppressedCount = suppressedCount
+}
+
+func TestSuppressCollector(t *testing.T) {
+
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name               string
+		tags               map[string]string
+		expectedSuppressed bool
+	}{
+		{"no_tags", nil, false},
+		{"ignore_tag", map[string]string{"finala": "ignore"}, true},
+		{"ignore_tag_other_value", map[string]string{"finala": "report"}, false},
+		{"ignore_tag_any_value", map[string]string{"dr-standby": "eu-west-1"}, true},
+		{"snooze_future_date", map[string]string{"finala-snooze-until": "2026-12-01"}, true},
+		{"snooze_future_rfc3339", map[string]string{"finala-snooze-until": "2026-06-01T10:00:00Z"}, true},
+		{"snooze_expired", map[string]string{"finala-snooze-until": "2026-01-01"}, false},
+		{"snooze_invalid_date", map[string]string{"finala-snooze-until": "next week"}, false},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			mockCollector := &mockStatusCollector{}
+			suppressCollector := NewSuppressCollector(mockCollector, map[string]string{"finala": "ignore", "dr-standby": ""}, []string{"finala-snooze-until"})
+			suppressCollector.now = func() time.Time { return now }
+
+			suppressCollector.AddResource(EventCollector{
+				ResourceName: "aws_test",
+				Data: mockDetectedResource{
+					PriceDetectedFields: PriceDetectedFields{Tag: test.tags},
+				},
+			})
+			suppressCollector.CollectFinish("aws_test")
+
+			expectedEvents, expectedSuppressedCount := 1, 0
+			if test.expectedSuppressed {
+				expectedEvents, expectedSuppressedCount = 0, 1
+			}
+
+			if len(mockCollector.events) != expectedEvents {
+				t.Fatalf("unexpected collector events, got %d expected %d", len(mockCollector.events), expectedEvents)
+			}
+
+			if mockCollector.suppressedCount != expectedSuppressedCount {
+				t.Fatalf("unexpected suppressed count, got %d expected %d", mockCollector.suppressedCount, expectedSuppressedCount)
+			}
+		})
+	}
+
+	t.Run("runs", func(t *testing.T) {
+		mockCollector := &mockStatusCollector{}
+		suppressCollector := NewSuppressCollector(mockCollector, map[string]string{"finala": "ignore"}, nil)
+		firstRun := suppressCollector.NewRun()
+		secondRun := suppressCollector.NewRun()
+
+		suppressed := EventCollector{
+			ResourceName: "aws_test",
+			Data: mockDetectedResource{
+				PriceDetectedFields: PriceDetectedFields{Tag: map[string]string{"finala": "ignore"}},
+			},
+		}
+		firstRun.AddResource(suppressed)
+		secondRun.AddResource(suppressed)
+		secondRun.AddResource(suppressed)
+
+		// The first run to finish reports only its own suppressed resources
+		firstRun.CollectFinish("aws_test")
+		if mockCollector.suppressedCount != 1 {
+			t.Fatalf("unexpected suppressed count, got %d expected %d", mockCollector.suppressedCount, 1)
+		}
+
+		secondRun.CollectFinish("aws_test")
+		if mockCollector.suppressedCount != 2 {
+			t.Fatalf("unexpected suppressed count, got %d expected %d", mockCollector.suppressedCount, 2)
+		}
+	})
+
+	t.Run("without_tag_field", func(t *testing.T) {
+		if resourceTags(struct{ Name string }{"test"}) != nil {
+			t.Fatalf("unexpected resource tags, expected nil")
+		}
+	})
+
+}
@@ -33,10 +33,14 @@ func (mc *MockCollector) CollectStart(resourceName collector.ResourceIdentifier)
 
 }
 func (mc *MockCollector) CollectFinish(resourceName collector.ResourceIdentifier) {
+	mc.CollectFinishSuppressed(resourceName, 0)
+}
+func (mc *MockCollector) CollectFinishSuppressed(resourceName collector.ResourceIdentifier, suppressedCount int) {
 	mc.updateServiceStatus(collector.EventCollector{
 		ResourceName: resourceName,
 		Data: collector.EventStatusData{
-			Status: collector.EventFinish,
+			Status:          collector.EventFinish,
+			SuppressedCount: suppressedCount,
 		},
 	})
 
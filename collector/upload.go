@@ -0,0 +1,123 @@
+package collector
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"finala/request"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// uploadBulkSize defines the max events count of a single upload request
+	uploadBulkSize = 500
+
+	// maxOutputLineSize defines the max size of a single event line in the output file
+	maxOutputLineSize = 10 * 1024 * 1024
+)
+
+// ErrMissingOutputHeader returned when an event appears in the output file before any execution header
+var ErrMissingOutputHeader = errors.New("event found before the execution header line")
+
+// UploadFile sends all the events of a collector output file to the api server, under their original execution id
+func UploadFile(req *request.HTTPClient, apiEndpoint string, path string) error {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	uploader := &fileUploader{
+		request:     req,
+		apiEndpoint: apiEndpoint,
+		events:      []json.RawMessage{},
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxOutputLineSize)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		header := OutputHeader{}
+		err = json.Unmarshal(line, &header)
+		if err != nil {
+			return err
+		}
+
+		// A header line opens the events of a new execution
+		if header.ExecutionID != "" {
+			err = uploader.flush()
+			if err != nil {
+				return err
+			}
+			uploader.executionID = header.ExecutionID
+			uploader.sentCount = 0
+			continue
+		}
+
+		if uploader.executionID == "" {
+			return ErrMissingOutputHeader
+		}
+
+		// The scanner reuses its buffer, the line must be copied before it is kept
+		event := make(json.RawMessage, len(line))
+		copy(event, line)
+		uploader.events = append(uploader.events, event)
+
+		if len(uploader.events) >= uploadBulkSize {
+			err = uploader.flush()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return uploader.flush()
+}
+
+// fileUploader holds the pending events of the current uploaded execution
+type fileUploader struct {
+	request     *request.HTTPClient
+	apiEndpoint string
+	executionID string
+	events      []json.RawMessage
+	sentCount   int
+}
+
+// flush sends the pending events to the api server
+func (fu *fileUploader) flush() error {
+
+	if len(fu.events) == 0 {
+		return nil
+	}
+
+	buf, err := json.Marshal(fu.events)
+	if err != nil {
+		return err
+	}
+
+	err = postEvents(fu.request, fu.apiEndpoint, fu.executionID, buf)
+	if err != nil {
+		return fmt.Errorf("could not upload events of execution %s: %w", fu.executionID, err)
+	}
+
+	fu.sentCount += len(fu.events)
+	log.WithFields(log.Fields{
+		"execution_id": fu.executionID,
+		"event_count":  fu.sentCount,
+	}).Info("events were uploaded")
+
+	fu.events = []json.RawMessage{}
+	return nil
+}
@@ -0,0 +1,83 @@
+package collector_test
+
+import (
+	"encoding/json"
+	"finala/collector"
+	"finala/request"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUploadFile(t *testing.T) {
+
+	directory, _ := ioutil.TempDir("", "output")
+	defer os.RemoveAll(directory)
+	path := filepath.Join(directory, "events.json")
+
+	output, err := collector.NewFileOutput(path)
+	if err != nil {
+		t.Fatalf("unexpected file output error, got %v expected %v", err, nil)
+	}
+
+	_ = output.Write("first_run", []collector.EventCollector{{ResourceName: "test1"}, {ResourceName: "test2"}})
+	_ = output.Write("first_run", []collector.EventCollector{{ResourceName: "test3"}})
+	_ = output.Write("second_run", []collector.EventCollector{{ResourceName: "test4"}})
+	_ = output.Close()
+
+	receivedEvents := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		var events []collector.EventCollector
+		buf, _ := ioutil.ReadAll(req.Body)
+		if err := json.Unmarshal(buf, &events); err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		executionID := strings.TrimPrefix(req.URL.Path, "/api/v1/detect-events/")
+		receivedEvents[executionID] += len(events)
+		resp.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	t.Run("valid", func(t *testing.T) {
+		err := collector.UploadFile(request.NewHTTPClient(), server.URL, path)
+		if err != nil {
+			t.Fatalf("unexpected upload error, got %v expected %v", err, nil)
+		}
+
+		if receivedEvents["first_run"] != 3 {
+			t.Fatalf("unexpected first execution events, got %d expected %d", receivedEvents["first_run"], 3)
+		}
+
+		if receivedEvents["second_run"] != 1 {
+			t.Fatalf("unexpected second execution events, got %d expected %d", receivedEvents["second_run"], 1)
+		}
+	})
+
+	t.Run("missing_header", func(t *testing.T) {
+		invalidPath := filepath.Join(directory, "invalid.json")
+		_ = ioutil.WriteFile(invalidPath, []byte(`{"ResourceName":"test1"}`), 0644)
+
+		err := collector.UploadFile(request.NewHTTPClient(), server.URL, invalidPath)
+		if err != collector.ErrMissingOutputHeader {
+			t.Fatalf("unexpected upload error, got %v expected %v", err, collector.ErrMissingOutputHeader)
+		}
+	})
+
+	t.Run("api_unavailable", func(t *testing.T) {
+		unavailableServer := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			resp.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer unavailableServer.Close()
+
+		err := collector.UploadFile(request.NewHTTPClient(), unavailableServer.URL, path)
+		if err == nil {
+			t.Fatalf("unexpected upload error, got %v expected error", err)
+		}
+	})
+
+}
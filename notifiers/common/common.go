@@ -1,5 +1,15 @@
 package common
 
+// currencySymbols defines the symbols of the common reporting currencies
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"ILS": "₪",
+	"INR": "₹",
+}
+
 type NotifierConfig map[string]interface{}
 type NotifierName string
 type ConfigByName map[NotifierName]NotifierConfig
@@ -10,3 +20,15 @@ type Notifier interface {
 	BuildSendURL(baseURL string, executionID string, filters []Tag) string
 	Send(message NotifierReport)
 }
+
+// CurrencySymbol returns the symbol of the currency code, the code itself is returned for an unknown currency.
+// The summary of an older api has no currency, and its prices are in USD
+func CurrencySymbol(code string) string {
+	if code == "" {
+		code = "USD"
+	}
+	if symbol, found := currencySymbols[code]; found {
+		return symbol
+	}
+	return code + " "
+}
@@ -41,6 +41,7 @@ type NotifierCollectorsSummary struct {
 	ResourceName  string  `json:"ResourceName"`
 	ResourceCount int64   `json:"ResourceCount"`
 	TotalSpent    float64 `json:"TotalSpent"`
+	Currency      string  `json:"Currency"`
 	Status        int     `json:"Status"`
 	ErrorMessage  string  `json:"ErrorMessage"`
 	EventTime     int64   `json:"-"`
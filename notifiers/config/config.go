@@ -3,6 +3,7 @@ package config
 import (
 	notifierCommon "finala/notifiers/common"
 	notifierLoader "finala/notifiers/load"
+	"finala/request"
 	"io/ioutil"
 
 	log "github.com/sirupsen/logrus"
@@ -13,6 +14,7 @@ import (
 type NotifierConfig struct {
 	LogLevel            string                      `yaml:"log_level"`
 	APIServerAddr       string                      `yaml:"api_server_address"`
+	APIServerRetry      request.RetryConfig         `yaml:"api_server_retry"`
 	UIAddr              string                      `yaml:"ui_address"`
 	NotifiersConfigs    notifierCommon.ConfigByName `yaml:"notifiers"`
 	registeredNotifiers []notifierCommon.Notifier
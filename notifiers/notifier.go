@@ -3,6 +3,7 @@ package notifiers
 import (
 	"encoding/json"
 	notifierCommon "finala/notifiers/common"
+	"net/http"
 	"net/url"
 
 	"finala/request"
@@ -49,6 +50,12 @@ func (dfm *DataFetcherManager) GetLatestExecution() (latestExecution string, err
 
 	defer res.Body.Close()
 
+	if res.StatusCode != http.StatusOK {
+		err = &request.HttpError{Status: res.Status, StatusCode: res.StatusCode}
+		dfm.log.WithError(err).Error("could not get the latest execution")
+		return "", err
+	}
+
 	var executions []notifierCommon.NotifierExecutionsResponse
 	err = json.NewDecoder(res.Body).Decode(&executions)
 
@@ -75,6 +82,12 @@ func (dfm *DataFetcherManager) GetExecutionSummary(executionID string, filterOpt
 
 	defer res.Body.Close()
 
+	if res.StatusCode != http.StatusOK {
+		err = &request.HttpError{Status: res.Status, StatusCode: res.StatusCode}
+		dfm.log.WithError(err).Error("could not get the execution summary")
+		return nil, err
+	}
+
 	var executionSummary map[string]*notifierCommon.NotifierCollectorsSummary
 	err = json.NewDecoder(res.Body).Decode(&executionSummary)
 
@@ -63,7 +63,8 @@ func (mc *dataFetcherMockClient) DO(r *http.Request) (*http.Response, error) {
 		newBody = ioutil.NopCloser(strings.NewReader(expectedSummaryResponse))
 	}
 	return &http.Response{
-		Body: newBody,
+		StatusCode: http.StatusOK,
+		Body:       newBody,
 	}, nil
 }
 
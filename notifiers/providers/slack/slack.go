@@ -92,6 +92,7 @@ func (sm *Manager) prepareAttachment(message common.NotifierReport, elasticSearc
 				strings.Join(elasticSearchQueryTags, " AND ")),
 		}}
 	var totalPotentialSaving float64
+	currencySymbol := common.CurrencySymbol("")
 	for _, executionData := range message.ExecutionSummaryData {
 		additionalFilter := common.Tag{Name: "resource", Value: executionData.ResourceName}
 		filters := append(message.NotifyByTag.Tags, additionalFilter)
@@ -101,13 +102,15 @@ func (sm *Manager) prepareAttachment(message common.NotifierReport, elasticSearc
 			continue
 		}
 		totalPotentialSaving = totalPotentialSaving + executionData.TotalSpent
+		currencySymbol = common.CurrencySymbol(executionData.Currency)
 		slackAttachments = append(slackAttachments, slackApi.Attachment{
 			Color: greenMessageColor,
 			Fields: []slackApi.AttachmentField{
 				{
 					Title: strings.ToUpper(executionData.ResourceName),
-					Value: fmt.Sprintf("Potential Saving: <%s|$%s>",
+					Value: fmt.Sprintf("Potential Saving: <%s|%s%s>",
 						resourceLink,
+						currencySymbol,
 						humanize.Commaf(math.Floor(executionData.TotalSpent))),
 					Short: false,
 				},
@@ -120,7 +123,7 @@ func (sm *Manager) prepareAttachment(message common.NotifierReport, elasticSearc
 		Color: blueMessageColor,
 		Fields: []slackApi.AttachmentField{
 			{
-				Title: fmt.Sprintf("Total Potential Savings: $%s", humanize.Commaf(math.Floor(totalPotentialSaving))),
+				Title: fmt.Sprintf("Total Potential Savings: %s%s", currencySymbol, humanize.Commaf(math.Floor(totalPotentialSaving))),
 				Short: false,
 			},
 		},
@@ -3,6 +3,7 @@ package slack
 import (
 	"finala/notifiers/common"
 	"fmt"
+	"strings"
 
 	"github.com/nlopes/slack"
 	"github.com/pkg/errors"
@@ -120,12 +121,14 @@ func TestPrepareAttachment(t *testing.T) {
 				ResourceName:  "ec2",
 				ResourceCount: 5,
 				TotalSpent:    20,
+				Currency:      "EUR",
 				Status:        2,
 			},
 			"c_resource": {
 				ResourceName:  "rds",
 				ResourceCount: 5,
 				TotalSpent:    30,
+				Currency:      "EUR",
 				Status:        1,
 			},
 		},
@@ -139,6 +142,14 @@ func TestPrepareAttachment(t *testing.T) {
 			t.Fatalf("unexpected len of slack attachments , got %d expected %d", len(attachments), 3)
 		}
 	})
+	t.Run("check slack attachments currency", func(t *testing.T) {
+		if !strings.Contains(attachments[1].Fields[0].Value, "|€30>") {
+			t.Fatalf("unexpected potential saving, got %s expected %s currency", attachments[1].Fields[0].Value, "EUR")
+		}
+		if attachments[2].Fields[0].Title != "Total Potential Savings: €30" {
+			t.Fatalf("unexpected total potential savings, got %s expected %s", attachments[2].Fields[0].Title, "Total Potential Savings: €30")
+		}
+	})
 }
 
 func TestFormatTagsElasticSearchQuery(t *testing.T) {
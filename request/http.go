@@ -3,12 +3,35 @@ package request
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	// defaultMaxAttempts defines the default number of attempts of a single request (no retries)
+	defaultMaxAttempts = 1
+
+	// defaultBaseDelay defines the default delay before the first retry
+	defaultBaseDelay = 500 * time.Millisecond
+
+	// defaultMaxDelay defines the default maximum delay between retries
+	defaultMaxDelay = 30 * time.Second
+)
+
+// defaultRetryableStatusCodes defines the response status codes that will be retried by default
+var defaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
 type HTTPClientDescriber interface {
 	Request(method string, url string, v url.Values, body io.Reader) (*http.Request, error)
 	DO(r *http.Request) (*http.Response, error)
@@ -23,16 +46,64 @@ func (e *HttpError) Error() string {
 	return fmt.Sprintf("HTTP error: %d - %s", e.StatusCode, e.Status)
 }
 
+// RetryConfig describe the retry policy of the HTTP client.
+// The delay between attempts grows exponentially from BaseDelay up to MaxDelay, with a random jitter
+type RetryConfig struct {
+	MaxAttempts          int           `yaml:"max_attempts"`
+	BaseDelay            time.Duration `yaml:"base_delay"`
+	MaxDelay             time.Duration `yaml:"max_delay"`
+	AttemptTimeout       time.Duration `yaml:"attempt_timeout"`
+	RetryableStatusCodes []int         `yaml:"retryable_status_codes"`
+}
+
 // HTTPClient precent http client struct
 type HTTPClient struct {
-	http *http.Client
+	http                 *http.Client
+	maxAttempts          int
+	baseDelay            time.Duration
+	maxDelay             time.Duration
+	retryableStatusCodes map[int]struct{}
 }
 
 // NewHTTPClient create new request client
 func NewHTTPClient() *HTTPClient {
-	return &HTTPClient{
-		http: &http.Client{},
+	return NewHTTPClientWithRetry(RetryConfig{})
+}
+
+// NewHTTPClientWithRetry create new request client with the given retry policy, unset fields get the default values
+func NewHTTPClientWithRetry(retry RetryConfig) *HTTPClient {
+
+	client := &HTTPClient{
+		http: &http.Client{
+			Timeout: retry.AttemptTimeout,
+		},
+		maxAttempts:          retry.MaxAttempts,
+		baseDelay:            retry.BaseDelay,
+		maxDelay:             retry.MaxDelay,
+		retryableStatusCodes: make(map[int]struct{}),
+	}
+
+	if client.maxAttempts <= 0 {
+		client.maxAttempts = defaultMaxAttempts
+	}
+
+	if client.baseDelay <= 0 {
+		client.baseDelay = defaultBaseDelay
+	}
+
+	if client.maxDelay <= 0 {
+		client.maxDelay = defaultMaxDelay
+	}
+
+	statusCodes := retry.RetryableStatusCodes
+	if len(statusCodes) == 0 {
+		statusCodes = defaultRetryableStatusCodes
 	}
+	for _, statusCode := range statusCodes {
+		client.retryableStatusCodes[statusCode] = struct{}{}
+	}
+
+	return client
 }
 
 // Request create a HTTP client request
@@ -55,7 +126,78 @@ func (c HTTPClient) Request(method string, url string, v url.Values, body io.Rea
 	return r, nil
 }
 
-// DO sends an HTTP request and returns an HTTP response
+// DO sends an HTTP request and returns an HTTP response.
+// Transport errors and retryable status codes are retried according to the client retry policy,
+// when all the attempts fail the last response (or error) is returned
 func (c HTTPClient) DO(r *http.Request) (*http.Response, error) {
-	return c.http.Do(r)
+
+	for attempt := 1; ; attempt++ {
+
+		res, err := c.http.Do(r)
+		if attempt >= c.maxAttempts || !c.isRetryable(res, err) {
+			return res, err
+		}
+
+		// The request body was already consumed, it can be retried only if it can be recreated
+		if r.Body != nil && r.GetBody == nil {
+			return res, err
+		}
+
+		delay := c.backoff(attempt)
+		logger := log.WithFields(log.Fields{
+			"method":       r.Method,
+			"url":          r.URL.String(),
+			"attempt":      attempt,
+			"max_attempts": c.maxAttempts,
+			"delay":        delay,
+		})
+		if err != nil {
+			logger = logger.WithError(err)
+		} else {
+			logger = logger.WithField("status_code", res.StatusCode)
+			_, _ = io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+		}
+		logger.Warn("HTTP request failed, retrying")
+
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return nil, r.Context().Err()
+		}
+
+		if r.GetBody != nil {
+			body, err := r.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r.Body = body
+		}
+	}
+}
+
+// isRetryable returns true if the request attempt result should be retried
+func (c HTTPClient) isRetryable(res *http.Response, err error) bool {
+
+	if err != nil {
+		return true
+	}
+	_, found := c.retryableStatusCodes[res.StatusCode]
+	return found
+}
+
+// backoff returns the delay before the next attempt. The delay is a random value between
+// zero and the exponential delay of the given attempt (full jitter), capped by the max delay
+func (c HTTPClient) backoff(attempt int) time.Duration {
+
+	delay := c.maxDelay
+	// Avoid overflow of the exponential delay on high attempts
+	if attempt < 32 {
+		exponential := c.baseDelay * time.Duration(1<<uint(attempt-1))
+		if exponential > 0 && exponential < c.maxDelay {
+			delay = exponential
+		}
+	}
+
+	return time.Duration(rand.Int63n(int64(delay) + 1))
 }
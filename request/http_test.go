@@ -1,10 +1,13 @@
 package request
 
 import (
+	"bytes"
 	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -55,3 +58,115 @@ func TestClient(t *testing.T) {
 	}
 
 }
+
+func TestClientRetry(t *testing.T) {
+
+	retry := RetryConfig{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    time.Millisecond * 5,
+	}
+
+	testCases := []struct {
+		name               string
+		responses          []int
+		expectedStatusCode int
+		expectedAttempts   int
+	}{
+		{"success", []int{http.StatusOK}, http.StatusOK, 1},
+		{"retry_success", []int{http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusOK}, http.StatusOK, 3},
+		{"retry_exhausted", []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK}, http.StatusServiceUnavailable, 3},
+		{"not_retryable", []int{http.StatusBadRequest, http.StatusOK}, http.StatusBadRequest, 1},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			var attempts int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				if string(body) != "foo" {
+					w.WriteHeader(http.StatusTeapot)
+					return
+				}
+				attempt := atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(test.responses[attempt-1])
+			}))
+			defer srv.Close()
+
+			c := NewHTTPClientWithRetry(retry)
+			req, err := c.Request("POST", srv.URL, nil, bytes.NewBufferString("foo"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := c.DO(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.StatusCode != test.expectedStatusCode {
+				t.Fatalf("unexpected status code: got %d want %d", res.StatusCode, test.expectedStatusCode)
+			}
+
+			if count := atomic.LoadInt32(&attempts); int(count) != test.expectedAttempts {
+				t.Fatalf("unexpected attempts count: got %d want %d", count, test.expectedAttempts)
+			}
+		})
+	}
+
+	t.Run("attempt_timeout", func(t *testing.T) {
+		var attempts int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			time.Sleep(time.Millisecond * 200)
+		}))
+		defer srv.Close()
+
+		c := NewHTTPClientWithRetry(RetryConfig{
+			MaxAttempts:    2,
+			BaseDelay:      time.Millisecond,
+			AttemptTimeout: time.Millisecond * 50,
+		})
+		req, err := c.Request("GET", srv.URL, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = c.DO(req)
+		if err == nil {
+			t.Fatalf("unexpected timeout error, got nil")
+		}
+
+		if count := atomic.LoadInt32(&attempts); count != 2 {
+			t.Fatalf("unexpected attempts count: got %d want %d", count, 2)
+		}
+	})
+
+}
+
+func TestClientBackoff(t *testing.T) {
+
+	c := NewHTTPClientWithRetry(RetryConfig{
+		BaseDelay: time.Second,
+		MaxDelay:  time.Second * 10,
+	})
+
+	testCases := []struct {
+		attempt  int
+		maxDelay time.Duration
+	}{
+		{1, time.Second},
+		{2, time.Second * 2},
+		{3, time.Second * 4},
+		{10, time.Second * 10},
+		{100, time.Second * 10},
+	}
+
+	for _, test := range testCases {
+		delay := c.backoff(test.attempt)
+		if delay < 0 || delay > test.maxDelay {
+			t.Fatalf("unexpected backoff delay for attempt %d: got %s want up to %s", test.attempt, delay, test.maxDelay)
+		}
+	}
+
+}
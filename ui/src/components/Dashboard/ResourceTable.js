@@ -7,6 +7,7 @@ import TextUtils from "utils/Text";
 import TagsDialog from "../Dialog/Tags";
 import ReportProblemIcon from "@material-ui/icons/ReportProblem";
 import { getHistory } from "../../utils/History";
+import { MoneyDirective } from "../../utils/Money";
 import { useTableFilters } from "../../Hooks/TableHooks";
 
 import {
@@ -81,18 +82,37 @@ const ResourceTable = ({
    */
   const getRowRender = (key) => {
     let renderr = false;
+    const resourceInfo = resources[currentResource] || {};
     switch (key) {
       case "PricePerMonth":
+      case "EffectivePricePerMonth":
       case "TotalSpendPrice":
-        renderr = (data) => <span>{numeral(data).format("$ 0,0[.]00")}</span>;
+        renderr = (data) => (
+          <span>{MoneyDirective(data, resourceInfo.Currency)}</span>
+        );
         break;
       case "PricePerHour":
-        renderr = (data) => <span>{numeral(data).format("$ 0,0[.]000")}</span>;
+      case "EffectivePricePerHour":
+        renderr = (data) => (
+          <span>
+            {MoneyDirective(data, resourceInfo.Currency, "0,0[.]000")}
+          </span>
+        );
+        break;
+      case "CoveragePercentage":
+        renderr = (data) => (
+          <span>{numeral(data / 100).format("0[.]0%")}</span>
+        );
         break;
       case "Tag":
         renderr = (data) => <TagsDialog tags={data} />;
         break;
+      case "MetricValues":
+        renderr = (data) => <span>{JSON.stringify(data)}</span>;
+        break;
       case "LaunchTime":
+      case "MetricStartTime":
+      case "MetricEndTime":
         renderr = (data) => (
           <span>{Moment(data).format("YYYY-MM-DD HH:mm")}</span>
         );
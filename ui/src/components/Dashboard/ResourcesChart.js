@@ -148,7 +148,7 @@ const ResourcesChart = ({
    */
   sortedResources.forEach((resource) => {
     const title = titleDirective(resource.ResourceName);
-    const amount = MoneyDirective(resource.TotalSpent);
+    const amount = MoneyDirective(resource.TotalSpent, resource.Currency);
     resource.title = `${title} (${amount})`;
     resource.display_title = `${title}`;
 
@@ -36,7 +36,7 @@ const ResourcesList = ({ resources, filters, addFilter, setResource }) => {
     .sort((a, b) => (a.TotalSpent > b.TotalSpent ? -1 : 1))
     .map((resource) => {
       const title = titleDirective(resource.ResourceName);
-      const amount = MoneyDirective(resource.TotalSpent);
+      const amount = MoneyDirective(resource.TotalSpent, resource.Currency);
       resource.title = `${title} (${amount})`;
       resource.display_title = `${title}`;
 
@@ -76,7 +76,19 @@ const StatisticsBar = ({
     return acc + TotalSpent;
   }, 0);
 
+  // The effective spent excludes the spent that is covered by reserved instances and savings plans
+  const EffectiveTotalSpent = Object.values(resources).reduce(
+    (acc, resource) => {
+      if (currentResource && currentResource !== resource.ResourceName) {
+        return acc;
+      }
+      return acc + (resource.EffectiveTotalSpent || 0);
+    },
+    0
+  );
+
   const DailySpent = TotalSpent / 30;
+  const Currency = (Object.values(resources)[0] || {}).Currency;
 
   return (
     <Fragment>
@@ -92,10 +104,18 @@ const StatisticsBar = ({
                     )}
                     {!isResourceListLoading && (
                       <Typography className={classes.unused}>
-                        {MoneyDirective(TotalSpent)}
+                        {MoneyDirective(TotalSpent, Currency)}
                       </Typography>
                     )}
                     <Typography>Monthly unused resources</Typography>
+                    {!isResourceListLoading &&
+                      EffectiveTotalSpent > 0 &&
+                      EffectiveTotalSpent < TotalSpent && (
+                        <Typography variant="caption">
+                          {MoneyDirective(EffectiveTotalSpent, Currency)} after
+                          RI and Savings Plans coverage
+                        </Typography>
+                      )}
                   </div>
                 </Tooltip>
               </Grid>
@@ -107,7 +127,7 @@ const StatisticsBar = ({
                     )}
                     {!isResourceListLoading && (
                       <Typography className={classes.unused_daily}>
-                        {MoneyDirective(DailySpent)}
+                        {MoneyDirective(DailySpent, Currency)}
                       </Typography>
                     )}
                     <Typography>Daily waste</Typography>
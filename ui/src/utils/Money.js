@@ -1,9 +1,31 @@
 import numeral from "numeral";
+
+// Symbols of the common reporting currencies, unknown currencies are presented by their code
+const currencySymbols = {
+  USD: "$",
+  EUR: "€",
+  GBP: "£",
+  JPY: "¥",
+  ILS: "₪",
+  INR: "₹",
+};
+
+/**
+ *
+ * @param {string} currency currency code, USD by default
+ * @returns currency sign
+ */
+export const CurrencySymbol = (currency = "USD") => {
+  return currencySymbols[currency || "USD"] || currency;
+};
+
 /**
  *
  * @param {float} amount amount to format
+ * @param {string} currency currency code of the amount
+ * @param {string} format numeral format of the amount
  * @returns formatted money with currency sign
  */
-export const MoneyDirective = (amount) => {
-  return numeral(amount).format("$ 0,0[.]00");
+export const MoneyDirective = (amount, currency, format = "0,0[.]00") => {
+  return `${CurrencySymbol(currency)} ${numeral(amount).format(format)}`;
 };